@@ -19,6 +19,11 @@ type DNSRouter interface {
 	ClearCache()
 	LookupReverseMapping(ip netip.Addr) (string, bool)
 	ResetNetwork()
+	Rules() []DNSRule
+	// RulesWithStatistics returns the current rules and their statistics from the same reload.
+	RulesWithStatistics() ([]DNSRule, []*RuleStatistics)
+	// ReloadRules creates and starts new rules with ctx, the returned function swaps them in.
+	ReloadRules(ctx context.Context, rules []option.DNSRule) (commit func(), err error)
 }
 
 type DNSClient interface {
@@ -36,6 +41,7 @@ type DNSQueryOptions struct {
 	DisableCache bool
 	RewriteTTL   *uint32
 	ClientSubnet netip.Prefix
+	DNS64Prefix  netip.Prefix
 }
 
 type RDRCStore interface {
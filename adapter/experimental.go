@@ -52,6 +52,8 @@ type CacheFile interface {
 	StoreGroupExpand(group string, expand bool) error
 	LoadRuleSet(tag string) *SavedRuleSet
 	SaveRuleSet(tag string, set *SavedRuleSet) error
+	LoadQuota(tag string) map[string]*SavedQuota
+	StoreQuota(tag string, quotas map[string]*SavedQuota) error
 }
 
 type SavedRuleSet struct {
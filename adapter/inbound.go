@@ -2,10 +2,12 @@ package adapter
 
 import (
 	"context"
+	"net"
 	"net/netip"
 	"time"
 
 	"github.com/sagernet/sing-box/common/process"
+	"github.com/sagernet/sing-box/common/ratelimit"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
@@ -56,8 +58,14 @@ type InboundContext struct {
 	Protocol     string
 	Domain       string
 	Client       string
+	ALPN         []string
+	ECH          bool
 	SniffContext any
 
+	HTTPMethod    string
+	HTTPPath      string
+	HTTPUserAgent string
+
 	// cache
 
 	// Deprecated: implement in rule action
@@ -73,6 +81,14 @@ type InboundContext struct {
 	UDPTimeout                time.Duration
 	TLSFragment               bool
 	TLSFragmentFallbackDelay  time.Duration
+	QUICFragment              bool
+	QUICFragmentReorder       bool
+	QUICFragmentPadding       bool
+	HTTPHostSplit             bool
+	HTTPHostMixCase           bool
+	HTTPHostExtraSpace        bool
+	RateLimit                 *ratelimit.Group
+	RateLimitKey              string
 
 	NetworkStrategy     *C.NetworkStrategy
 	NetworkType         []C.InterfaceType
@@ -83,6 +99,8 @@ type InboundContext struct {
 	SourceGeoIPCode      string
 	GeoIPCode            string
 	ProcessInfo          *process.Info
+	SourceMACAddress     net.HardwareAddr
+	SourceDevice         string
 	QueryType            uint16
 	FakeIP               bool
 
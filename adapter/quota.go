@@ -0,0 +1,72 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	N "github.com/sagernet/sing/common/network"
+)
+
+type QuotaManager interface {
+	LifecycleService
+	// Exhausted reports whether any quota of the user is exhausted, and the outbound to reroute to, if configured.
+	Exhausted(user string) (outbound string, exhausted bool)
+	RoutedConnection(ctx context.Context, conn net.Conn, metadata InboundContext) net.Conn
+	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext) N.PacketConn
+	Quotas() []QuotaStatus
+	ResetQuota(tag string, user string) error
+}
+
+type QuotaStatus struct {
+	Tag       string
+	User      string
+	Limit     uint64
+	Used      uint64
+	LastReset time.Time
+	NextReset time.Time
+}
+
+type SavedQuota struct {
+	Used      uint64
+	LastReset time.Time
+}
+
+func (q *SavedQuota) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, q.Used)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, q.LastReset.Unix())
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (q *SavedQuota) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	var version uint8
+	err := binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return err
+	}
+	err = binary.Read(reader, binary.BigEndian, &q.Used)
+	if err != nil {
+		return err
+	}
+	var lastReset int64
+	err = binary.Read(reader, binary.BigEndian, &lastReset)
+	if err != nil {
+		return err
+	}
+	q.LastReset = time.Unix(lastReset, 0)
+	return nil
+}
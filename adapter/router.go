@@ -5,9 +5,13 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"net/netip"
 	"sync"
+	"time"
 
+	"github.com/sagernet/sing-box/common/geoip"
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 	"github.com/sagernet/sing/common/ntp"
@@ -22,12 +26,39 @@ type Router interface {
 	PreMatch(metadata InboundContext) error
 	ConnectionRouterEx
 	RuleSet(tag string) (RuleSet, bool)
+	RuleSets() []RuleSet
+	LookupASN(addr netip.Addr) (geoip.ASN, bool)
 	NeedWIFIState() bool
+	HasDeviceAlias(alias string) bool
 	Rules() []Rule
+	// RulesWithStatistics returns the current rules and their statistics from the same reload.
+	RulesWithStatistics() ([]Rule, []*RuleStatistics)
+	Reload(rules []option.Rule, ruleSets []option.RuleSet, dnsRules []option.DNSRule) error
+	Script() Script
+	CompileScript(content string) (Script, error)
+	UpdateScript(content string) error
 	SetTracker(tracker ConnectionTracker)
 	ResetNetwork()
 }
 
+// ReloadRules replaces route rules, rule-sets and DNS rules of the router with those in options,
+// other options are ignored.
+func ReloadRules(router Router, options option.Options) error {
+	var (
+		rules    []option.Rule
+		ruleSets []option.RuleSet
+		dnsRules []option.DNSRule
+	)
+	if options.Route != nil {
+		rules = options.Route.Rules
+		ruleSets = options.Route.RuleSet
+	}
+	if options.DNS != nil {
+		dnsRules = options.DNS.Rules
+	}
+	return router.Reload(rules, ruleSets, dnsRules)
+}
+
 type ConnectionTracker interface {
 	RoutedConnection(ctx context.Context, conn net.Conn, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) net.Conn
 	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) N.PacketConn
@@ -56,12 +87,36 @@ type RuleSet interface {
 	Cleanup()
 	RegisterCallback(callback RuleSetUpdateCallback) *list.Element[RuleSetUpdateCallback]
 	UnregisterCallback(element *list.Element[RuleSetUpdateCallback])
+	Status() RuleSetStatus
+	// Refresh reloads the rule-set from its file or URL immediately.
+	Refresh(ctx context.Context) error
 	Close() error
 	HeadlessRule
 }
 
+type RuleSetStatus struct {
+	Type        string
+	Format      string
+	Behavior    string
+	RuleCount   int
+	LastUpdated time.Time
+}
+
 type RuleSetUpdateCallback func(it RuleSet)
 
+type MutableRuleSet interface {
+	RuleSet
+	Content() MutableRuleSetContent
+	// Update removes and then adds entries, the result is saved to the cache file if enabled.
+	Update(add MutableRuleSetContent, remove MutableRuleSetContent) error
+}
+
+type MutableRuleSetContent struct {
+	Domain       []string `json:"domain,omitempty"`
+	DomainSuffix []string `json:"domain_suffix,omitempty"`
+	IPCIDR       []string `json:"ip_cidr,omitempty"`
+}
+
 type RuleSetMetadata struct {
 	ContainsProcessRule bool
 	ContainsWIFIRule    bool
@@ -2,6 +2,7 @@ package adapter
 
 import (
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common/atomic"
 )
 
 type HeadlessRule interface {
@@ -22,6 +23,18 @@ type DNSRule interface {
 	MatchAddressLimit(metadata *InboundContext) bool
 }
 
+type RuleStatistics struct {
+	Matches  atomic.Int64
+	Upload   atomic.Int64
+	Download atomic.Int64
+}
+
+func (s *RuleStatistics) Reset() {
+	s.Matches.Store(0)
+	s.Upload.Store(0)
+	s.Download.Store(0)
+}
+
 type RuleAction interface {
 	Type() string
 	String() string
@@ -29,7 +42,7 @@ type RuleAction interface {
 
 func IsFinalAction(action RuleAction) bool {
 	switch action.Type() {
-	case C.RuleActionTypeSniff, C.RuleActionTypeResolve:
+	case C.RuleActionTypeSniff, C.RuleActionTypeResolve, C.RuleActionTypeScript:
 		return false
 	default:
 		return true
@@ -0,0 +1,8 @@
+package adapter
+
+type Script interface {
+	// Call runs the named script function with the metadata of the connection.
+	// The result is nil, bool, int64, float64 or string.
+	Call(function string, metadata *InboundContext) (any, error)
+	HasFunction(function string) bool
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"runtime/debug"
 	"time"
 
@@ -175,6 +176,14 @@ func New(options Options) (*Box, error) {
 	service.MustRegister[adapter.NetworkManager](ctx, networkManager)
 	connectionManager := route.NewConnectionManager(logFactory.NewLogger("connection"))
 	service.MustRegister[adapter.ConnectionManager](ctx, connectionManager)
+	if len(routeOptions.Quotas) > 0 {
+		quotaManager, err := route.NewQuotaManager(ctx, logFactory.NewLogger("quota"), routeOptions.Quotas)
+		if err != nil {
+			return nil, E.Cause(err, "initialize quota manager")
+		}
+		service.MustRegister[adapter.QuotaManager](ctx, quotaManager)
+		services = append(services, quotaManager)
+	}
 	router := route.NewRouter(ctx, logFactory, routeOptions, dnsOptions)
 	service.MustRegister[adapter.Router](ctx, router)
 	err = router.Initialize(routeOptions.Rules, routeOptions.RuleSet)
@@ -312,7 +321,7 @@ func New(options Options) (*Box, error) {
 		services = append(services, clashServer)
 	}
 	if needV2RayAPI {
-		v2rayServer, err := experimental.NewV2RayServer(logFactory.NewLogger("v2ray-api"), common.PtrValueOrDefault(experimentalOptions.V2RayAPI))
+		v2rayServer, err := experimental.NewV2RayServer(ctx, logFactory.NewLogger("v2ray-api"), common.PtrValueOrDefault(experimentalOptions.V2RayAPI))
 		if err != nil {
 			return nil, E.Cause(err, "create v2ray-server")
 		}
@@ -481,6 +490,34 @@ func (s *Box) Router() adapter.Router {
 	return s.router
 }
 
+// ReloadRules replaces route rules, rule-sets and DNS rules with those in options,
+// other options are ignored. In-flight connections are not affected.
+func (s *Box) ReloadRules(options option.Options) error {
+	return adapter.ReloadRules(s.router, options)
+}
+
+// OnlyRulesChanged reports whether newOptions differs from options only in
+// route rules, rule-sets and DNS rules, so that ReloadRules can be used instead of a restart.
+func OnlyRulesChanged(options option.Options, newOptions option.Options) bool {
+	return reflect.DeepEqual(withoutRules(options), withoutRules(newOptions))
+}
+
+func withoutRules(options option.Options) option.Options {
+	options.RawMessage = nil
+	if options.Route != nil {
+		routeOptions := *options.Route
+		routeOptions.Rules = nil
+		routeOptions.RuleSet = nil
+		options.Route = &routeOptions
+	}
+	if options.DNS != nil {
+		dnsOptions := *options.DNS
+		dnsOptions.Rules = nil
+		options.DNS = &dnsOptions
+	}
+	return options
+}
+
 func (s *Box) Inbound() adapter.InboundManager {
 	return s.inbound
 }
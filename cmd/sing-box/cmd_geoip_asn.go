@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/sagernet/sing-box/common/geoip"
+	"github.com/sagernet/sing-box/log"
+
+	"github.com/spf13/cobra"
+)
+
+var asnReader *geoip.ASNReader
+
+var commandGeoipASN = &cobra.Command{
+	Use:   "asn",
+	Short: "ASN database tools",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		err := geoipASNPreRun(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandGeoip.AddCommand(commandGeoipASN)
+}
+
+func geoipASNPreRun(cmd *cobra.Command) error {
+	path := "asn.mmdb"
+	if cmd.Flags().Changed("file") {
+		path = commandGeoIPFlagFile
+	}
+	reader, err := geoip.OpenASN(path)
+	if err != nil {
+		return err
+	}
+	asnReader = reader
+	return nil
+}
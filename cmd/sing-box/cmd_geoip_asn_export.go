@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/geoip"
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/spf13/cobra"
+	"go4.org/netipx"
+)
+
+var flagGeoipASNExportOutput string
+
+const flagGeoipASNExportDefaultOutput = "asn-<asn>.srs"
+
+var commandGeoipASNExport = &cobra.Command{
+	Use:   "export <asn or organization>...",
+	Short: "Export networks of ASNs as binary rule-set",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := geoipASNExport(args)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandGeoipASNExport.Flags().StringVarP(&flagGeoipASNExportOutput, "output", "o", flagGeoipASNExportDefaultOutput, "Output path")
+	commandGeoipASN.AddCommand(commandGeoipASNExport)
+}
+
+func geoipASNExport(values []string) error {
+	matcher, err := geoip.NewASNMatcher(values)
+	if err != nil {
+		return err
+	}
+	var builder netipx.IPSetBuilder
+	var found bool
+	err = asnReader.Range(func(prefix netip.Prefix, asn geoip.ASN) error {
+		if matcher.Match(asn) {
+			builder.AddPrefix(prefix)
+			found = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return E.New("no networks found for ", strings.Join(values, ", "))
+	}
+	ipSet, err := builder.IPSet()
+	if err != nil {
+		return err
+	}
+	prefixes := ipSet.Prefixes()
+	var headlessRule option.DefaultHeadlessRule
+	headlessRule.IPCIDR = make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		headlessRule.IPCIDR = append(headlessRule.IPCIDR, prefix.String())
+	}
+	plainRuleSet := option.PlainRuleSet{
+		Rules: []option.HeadlessRule{
+			{
+				Type:           C.RuleTypeDefault,
+				DefaultOptions: headlessRule,
+			},
+		},
+	}
+	var (
+		outputPath   string
+		outputWriter io.Writer
+	)
+	switch flagGeoipASNExportOutput {
+	case "stdout":
+		outputWriter = os.Stdout
+	case flagGeoipASNExportDefaultOutput:
+		outputPath = "asn-" + strings.ReplaceAll(strings.ToLower(values[0]), " ", "-") + ".srs"
+	default:
+		outputPath = flagGeoipASNExportOutput
+	}
+	if outputWriter != nil {
+		return srs.Write(outputWriter, plainRuleSet, C.RuleSetVersion2)
+	}
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	err = srs.Write(outputFile, plainRuleSet, C.RuleSetVersion2)
+	if err != nil {
+		outputFile.Close()
+		os.Remove(outputPath)
+		return err
+	}
+	return outputFile.Close()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/netip"
+	"os"
+
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/spf13/cobra"
+)
+
+var commandGeoipASNLookup = &cobra.Command{
+	Use:   "lookup <address>",
+	Short: "Lookup the ASN of an IP address",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := geoipASNLookup(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandGeoipASN.AddCommand(commandGeoipASNLookup)
+}
+
+func geoipASNLookup(address string) error {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return E.Cause(err, "parse address")
+	}
+	if !N.IsPublicAddr(addr) {
+		os.Stdout.WriteString("private\n")
+		return nil
+	}
+	asn, loaded := asnReader.Lookup(addr)
+	if !loaded {
+		os.Stdout.WriteString("unknown\n")
+		return nil
+	}
+	os.Stdout.WriteString(asn.String() + "\n")
+	return nil
+}
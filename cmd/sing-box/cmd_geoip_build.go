@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sagernet/sing-box/cmd/sing-box/internal/convertor/plain"
+	"github.com/sagernet/sing-box/common/geoip"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/spf13/cobra"
+)
+
+var commandGeoipBuildOutput string
+
+var commandGeoipBuild = &cobra.Command{
+	Use:   "build <source-directory>",
+	Short: "Build geoip database from a directory of CIDR lists named by country code",
+	Args:  cobra.ExactArgs(1),
+	// overrides opening the geoip file
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := geoipBuild(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandGeoipBuild.Flags().StringVarP(&commandGeoipBuildOutput, "output", "o", "geoip.db", "Output path")
+	commandGeoip.AddCommand(commandGeoipBuild)
+}
+
+func geoipBuild(sourcePath string) error {
+	dirEntries, err := os.ReadDir(sourcePath)
+	if err != nil {
+		return err
+	}
+	codes := make(map[string][]netip.Prefix)
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+		code := strings.ToLower(strings.TrimSuffix(dirEntry.Name(), filepath.Ext(dirEntry.Name())))
+		prefixes, err := readGeoipSource(filepath.Join(sourcePath, dirEntry.Name()))
+		if err != nil {
+			return E.Cause(err, "read ", dirEntry.Name())
+		}
+		codes[code] = append(codes[code], prefixes...)
+	}
+	if len(codes) == 0 {
+		return E.New("no CIDR lists found in ", sourcePath)
+	}
+	outputFile, err := os.Create(commandGeoipBuildOutput)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+	bufferedWriter := bufio.NewWriter(outputFile)
+	err = geoip.Write(bufferedWriter, codes)
+	if err != nil {
+		outputFile.Close()
+		os.Remove(commandGeoipBuildOutput)
+		return err
+	}
+	err = bufferedWriter.Flush()
+	if err != nil {
+		return err
+	}
+	log.Info("built ", len(codes), " codes")
+	return nil
+}
+
+func readGeoipSource(path string) ([]netip.Prefix, error) {
+	sourceFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Close()
+	rules, err := plain.ConvertIPCIDR(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []netip.Prefix
+	for _, rule := range rules {
+		for _, prefixString := range rule.DefaultOptions.IPCIDR {
+			prefixes = append(prefixes, netip.MustParsePrefix(prefixString))
+		}
+	}
+	return prefixes, nil
+}
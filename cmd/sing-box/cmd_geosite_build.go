@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/sagernet/sing-box/common/geosite"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/spf13/cobra"
+)
+
+var commandGeositeBuildOutput string
+
+var commandGeositeBuild = &cobra.Command{
+	Use:   "build <data-directory>",
+	Short: "Build geosite database from a domain-list-community style data directory",
+	Args:  cobra.ExactArgs(1),
+	// overrides opening the geosite file
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := geositeBuild(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandGeositeBuild.Flags().StringVarP(&commandGeositeBuildOutput, "output", "o", "geosite.db", "Output path")
+	commandGeoSite.AddCommand(commandGeositeBuild)
+}
+
+func geositeBuild(sourcePath string) error {
+	domains, err := geosite.ReadSource(sourcePath)
+	if err != nil {
+		return err
+	}
+	if len(domains) == 0 {
+		return E.New("no categories found in ", sourcePath)
+	}
+	outputFile, err := os.Create(commandGeositeBuildOutput)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+	bufferedWriter := bufio.NewWriter(outputFile)
+	err = geosite.Write(bufferedWriter, domains)
+	if err != nil {
+		outputFile.Close()
+		os.Remove(commandGeositeBuildOutput)
+		return err
+	}
+	err = bufferedWriter.Flush()
+	if err != nil {
+		return err
+	}
+	log.Info("built ", len(domains), " categories")
+	return nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/process"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/route"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagRouteTestDomain        string
+	flagRouteTestIP            string
+	flagRouteTestPort          uint16
+	flagRouteTestNetwork       string
+	flagRouteTestSource        string
+	flagRouteTestInbound       string
+	flagRouteTestUser          string
+	flagRouteTestProcessName   string
+	flagRouteTestProcessPath   string
+	flagRouteTestProcessParent string
+	flagRouteTestAncestorPath  []string
+	flagRouteTestCmdline       string
+	flagRouteTestCgroup        string
+	flagRouteTestPackageName   string
+	flagRouteTestSniffProtocol string
+	flagRouteTestSniffDomain   string
+	flagRouteTestSniffClient   string
+	flagRouteTestSniffALPN     []string
+	flagRouteTestSniffECH      bool
+	flagRouteTestResolve       []string
+	flagRouteTestNoDNS         bool
+	flagRouteTestLog           bool
+)
+
+var commandRouteTest = &cobra.Command{
+	Use:   "test",
+	Short: "Simulate routing of a connection",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := routeTest()
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	flags := commandRouteTest.Flags()
+	flags.StringVar(&flagRouteTestDomain, "domain", "", "destination domain")
+	flags.StringVar(&flagRouteTestIP, "ip", "", "destination IP address")
+	flags.Uint16Var(&flagRouteTestPort, "port", 443, "destination port")
+	flags.StringVarP(&flagRouteTestNetwork, "network", "n", N.NetworkTCP, "network type")
+	flags.StringVar(&flagRouteTestSource, "source", "", "source address (ip or ip:port)")
+	flags.StringVar(&flagRouteTestInbound, "inbound", "", "inbound tag")
+	flags.StringVar(&flagRouteTestUser, "user", "", "authenticated inbound user")
+	flags.StringVar(&flagRouteTestProcessName, "process", "", "process name")
+	flags.StringVar(&flagRouteTestProcessPath, "process-path", "", "process path")
+	flags.StringVar(&flagRouteTestProcessParent, "process-parent", "", "parent process name")
+	flags.StringArrayVar(&flagRouteTestAncestorPath, "process-ancestor", nil, "ancestor process path")
+	flags.StringVar(&flagRouteTestCmdline, "process-cmdline", "", "process command line")
+	flags.StringVar(&flagRouteTestCgroup, "process-cgroup", "", "process cgroup v2 path")
+	flags.StringVar(&flagRouteTestPackageName, "package", "", "android package name")
+	flags.StringVar(&flagRouteTestSniffProtocol, "sniff-protocol", "", "protocol returned by sniff actions")
+	flags.StringVar(&flagRouteTestSniffDomain, "sniff-domain", "", "domain returned by sniff actions")
+	flags.StringVar(&flagRouteTestSniffClient, "sniff-client", "", "client returned by sniff actions")
+	flags.StringSliceVar(&flagRouteTestSniffALPN, "sniff-alpn", nil, "ALPN protocols returned by sniff actions")
+	flags.BoolVar(&flagRouteTestSniffECH, "sniff-ech", false, "ECH offered returned by sniff actions")
+	flags.StringArrayVar(&flagRouteTestResolve, "resolve", nil, "stub DNS result for resolve actions (domain=ip[,ip])")
+	flags.BoolVar(&flagRouteTestNoDNS, "no-dns", false, "fail resolve actions without a stub instead of querying DNS")
+	flags.BoolVar(&flagRouteTestLog, "log", false, "keep log output from configuration")
+	commandRoute.AddCommand(commandRouteTest)
+}
+
+func routeTest() error {
+	metadata, err := routeTestMetadata()
+	if err != nil {
+		return err
+	}
+	simulateOptions := route.SimulateOptions{
+		SniffProtocol: flagRouteTestSniffProtocol,
+		SniffDomain:   flagRouteTestSniffDomain,
+		SniffClient:   flagRouteTestSniffClient,
+		SniffALPN:     flagRouteTestSniffALPN,
+		SniffECH:      flagRouteTestSniffECH,
+		DNSStubs:      make(map[string][]netip.Addr),
+		DisableDNS:    flagRouteTestNoDNS,
+	}
+	for _, stub := range flagRouteTestResolve {
+		domain, addressList, loaded := strings.Cut(stub, "=")
+		if !loaded {
+			return E.New("invalid DNS stub: ", stub)
+		}
+		var addresses []netip.Addr
+		for _, addressString := range strings.Split(addressList, ",") {
+			address, err := netip.ParseAddr(addressString)
+			if err != nil {
+				return E.Cause(err, "parse DNS stub: ", stub)
+			}
+			addresses = append(addresses, address)
+		}
+		simulateOptions.DNSStubs[strings.ToLower(domain)] = addresses
+	}
+	options, err := readConfigAndMerge()
+	if err != nil {
+		return err
+	}
+	if !flagRouteTestLog {
+		options.Log = &option.LogOptions{Disabled: true}
+	}
+	if options.Experimental != nil {
+		// do not open or modify the cache file of a running instance
+		options.Experimental.CacheFile = nil
+	}
+	ctx, cancel := context.WithCancel(globalCtx)
+	defer cancel()
+	instance, err := box.New(box.Options{Context: ctx, Options: options})
+	if err != nil {
+		return E.Cause(err, "create service")
+	}
+	defer instance.Close()
+	err = instance.PreStart()
+	if err != nil {
+		return E.Cause(err, "start service")
+	}
+	err = instance.Router().Start(adapter.StartStatePostStart)
+	if err != nil {
+		return E.Cause(err, "start router")
+	}
+	if metadata.Inbound != "" {
+		inbound, loaded := instance.Inbound().Get(metadata.Inbound)
+		if !loaded {
+			return E.New("inbound not found: ", metadata.Inbound)
+		}
+		metadata.InboundType = inbound.Type()
+	}
+	router, isRouter := instance.Router().(*route.Router)
+	if !isRouter {
+		return E.New("unsupported router")
+	}
+	result, err := router.Simulate(ctx, metadata, simulateOptions)
+	if err != nil {
+		return err
+	}
+	for _, step := range result.Steps {
+		if step.RuleIndex < 0 {
+			os.Stdout.WriteString("inbound\n")
+		} else if description := step.Rule.String(); description != "" {
+			os.Stdout.WriteString(F.ToString("match[", step.RuleIndex, "] ", description, " => ", step.Rule.Action(), "\n"))
+		} else {
+			os.Stdout.WriteString(F.ToString("match[", step.RuleIndex, "] => ", step.Rule.Action(), "\n"))
+		}
+		for _, details := range step.Details {
+			os.Stdout.WriteString("  " + details + "\n")
+		}
+	}
+	os.Stdout.WriteString(F.ToString("destination: ", result.Metadata.Destination, "\n"))
+	if result.SelectedRule != nil {
+		os.Stdout.WriteString(F.ToString("final rule: ", result.SelectedRuleIndex, "\n"))
+	} else {
+		os.Stdout.WriteString("final rule: none\n")
+	}
+	if result.Outbound != "" {
+		os.Stdout.WriteString("outbound: " + result.Outbound + "\n")
+	} else {
+		os.Stdout.WriteString(F.ToString("action: ", result.SelectedRule.Action(), "\n"))
+	}
+	return nil
+}
+
+func routeTestMetadata() (adapter.InboundContext, error) {
+	var metadata adapter.InboundContext
+	switch N.NetworkName(flagRouteTestNetwork) {
+	case N.NetworkTCP, N.NetworkUDP:
+		metadata.Network = N.NetworkName(flagRouteTestNetwork)
+	default:
+		return metadata, E.Cause(N.ErrUnknownNetwork, flagRouteTestNetwork)
+	}
+	switch {
+	case flagRouteTestDomain != "" && flagRouteTestIP != "":
+		address, err := netip.ParseAddr(flagRouteTestIP)
+		if err != nil {
+			return metadata, E.Cause(err, "parse destination IP")
+		}
+		// a connection to an IP address with a known domain, e.g. from reverse mapping
+		metadata.Destination = M.SocksaddrFrom(address, flagRouteTestPort)
+		metadata.Domain = flagRouteTestDomain
+	case flagRouteTestIP != "":
+		address, err := netip.ParseAddr(flagRouteTestIP)
+		if err != nil {
+			return metadata, E.Cause(err, "parse destination IP")
+		}
+		metadata.Destination = M.SocksaddrFrom(address, flagRouteTestPort)
+	case flagRouteTestDomain != "":
+		metadata.Destination = M.Socksaddr{Fqdn: flagRouteTestDomain, Port: flagRouteTestPort}
+	default:
+		return metadata, E.New("missing destination: --domain or --ip is required")
+	}
+	if flagRouteTestSource != "" {
+		metadata.Source = M.ParseSocksaddr(flagRouteTestSource)
+		if !metadata.Source.IsIP() {
+			return metadata, E.New("invalid source address: ", flagRouteTestSource)
+		}
+	}
+	metadata.Inbound = flagRouteTestInbound
+	metadata.User = flagRouteTestUser
+	// process information is never searched for simulated connections
+	metadata.ProcessInfo = &process.Info{
+		ProcessPath:       flagRouteTestProcessPath,
+		PackageName:       flagRouteTestPackageName,
+		UserId:            -1,
+		ProcessCmdline:    flagRouteTestCmdline,
+		ParentProcessName: flagRouteTestProcessParent,
+		AncestorPaths:     flagRouteTestAncestorPath,
+		CgroupPath:        strings.TrimPrefix(flagRouteTestCgroup, "/"),
+	}
+	if flagRouteTestProcessName != "" && flagRouteTestProcessPath == "" {
+		metadata.ProcessInfo.ProcessPath = flagRouteTestProcessName
+	}
+	return metadata, nil
+}
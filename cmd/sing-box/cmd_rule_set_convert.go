@@ -6,6 +6,10 @@ import (
 	"strings"
 
 	"github.com/sagernet/sing-box/cmd/sing-box/internal/convertor/adguard"
+	"github.com/sagernet/sing-box/cmd/sing-box/internal/convertor/dnsmasq"
+	"github.com/sagernet/sing-box/cmd/sing-box/internal/convertor/hosts"
+	"github.com/sagernet/sing-box/cmd/sing-box/internal/convertor/plain"
+	"github.com/sagernet/sing-box/cmd/sing-box/internal/convertor/surge"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -22,7 +26,7 @@ var (
 
 var commandRuleSetConvert = &cobra.Command{
 	Use:   "convert [source-path]",
-	Short: "Convert adguard DNS filter to rule-set",
+	Short: "Convert rule lists of other formats to rule-set",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		err := convertRuleSet(args[0])
@@ -34,7 +38,7 @@ var commandRuleSetConvert = &cobra.Command{
 
 func init() {
 	commandRuleSet.AddCommand(commandRuleSetConvert)
-	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertType, "type", "t", "", "Source type, available: adguard")
+	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertType, "type", "t", "", "Source type, available: adguard, surge, quantumultx, loon, hosts, dnsmasq, domain, ipcidr")
 	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertOutput, "output", "o", flagRuleSetCompileDefaultOutput, "Output file")
 }
 
@@ -55,6 +59,16 @@ func convertRuleSet(sourcePath string) error {
 	switch flagRuleSetConvertType {
 	case "adguard":
 		rules, err = adguard.Convert(reader)
+	case "surge", "quantumultx", "loon":
+		rules, err = surge.Convert(reader)
+	case "hosts":
+		rules, err = hosts.Convert(reader)
+	case "dnsmasq":
+		rules, err = dnsmasq.Convert(reader)
+	case "domain":
+		rules, err = plain.ConvertDomain(reader)
+	case "ipcidr":
+		rules, err = plain.ConvertIPCIDR(reader)
 	case "":
 		return E.New("source type is required")
 	default:
@@ -63,6 +77,9 @@ func convertRuleSet(sourcePath string) error {
 	if err != nil {
 		return err
 	}
+	if len(rules) == 0 {
+		return E.New("no supported rules found")
+	}
 	var outputPath string
 	if flagRuleSetConvertOutput == flagRuleSetCompileDefaultOutput {
 		if strings.HasSuffix(sourcePath, ".txt") {
@@ -73,6 +90,9 @@ func convertRuleSet(sourcePath string) error {
 	} else {
 		outputPath = flagRuleSetConvertOutput
 	}
+	if outputPath == sourcePath {
+		return E.New("output path is the same as the source path")
+	}
 	outputFile, err := os.Create(outputPath)
 	if err != nil {
 		return err
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/cmd/sing-box/internal/ruleset"
+	"github.com/sagernet/sing-box/log"
+
+	"github.com/spf13/cobra"
+)
+
+var commandRuleSetDiff = &cobra.Command{
+	Use:   "diff <old-path> <new-path>",
+	Short: "Print added and removed entries between rule-sets",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := diffRuleSet(args[0], args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSet.AddCommand(commandRuleSetDiff)
+}
+
+func diffRuleSet(oldPath string, newPath string) error {
+	oldRuleSet, err := readRuleSetFile(oldPath)
+	if err != nil {
+		return err
+	}
+	oldPlainRuleSet, err := oldRuleSet.Upgrade()
+	if err != nil {
+		return err
+	}
+	newRuleSet, err := readRuleSetFile(newPath)
+	if err != nil {
+		return err
+	}
+	newPlainRuleSet, err := newRuleSet.Upgrade()
+	if err != nil {
+		return err
+	}
+	diffItems, err := ruleset.Diff(oldPlainRuleSet.Rules, newPlainRuleSet.Rules)
+	if err != nil {
+		return err
+	}
+	var builder strings.Builder
+	for _, item := range diffItems {
+		builder.WriteString(item.ItemType)
+		builder.WriteString(":\n")
+		for _, entry := range item.Added {
+			builder.WriteString("+ ")
+			builder.WriteString(entry)
+			builder.WriteString("\n")
+		}
+		for _, entry := range item.Removed {
+			builder.WriteString("- ")
+			builder.WriteString(entry)
+			builder.WriteString("\n")
+		}
+	}
+	_, err = os.Stdout.WriteString(builder.String())
+	return err
+}
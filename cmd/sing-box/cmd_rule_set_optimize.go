@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sagernet/sing-box/cmd/sing-box/internal/ruleset"
+	"github.com/sagernet/sing-box/common/srs"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/spf13/cobra"
+)
+
+var flagRuleSetOptimizeOutput string
+
+const flagRuleSetOptimizeDefaultOutput = "<file_name>.optimized.srs"
+
+var commandRuleSetOptimize = &cobra.Command{
+	Use:   "optimize [source-path]",
+	Short: "Remove redundant entries from rule-set",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := optimizeRuleSet(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSet.AddCommand(commandRuleSetOptimize)
+	commandRuleSetOptimize.Flags().StringVarP(&flagRuleSetOptimizeOutput, "output", "o", flagRuleSetOptimizeDefaultOutput, "Output file")
+}
+
+func optimizeRuleSet(sourcePath string) error {
+	ruleSet, err := readRuleSetFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	plainRuleSet, err := ruleSet.Upgrade()
+	if err != nil {
+		return err
+	}
+	oldEntries, err := ruleset.Entries(plainRuleSet.Rules)
+	if err != nil {
+		return err
+	}
+	plainRuleSet.Rules, err = ruleset.Optimize(plainRuleSet.Rules)
+	if err != nil {
+		return err
+	}
+	newEntries, err := ruleset.Entries(plainRuleSet.Rules)
+	if err != nil {
+		return err
+	}
+	var outputPath string
+	if flagRuleSetOptimizeOutput == flagRuleSetOptimizeDefaultOutput {
+		outputPath = sourcePath[:len(sourcePath)-len(filepath.Ext(sourcePath))] + ".optimized.srs"
+	} else {
+		outputPath = flagRuleSetOptimizeOutput
+	}
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	err = srs.Write(outputFile, plainRuleSet, ruleSet.Version)
+	if err != nil {
+		outputFile.Close()
+		os.Remove(outputPath)
+		return err
+	}
+	outputFile.Close()
+	log.Info("optimized entries: ", countEntries(oldEntries), " -> ", countEntries(newEntries))
+	return nil
+}
+
+// readRuleSetFile reads a source or binary rule-set, binary rule items are recovered to lists.
+func readRuleSetFile(sourcePath string) (option.PlainRuleSetCompat, error) {
+	var (
+		content []byte
+		err     error
+	)
+	if sourcePath == "stdin" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(sourcePath)
+	}
+	if err != nil {
+		return option.PlainRuleSetCompat{}, err
+	}
+	if bytes.HasPrefix(content, srs.MagicBytes[:]) {
+		ruleSet, err := srs.Read(bytes.NewReader(content), true)
+		if err != nil {
+			return option.PlainRuleSetCompat{}, E.Cause(err, "read rule-set at ", sourcePath)
+		}
+		return ruleSet, nil
+	}
+	ruleSet, err := json.UnmarshalExtended[option.PlainRuleSetCompat](content)
+	if err != nil {
+		return option.PlainRuleSetCompat{}, E.Cause(err, "decode rule-set at ", sourcePath)
+	}
+	return ruleSet, nil
+}
+
+func countEntries(entries map[string][]string) int {
+	var count int
+	for _, entryList := range entries {
+		count += len(entryList)
+	}
+	return count
+}
@@ -122,10 +122,10 @@ func readConfigAndMerge() (option.Options, error) {
 	return mergedOptions, nil
 }
 
-func create() (*box.Box, context.CancelFunc, error) {
+func readRunConfig() (option.Options, error) {
 	options, err := readConfigAndMerge()
 	if err != nil {
-		return nil, nil, err
+		return option.Options{}, err
 	}
 	if disableColor {
 		if options.Log == nil {
@@ -133,6 +133,14 @@ func create() (*box.Box, context.CancelFunc, error) {
 		}
 		options.Log.DisableColor = true
 	}
+	return options, nil
+}
+
+func create() (*box.Box, option.Options, context.CancelFunc, error) {
+	options, err := readRunConfig()
+	if err != nil {
+		return nil, option.Options{}, nil, err
+	}
 	ctx, cancel := context.WithCancel(globalCtx)
 	instance, err := box.New(box.Options{
 		Context: ctx,
@@ -140,7 +148,7 @@ func create() (*box.Box, context.CancelFunc, error) {
 	})
 	if err != nil {
 		cancel()
-		return nil, nil, E.Cause(err, "create service")
+		return nil, option.Options{}, nil, E.Cause(err, "create service")
 	}
 
 	osSignals := make(chan os.Signal, 1)
@@ -161,9 +169,9 @@ func create() (*box.Box, context.CancelFunc, error) {
 	finishStart()
 	if err != nil {
 		cancel()
-		return nil, nil, E.Cause(err, "start service")
+		return nil, option.Options{}, nil, E.Cause(err, "start service")
 	}
-	return instance, cancel, nil
+	return instance, options, cancel, nil
 }
 
 func run() error {
@@ -171,7 +179,7 @@ func run() error {
 	signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 	defer signal.Stop(osSignals)
 	for {
-		instance, cancel, err := create()
+		instance, options, cancel, err := create()
 		if err != nil {
 			return err
 		}
@@ -179,6 +187,20 @@ func run() error {
 		for {
 			osSignal := <-osSignals
 			if osSignal == syscall.SIGHUP {
+				newOptions, err := readRunConfig()
+				if err != nil {
+					log.Error(E.Cause(err, "reload service"))
+					continue
+				}
+				if box.OnlyRulesChanged(options, newOptions) {
+					err = instance.ReloadRules(newOptions)
+					if err == nil {
+						options = newOptions
+						runtimeDebug.FreeOSMemory()
+						continue
+					}
+					log.Warn(E.Cause(err, "reload rules"), ", restarting service")
+				}
 				err = check()
 				if err != nil {
 					log.Error(E.Cause(err, "reload service"))
@@ -0,0 +1,68 @@
+package dnsmasq
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+// Convert converts domains of dnsmasq server=/domain/, address=/domain/ and similar options.
+// dnsmasq matches the domain and all its subdomains, so every domain becomes a domain_suffix rule.
+func Convert(reader io.Reader) ([]option.HeadlessRule, error) {
+	scanner := bufio.NewScanner(reader)
+	var (
+		domainMap  = make(map[string]bool)
+		domainList []string
+	)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		ruleLine := strings.TrimSpace(scanner.Text())
+		if ruleLine == "" || ruleLine[0] == '#' {
+			continue
+		}
+		optionName, optionValue, _ := strings.Cut(ruleLine, "=")
+		switch strings.TrimSpace(optionName) {
+		case "server", "address", "local", "ipset", "nftset":
+		default:
+			log.Warn("line ", lineNumber, ": ignored unsupported option: ", ruleLine)
+			continue
+		}
+		optionValue = strings.TrimSpace(optionValue)
+		if !strings.HasPrefix(optionValue, "/") {
+			log.Warn("line ", lineNumber, ": ignored option without domain: ", ruleLine)
+			continue
+		}
+		domains := strings.Split(optionValue[1:], "/")
+		// the last element is the upstream, address or set name
+		for _, domain := range domains[:len(domains)-1] {
+			domain = strings.ToLower(strings.Trim(domain, "."))
+			if domain == "" {
+				continue
+			}
+			if !M.IsDomainName(domain) {
+				log.Warn("line ", lineNumber, ": ignored invalid domain: ", domain)
+				continue
+			}
+			if !domainMap[domain] {
+				domainMap[domain] = true
+				domainList = append(domainList, domain)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(domainList) == 0 {
+		return nil, nil
+	}
+	return []option.HeadlessRule{{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultHeadlessRule{
+			DomainSuffix: domainList,
+		},
+	}}, nil
+}
@@ -0,0 +1,23 @@
+package dnsmasq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter(t *testing.T) {
+	t.Parallel()
+	rules, err := Convert(strings.NewReader(`
+# comment
+server=/example.com/114.114.114.114
+address=/ads.example.org/.example.net/0.0.0.0
+server=/example.com/223.5.5.5
+server=8.8.8.8
+cache-size=1000
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, []string{"example.com", "ads.example.org", "example.net"}, []string(rules[0].DefaultOptions.DomainSuffix))
+}
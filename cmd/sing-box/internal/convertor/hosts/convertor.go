@@ -0,0 +1,78 @@
+package hosts
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+var localHostnames = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"ip6-localnet":          true,
+	"ip6-mcastprefix":       true,
+	"ip6-allnodes":          true,
+	"ip6-allrouters":        true,
+	"ip6-allhosts":          true,
+	"0.0.0.0":               true,
+}
+
+// Convert converts hosts-file blocklists, every hostname becomes an exact domain rule
+// regardless of its address, while the usual local entries are skipped.
+func Convert(reader io.Reader) ([]option.HeadlessRule, error) {
+	scanner := bufio.NewScanner(reader)
+	var (
+		domainMap  = make(map[string]bool)
+		domainList []string
+	)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		ruleLine := scanner.Text()
+		if commentIndex := strings.IndexByte(ruleLine, '#'); commentIndex != -1 {
+			ruleLine = ruleLine[:commentIndex]
+		}
+		fields := strings.Fields(ruleLine)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := netip.ParseAddr(fields[0]); err != nil || len(fields) < 2 {
+			log.Warn("line ", lineNumber, ": ignored invalid hosts entry: ", scanner.Text())
+			continue
+		}
+		for _, hostname := range fields[1:] {
+			hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+			if localHostnames[hostname] {
+				continue
+			}
+			if !M.IsDomainName(hostname) {
+				log.Warn("line ", lineNumber, ": ignored invalid hostname: ", hostname)
+				continue
+			}
+			if !domainMap[hostname] {
+				domainMap[hostname] = true
+				domainList = append(domainList, hostname)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(domainList) == 0 {
+		return nil, nil
+	}
+	return []option.HeadlessRule{{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultHeadlessRule{
+			Domain: domainList,
+		},
+	}}, nil
+}
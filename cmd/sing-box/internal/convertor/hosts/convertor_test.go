@@ -0,0 +1,30 @@
+package hosts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter(t *testing.T) {
+	t.Parallel()
+	rules, err := Convert(strings.NewReader(`
+# hosts
+127.0.0.1 localhost localhost.localdomain
+::1 ip6-localhost ip6-loopback
+0.0.0.0 0.0.0.0
+0.0.0.0 ads.example.com tracker.example.com # inline comment
+127.0.0.1 Ads.Example.com. telemetry.example.org
+:: ipv6.example.net
+ads.example.com
+0.0.0.0
+0.0.0.0 invalid_host!
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, []string{"ads.example.com", "tracker.example.com", "telemetry.example.org", "ipv6.example.net"}, []string(rules[0].DefaultOptions.Domain))
+	rules, err = Convert(strings.NewReader("127.0.0.1 localhost\n"))
+	require.NoError(t, err)
+	require.Empty(t, rules)
+}
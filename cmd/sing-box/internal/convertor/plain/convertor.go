@@ -0,0 +1,84 @@
+package plain
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+// ConvertDomain converts line-by-line domain lists. Domains are matched exactly,
+// unless prefixed with `.` or `+.`, which match the domain and all its subdomains.
+func ConvertDomain(reader io.Reader) ([]option.HeadlessRule, error) {
+	var rule option.DefaultHeadlessRule
+	err := readLines(reader, func(lineNumber int, ruleLine string) {
+		domain := strings.ToLower(strings.TrimSuffix(ruleLine, "."))
+		isSuffix := strings.HasPrefix(domain, ".") || strings.HasPrefix(domain, "+.")
+		domain = strings.TrimPrefix(strings.TrimPrefix(domain, "+"), ".")
+		if !M.IsDomainName(domain) {
+			log.Warn("line ", lineNumber, ": ignored invalid domain: ", ruleLine)
+			return
+		}
+		if isSuffix {
+			rule.DomainSuffix = append(rule.DomainSuffix, domain)
+		} else {
+			rule.Domain = append(rule.Domain, domain)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return singleRule(rule), nil
+}
+
+// ConvertIPCIDR converts line-by-line lists of IP CIDRs or addresses.
+func ConvertIPCIDR(reader io.Reader) ([]option.HeadlessRule, error) {
+	var rule option.DefaultHeadlessRule
+	err := readLines(reader, func(lineNumber int, ruleLine string) {
+		prefix, err := netip.ParsePrefix(ruleLine)
+		if err != nil {
+			address, addrErr := netip.ParseAddr(ruleLine)
+			if addrErr != nil {
+				log.Warn("line ", lineNumber, ": ignored invalid IP CIDR: ", ruleLine)
+				return
+			}
+			prefix = netip.PrefixFrom(address, address.BitLen())
+		}
+		rule.IPCIDR = append(rule.IPCIDR, prefix.Masked().String())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return singleRule(rule), nil
+}
+
+func readLines(reader io.Reader, f func(lineNumber int, ruleLine string)) error {
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		ruleLine := scanner.Text()
+		if commentIndex := strings.IndexByte(ruleLine, '#'); commentIndex != -1 {
+			ruleLine = ruleLine[:commentIndex]
+		}
+		ruleLine = strings.TrimSpace(ruleLine)
+		if ruleLine == "" {
+			continue
+		}
+		f(lineNumber, ruleLine)
+	}
+	return scanner.Err()
+}
+
+func singleRule(rule option.DefaultHeadlessRule) []option.HeadlessRule {
+	if !rule.IsValid() {
+		return nil
+	}
+	return []option.HeadlessRule{{
+		Type:           C.RuleTypeDefault,
+		DefaultOptions: rule,
+	}}
+}
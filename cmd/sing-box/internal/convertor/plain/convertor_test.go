@@ -0,0 +1,43 @@
+package plain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertDomain(t *testing.T) {
+	t.Parallel()
+	rules, err := ConvertDomain(strings.NewReader(`
+# domain list
+example.com
+.example.org
++.Example.NET.
+  sub.example.io  # inline comment
+invalid_domain!
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, []string{"example.com", "sub.example.io"}, []string(rules[0].DefaultOptions.Domain))
+	require.Equal(t, []string{"example.org", "example.net"}, []string(rules[0].DefaultOptions.DomainSuffix))
+	rules, err = ConvertDomain(strings.NewReader("# empty\n"))
+	require.NoError(t, err)
+	require.Empty(t, rules)
+}
+
+func TestConvertIPCIDR(t *testing.T) {
+	t.Parallel()
+	rules, err := ConvertIPCIDR(strings.NewReader(`
+# ip list
+10.0.0.0/8
+192.168.1.1/24
+1.1.1.1
+2001:db8::/32
+2001:db8::1
+not-an-ip
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, []string{"10.0.0.0/8", "192.168.1.0/24", "1.1.1.1/32", "2001:db8::/32", "2001:db8::1/128"}, []string(rules[0].DefaultOptions.IPCIDR))
+}
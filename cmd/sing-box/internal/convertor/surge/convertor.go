@@ -0,0 +1,94 @@
+package surge
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/convertor/clash"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+)
+
+// ruleTypeAliases maps Quantumult X and Loon rule types to the Clash ones.
+var ruleTypeAliases = map[string]string{
+	"HOST":          "DOMAIN",
+	"HOST-SUFFIX":   "DOMAIN-SUFFIX",
+	"HOST-KEYWORD":  "DOMAIN-KEYWORD",
+	"HOST-WILDCARD": "DOMAIN-WILDCARD",
+	"IP6-CIDR":      "IP-CIDR6",
+	"DEST-PORT":     "DST-PORT",
+	"SRC-IP":        "SRC-IP-CIDR",
+}
+
+// Convert converts Surge, Quantumult X and Loon rule lists.
+// Policies and options after the rule value are ignored.
+func Convert(reader io.Reader) ([]option.HeadlessRule, error) {
+	scanner := bufio.NewScanner(reader)
+	var lines []clash.Line
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		ruleLine := strings.TrimSpace(scanner.Text())
+		if ruleLine == "" || ruleLine[0] == '#' || ruleLine[0] == ';' || strings.HasPrefix(ruleLine, "//") {
+			continue
+		}
+		lines = append(lines, clash.Line{
+			Position: "line " + strconv.Itoa(lineNumber),
+			Content:  normalizeRuleTypes(ruleLine),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	rules, warnings, err := clash.ConvertClassical(lines)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		log.Warn(warning)
+	}
+	return rules, nil
+}
+
+// normalizeRuleTypes rewrites aliased rule types of the line and of the sub-rules of logical rules.
+func normalizeRuleTypes(ruleLine string) string {
+	ruleType, payload, found := strings.Cut(ruleLine, ",")
+	ruleType = normalizeRuleType(ruleType)
+	if !found {
+		return ruleType
+	}
+	switch strings.ToUpper(strings.TrimSpace(ruleType)) {
+	case "AND", "OR", "NOT":
+	default:
+		return ruleType + "," + payload
+	}
+	var builder strings.Builder
+	builder.WriteString(ruleType)
+	builder.WriteByte(',')
+	for {
+		index := strings.IndexByte(payload, '(')
+		if index == -1 {
+			builder.WriteString(payload)
+			break
+		}
+		builder.WriteString(payload[:index+1])
+		payload = payload[index+1:]
+		if payload == "" || payload[0] == '(' {
+			continue
+		}
+		typeEnd := strings.IndexAny(payload, ",)")
+		if typeEnd == -1 {
+			typeEnd = len(payload)
+		}
+		builder.WriteString(normalizeRuleType(payload[:typeEnd]))
+		payload = payload[typeEnd:]
+	}
+	return builder.String()
+}
+
+func normalizeRuleType(ruleType string) string {
+	if alias, loaded := ruleTypeAliases[strings.ToUpper(strings.TrimSpace(ruleType))]; loaded {
+		return alias
+	}
+	return ruleType
+}
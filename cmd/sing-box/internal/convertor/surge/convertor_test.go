@@ -0,0 +1,39 @@
+package surge
+
+import (
+	"strings"
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter(t *testing.T) {
+	t.Parallel()
+	rules, err := Convert(strings.NewReader(`
+# Surge
+DOMAIN-SUFFIX,example.org
+IP-CIDR,10.0.0.0/8,no-resolve
+USER-AGENT,Example*
+; Quantumult X
+host,example.com,proxy
+ip6-cidr,2001:db8::/32,direct
+// Loon
+AND,((DEST-PORT,443),(HOST-KEYWORD,sagernet))
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+	require.Equal(t, []string{"example.org"}, []string(rules[0].DefaultOptions.DomainSuffix))
+	require.Equal(t, []string{"example.com"}, []string(rules[0].DefaultOptions.Domain))
+	require.Equal(t, []string{"10.0.0.0/8", "2001:db8::/32"}, []string(rules[1].DefaultOptions.IPCIDR))
+	require.Equal(t, C.RuleTypeLogical, rules[2].Type)
+	require.Equal(t, []uint16{443}, []uint16(rules[2].LogicalOptions.Rules[0].DefaultOptions.Port))
+	require.Equal(t, []string{"sagernet"}, []string(rules[2].LogicalOptions.Rules[1].DefaultOptions.DomainKeyword))
+}
+
+func TestNormalizeRuleTypes(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "DOMAIN-SUFFIX,example.com,proxy", normalizeRuleTypes("host-suffix,example.com,proxy"))
+	require.Equal(t, "OR,((DOMAIN,a.com),(NOT,((DST-PORT,80))))", normalizeRuleTypes("OR,((HOST,a.com),(NOT,((DEST-PORT,80))))"))
+}
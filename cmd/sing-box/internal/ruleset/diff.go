@@ -0,0 +1,142 @@
+package ruleset
+
+import (
+	"sort"
+	"strconv"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+)
+
+type DiffItem struct {
+	ItemType string
+	Added    []string
+	Removed  []string
+}
+
+// Entries flattens rules into entries per item type, named as in the source format.
+// Logical rules are kept as a whole under the logical type.
+func Entries(rules []option.HeadlessRule) (map[string][]string, error) {
+	entrySets := make(map[string]map[string]bool)
+	addEntry := func(itemType string, entry string) {
+		entrySet := entrySets[itemType]
+		if entrySet == nil {
+			entrySet = make(map[string]bool)
+			entrySets[itemType] = entrySet
+		}
+		entrySet[entry] = true
+	}
+	for i, rule := range rules {
+		if rule.Type == C.RuleTypeLogical {
+			content, err := json.Marshal(rule)
+			if err != nil {
+				return nil, E.Cause(err, "encode rule[", i, "]")
+			}
+			addEntry(C.RuleTypeLogical, string(content))
+			continue
+		}
+		defaultRule := rule.DefaultOptions
+		clearMatchers(&defaultRule)
+		content, err := json.Marshal(defaultRule)
+		if err != nil {
+			return nil, E.Cause(err, "encode rule[", i, "]")
+		}
+		var items map[string]any
+		err = json.Unmarshal(content, &items)
+		if err != nil {
+			return nil, E.Cause(err, "decode rule[", i, "]")
+		}
+		for itemType, value := range items {
+			switch itemValue := value.(type) {
+			case []any:
+				for _, element := range itemValue {
+					addEntry(itemType, entryString(element))
+				}
+			default:
+				addEntry(itemType, entryString(itemValue))
+			}
+		}
+	}
+	entries := make(map[string][]string, len(entrySets))
+	for itemType, entrySet := range entrySets {
+		entryList := make([]string, 0, len(entrySet))
+		for entry := range entrySet {
+			entryList = append(entryList, entry)
+		}
+		switch itemType {
+		case "ip_cidr", "source_ip_cidr":
+			// compare address ranges instead of how they are split into prefixes
+			var err error
+			entryList, err = mergePrefixes(entryList)
+			if err != nil {
+				return nil, E.Cause(err, itemType)
+			}
+		}
+		sort.Strings(entryList)
+		entries[itemType] = entryList
+	}
+	return entries, nil
+}
+
+func Diff(oldRules []option.HeadlessRule, newRules []option.HeadlessRule) ([]DiffItem, error) {
+	oldEntries, err := Entries(oldRules)
+	if err != nil {
+		return nil, err
+	}
+	newEntries, err := Entries(newRules)
+	if err != nil {
+		return nil, err
+	}
+	itemTypes := make(map[string]bool)
+	for itemType := range oldEntries {
+		itemTypes[itemType] = true
+	}
+	for itemType := range newEntries {
+		itemTypes[itemType] = true
+	}
+	var diffItems []DiffItem
+	for itemType := range itemTypes {
+		diffItem := DiffItem{
+			ItemType: itemType,
+			Added:    subtract(newEntries[itemType], oldEntries[itemType]),
+			Removed:  subtract(oldEntries[itemType], newEntries[itemType]),
+		}
+		if len(diffItem.Added) > 0 || len(diffItem.Removed) > 0 {
+			diffItems = append(diffItems, diffItem)
+		}
+	}
+	sort.Slice(diffItems, func(i, j int) bool {
+		return diffItems[i].ItemType < diffItems[j].ItemType
+	})
+	return diffItems, nil
+}
+
+func subtract(entries []string, excluded []string) []string {
+	excludedSet := make(map[string]bool, len(excluded))
+	for _, entry := range excluded {
+		excludedSet[entry] = true
+	}
+	var result []string
+	for _, entry := range entries {
+		if !excludedSet[entry] {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func entryString(value any) string {
+	switch entry := value.(type) {
+	case string:
+		return entry
+	case float64:
+		return strconv.FormatFloat(entry, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(entry)
+	default:
+		content, _ := json.Marshal(entry)
+		return string(content)
+	}
+}
@@ -0,0 +1,223 @@
+package ruleset
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"go4.org/netipx"
+)
+
+// Optimize removes redundant entries from rules without changing what they match.
+// Rules read from binary rule-sets must be recovered to lists first.
+func Optimize(rules []option.HeadlessRule) ([]option.HeadlessRule, error) {
+	var (
+		newRules        []option.HeadlessRule
+		domainRuleIndex = -1
+		ipRuleIndex     = -1
+	)
+	for _, rule := range rules {
+		if rule.Type != C.RuleTypeDefault {
+			newRules = append(newRules, rule)
+			continue
+		}
+		defaultRule := rule.DefaultOptions
+		clearMatchers(&defaultRule)
+		// top-level rules are ORed, so rules with only domain or only ip_cidr items can be merged
+		switch {
+		case isDomainOnlyRule(defaultRule) && domainRuleIndex != -1:
+			domainRule := &newRules[domainRuleIndex].DefaultOptions
+			domainRule.Domain = append(domainRule.Domain, defaultRule.Domain...)
+			domainRule.DomainSuffix = append(domainRule.DomainSuffix, defaultRule.DomainSuffix...)
+			domainRule.DomainKeyword = append(domainRule.DomainKeyword, defaultRule.DomainKeyword...)
+			domainRule.DomainRegex = append(domainRule.DomainRegex, defaultRule.DomainRegex...)
+		case isIPCIDROnlyRule(defaultRule) && ipRuleIndex != -1:
+			ipRule := &newRules[ipRuleIndex].DefaultOptions
+			ipRule.IPCIDR = append(ipRule.IPCIDR, defaultRule.IPCIDR...)
+		default:
+			if isDomainOnlyRule(defaultRule) {
+				domainRuleIndex = len(newRules)
+			} else if isIPCIDROnlyRule(defaultRule) {
+				ipRuleIndex = len(newRules)
+			}
+			newRules = append(newRules, option.HeadlessRule{Type: C.RuleTypeDefault, DefaultOptions: defaultRule})
+		}
+	}
+	for i := range newRules {
+		err := optimizeRule(&newRules[i])
+		if err != nil {
+			return nil, E.Cause(err, "optimize rule[", i, "]")
+		}
+	}
+	return newRules, nil
+}
+
+func optimizeRule(rule *option.HeadlessRule) error {
+	switch rule.Type {
+	case C.RuleTypeDefault:
+		return optimizeDefaultRule(&rule.DefaultOptions)
+	case C.RuleTypeLogical:
+		for i := range rule.LogicalOptions.Rules {
+			err := optimizeRule(&rule.LogicalOptions.Rules[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func optimizeDefaultRule(rule *option.DefaultHeadlessRule) error {
+	clearMatchers(rule)
+	rule.Network = common.Uniq(rule.Network)
+	rule.DomainRegex = common.Uniq(rule.DomainRegex)
+	rule.PortRange = common.Uniq(rule.PortRange)
+	rule.SourcePortRange = common.Uniq(rule.SourcePortRange)
+	rule.Port = common.Uniq(rule.Port)
+	rule.SourcePort = common.Uniq(rule.SourcePort)
+	rule.ProcessName = common.Uniq(rule.ProcessName)
+	rule.ProcessPath = common.Uniq(rule.ProcessPath)
+	rule.ProcessPathRegex = common.Uniq(rule.ProcessPathRegex)
+	rule.PackageName = common.Uniq(rule.PackageName)
+	rule.WIFISSID = common.Uniq(rule.WIFISSID)
+	rule.WIFIBSSID = common.Uniq(rule.WIFIBSSID)
+	rule.AdGuardDomain = common.Uniq(rule.AdGuardDomain)
+
+	keywords := common.Uniq(rule.DomainKeyword)
+	sort.Slice(keywords, func(i, j int) bool {
+		return len(keywords[i]) < len(keywords[j])
+	})
+	var domainKeyword []string
+	for _, keyword := range keywords {
+		if !containsKeyword(keyword, domainKeyword) {
+			domainKeyword = append(domainKeyword, keyword)
+		}
+	}
+	sort.Strings(domainKeyword)
+	rule.DomainKeyword = domainKeyword
+
+	suffixSet := make(map[string]bool)
+	for _, suffix := range rule.DomainSuffix {
+		suffixSet[suffix] = true
+	}
+	var domainSuffix []string
+	for suffix := range suffixSet {
+		if suffixCovered(suffix, suffixSet) || containsKeyword(suffix, rule.DomainKeyword) {
+			continue
+		}
+		domainSuffix = append(domainSuffix, suffix)
+	}
+	sort.Strings(domainSuffix)
+	rule.DomainSuffix = domainSuffix
+	suffixSet = make(map[string]bool)
+	for _, suffix := range rule.DomainSuffix {
+		suffixSet[suffix] = true
+	}
+	var domainList []string
+	for _, domain := range common.Uniq(rule.Domain) {
+		if suffixSet[domain] || suffixCovered(domain, suffixSet) || containsKeyword(domain, rule.DomainKeyword) {
+			continue
+		}
+		domainList = append(domainList, domain)
+	}
+	sort.Strings(domainList)
+	rule.Domain = domainList
+
+	var err error
+	rule.IPCIDR, err = mergePrefixes(rule.IPCIDR)
+	if err != nil {
+		return E.Cause(err, "ip_cidr")
+	}
+	rule.SourceIPCIDR, err = mergePrefixes(rule.SourceIPCIDR)
+	if err != nil {
+		return E.Cause(err, "source_ip_cidr")
+	}
+	return nil
+}
+
+// suffixCovered checks if all domains matched by the domain or suffix are matched by a parent suffix.
+// A suffix without the leading dot matches the domain itself and its subdomains,
+// while one with the leading dot only matches the subdomains.
+func suffixCovered(suffix string, suffixSet map[string]bool) bool {
+	name := strings.TrimPrefix(suffix, ".")
+	if name != suffix && suffixSet[name] {
+		return true
+	}
+	for {
+		index := strings.IndexByte(name, '.')
+		if index == -1 {
+			return false
+		}
+		name = name[index+1:]
+		if suffixSet[name] || suffixSet["."+name] {
+			return true
+		}
+	}
+}
+
+func containsKeyword(value string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(value, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func mergePrefixes(prefixList []string) ([]string, error) {
+	if len(prefixList) == 0 {
+		return nil, nil
+	}
+	var builder netipx.IPSetBuilder
+	for _, prefixString := range prefixList {
+		prefix, err := netip.ParsePrefix(prefixString)
+		if err == nil {
+			builder.AddPrefix(prefix)
+			continue
+		}
+		address, addrErr := netip.ParseAddr(prefixString)
+		if addrErr != nil {
+			return nil, err
+		}
+		builder.Add(address)
+	}
+	ipSet, err := builder.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	return common.Map(ipSet.Prefixes(), netip.Prefix.String), nil
+}
+
+func clearMatchers(rule *option.DefaultHeadlessRule) {
+	rule.DomainMatcher = nil
+	rule.AdGuardDomainMatcher = nil
+	rule.SourceIPSet = nil
+	rule.IPSet = nil
+	rule.MappedDomainSet = nil
+	rule.MappedSourceIPSet = nil
+	rule.MappedIPSet = nil
+}
+
+func isDomainOnlyRule(rule option.DefaultHeadlessRule) bool {
+	if rule.Invert || len(rule.Domain)+len(rule.DomainSuffix)+len(rule.DomainKeyword)+len(rule.DomainRegex) == 0 {
+		return false
+	}
+	rule.Domain = nil
+	rule.DomainSuffix = nil
+	rule.DomainKeyword = nil
+	rule.DomainRegex = nil
+	return !rule.IsValid()
+}
+
+func isIPCIDROnlyRule(rule option.DefaultHeadlessRule) bool {
+	if rule.Invert || len(rule.IPCIDR) == 0 {
+		return false
+	}
+	rule.IPCIDR = nil
+	return !rule.IsValid()
+}
@@ -0,0 +1,58 @@
+package ruleset
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+	rules, err := Optimize([]option.HeadlessRule{
+		{Type: C.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{
+			Domain:       []string{"example.com", "a.example.net", "example.net", "example.net", "maps.google.com"},
+			DomainSuffix: []string{".example.com", "example.org", "a.example.org", ".example.net"},
+		}},
+		{Type: C.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{
+			DomainKeyword: []string{"google", "googleapis"},
+		}},
+		{Type: C.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{
+			IPCIDR: []string{"10.0.0.0/9", "10.128.0.0/9", "10.1.0.0/16"},
+		}},
+		{Type: C.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{
+			DomainSuffix: []string{"example.io"},
+			Invert:       true,
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+	require.Equal(t, []string{"example.com", "example.net"}, []string(rules[0].DefaultOptions.Domain))
+	require.Equal(t, []string{".example.com", ".example.net", "example.org"}, []string(rules[0].DefaultOptions.DomainSuffix))
+	require.Equal(t, []string{"google"}, []string(rules[0].DefaultOptions.DomainKeyword))
+	require.Equal(t, []string{"10.0.0.0/8"}, []string(rules[1].DefaultOptions.IPCIDR))
+	require.True(t, rules[2].DefaultOptions.Invert)
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	diffItems, err := Diff([]option.HeadlessRule{
+		{Type: C.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{
+			Domain: []string{"example.com", "example.org"},
+			IPCIDR: []string{"10.0.0.0/8"},
+		}},
+	}, []option.HeadlessRule{
+		{Type: C.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{
+			Domain: []string{"example.com", "example.net"},
+			IPCIDR: []string{"10.0.0.0/9", "10.128.0.0/9"},
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []DiffItem{{
+		ItemType: "domain",
+		Added:    []string{"example.net"},
+		Removed:  []string{"example.org"},
+	}}, diffItems)
+}
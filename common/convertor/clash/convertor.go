@@ -0,0 +1,387 @@
+package clash
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Line is a payload entry and its position for error messages.
+type Line struct {
+	Position string
+	Content  string
+}
+
+// Convert converts a Clash rule-provider payload in YAML or text format to headless rules.
+// Classical rules of types that can not be expressed in a rule-set are skipped and returned as warnings.
+func Convert(content []byte, behavior string) (rules []option.HeadlessRule, warnings []string, err error) {
+	lines, err := readPayload(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch behavior {
+	case C.RuleSetBehaviorDomain:
+		rules, err = convertDomain(lines)
+	case C.RuleSetBehaviorIPCIDR:
+		rules, err = convertIPCIDR(lines)
+	case C.RuleSetBehaviorClassical:
+		rules, warnings, err = ConvertClassical(lines)
+	default:
+		err = E.New("unknown behavior: ", behavior)
+	}
+	return
+}
+
+func readPayload(content []byte) ([]Line, error) {
+	if isYAMLPayload(content) {
+		var document struct {
+			Payload []string `yaml:"payload"`
+		}
+		err := yaml.Unmarshal(content, &document)
+		if err != nil {
+			return nil, E.Cause(err, "parse YAML payload")
+		}
+		var lines []Line
+		for i, line := range document.Payload {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			lines = append(lines, Line{"payload[" + strconv.Itoa(i) + "]", line})
+		}
+		return lines, nil
+	}
+	var lines []Line
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		lines = append(lines, Line{"line " + strconv.Itoa(lineNumber), line})
+	}
+	return lines, scanner.Err()
+}
+
+func isYAMLPayload(content []byte) bool {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("payload:")) {
+			return true
+		}
+	}
+	return false
+}
+
+func convertDomain(lines []Line) ([]option.HeadlessRule, error) {
+	var rule option.DefaultHeadlessRule
+	for _, line := range lines {
+		domain := strings.ToLower(strings.TrimSuffix(line.Content, "."))
+		switch {
+		case strings.HasPrefix(domain, "+."):
+			rule.DomainSuffix = append(rule.DomainSuffix, domain[2:])
+		case strings.Contains(domain, "*"):
+			rule.DomainRegex = append(rule.DomainRegex, wildcardToRegex(domain))
+		case strings.HasPrefix(domain, "."):
+			rule.DomainSuffix = append(rule.DomainSuffix, domain)
+		case domain == "":
+			return nil, E.New(line.Position, ": invalid domain: ", line.Content)
+		default:
+			rule.Domain = append(rule.Domain, domain)
+		}
+	}
+	return singleRule(rule), nil
+}
+
+func convertIPCIDR(lines []Line) ([]option.HeadlessRule, error) {
+	var rule option.DefaultHeadlessRule
+	for _, line := range lines {
+		prefix, err := parsePrefix(line.Content)
+		if err != nil {
+			return nil, E.Cause(err, line.Position)
+		}
+		rule.IPCIDR = append(rule.IPCIDR, prefix)
+	}
+	return singleRule(rule), nil
+}
+
+// ConvertClassical converts classical rules, also used for the similar Surge-like rule lists.
+func ConvertClassical(lines []Line) ([]option.HeadlessRule, []string, error) {
+	var (
+		groups     []string
+		groupRules = make(map[string]*option.DefaultHeadlessRule)
+		rules      []option.HeadlessRule
+		warnings   []string
+	)
+	for _, line := range lines {
+		rule, err := parseClassicalRule(line.Content)
+		if err != nil {
+			if errors.Is(err, errUnsupportedRule) {
+				warnings = append(warnings, line.Position+": skipped "+err.Error())
+				continue
+			}
+			return nil, nil, E.Cause(err, line.Position)
+		}
+		if rule.Type == C.RuleTypeLogical {
+			rules = append(rules, rule)
+			continue
+		}
+		// items of the same kind are ORed in one rule, while different kinds are kept separate
+		group := ruleGroup(rule.DefaultOptions)
+		groupRule, loaded := groupRules[group]
+		if !loaded {
+			groupRule = new(option.DefaultHeadlessRule)
+			groupRules[group] = groupRule
+			groups = append(groups, group)
+		}
+		mergeRule(groupRule, rule.DefaultOptions)
+	}
+	mergedRules := make([]option.HeadlessRule, 0, len(groups)+len(rules))
+	for _, group := range groups {
+		mergedRules = append(mergedRules, singleRule(*groupRules[group])...)
+	}
+	return append(mergedRules, rules...), warnings, nil
+}
+
+var errUnsupportedRule = E.New("unsupported rule type")
+
+func parseClassicalRule(line string) (option.HeadlessRule, error) {
+	ruleType, payload, _ := strings.Cut(line, ",")
+	ruleType = strings.ToUpper(strings.TrimSpace(ruleType))
+	payload = strings.TrimSpace(payload)
+	switch ruleType {
+	case "AND", "OR", "NOT":
+		return parseLogicalRule(ruleType, payload)
+	}
+	// remove rule parameters such as no-resolve
+	if ruleType != "DOMAIN-REGEX" && ruleType != "PROCESS-PATH-REGEX" {
+		payload, _, _ = strings.Cut(payload, ",")
+		payload = strings.TrimSpace(payload)
+	}
+	if payload == "" {
+		return option.HeadlessRule{}, E.New("missing payload: ", line)
+	}
+	var rule option.DefaultHeadlessRule
+	switch ruleType {
+	case "DOMAIN":
+		rule.Domain = []string{strings.ToLower(payload)}
+	case "DOMAIN-SUFFIX":
+		rule.DomainSuffix = []string{strings.ToLower(payload)}
+	case "DOMAIN-KEYWORD":
+		rule.DomainKeyword = []string{strings.ToLower(payload)}
+	case "DOMAIN-REGEX":
+		_, err := regexp.Compile(payload)
+		if err != nil {
+			return option.HeadlessRule{}, E.Cause(err, "parse domain regex")
+		}
+		rule.DomainRegex = []string{payload}
+	case "DOMAIN-WILDCARD":
+		rule.DomainRegex = []string{wildcardToRegex(strings.ToLower(payload))}
+	case "IP-CIDR", "IP-CIDR6":
+		prefix, err := parsePrefix(payload)
+		if err != nil {
+			return option.HeadlessRule{}, err
+		}
+		rule.IPCIDR = []string{prefix}
+	case "SRC-IP-CIDR":
+		prefix, err := parsePrefix(payload)
+		if err != nil {
+			return option.HeadlessRule{}, err
+		}
+		rule.SourceIPCIDR = []string{prefix}
+	case "DST-PORT", "SRC-PORT":
+		ports, portRanges, err := parsePorts(payload)
+		if err != nil {
+			return option.HeadlessRule{}, err
+		}
+		if ruleType == "DST-PORT" {
+			rule.Port = ports
+			rule.PortRange = portRanges
+		} else {
+			rule.SourcePort = ports
+			rule.SourcePortRange = portRanges
+		}
+	case "PROCESS-NAME":
+		rule.ProcessName = []string{payload}
+	case "PROCESS-PATH":
+		rule.ProcessPath = []string{payload}
+	case "PROCESS-PATH-REGEX":
+		_, err := regexp.Compile(payload)
+		if err != nil {
+			return option.HeadlessRule{}, E.Cause(err, "parse process path regex")
+		}
+		rule.ProcessPathRegex = []string{payload}
+	case "NETWORK":
+		network := strings.ToLower(payload)
+		if network != "tcp" && network != "udp" {
+			return option.HeadlessRule{}, E.New("unknown network: ", payload)
+		}
+		rule.Network = []string{network}
+	default:
+		return option.HeadlessRule{}, E.Extend(errUnsupportedRule, ruleType)
+	}
+	return option.HeadlessRule{
+		Type:           C.RuleTypeDefault,
+		DefaultOptions: rule,
+	}, nil
+}
+
+// parseLogicalRule parses payloads like ((DOMAIN,example.com),(NETWORK,UDP)).
+func parseLogicalRule(ruleType string, payload string) (option.HeadlessRule, error) {
+	if !strings.HasPrefix(payload, "(") || !strings.HasSuffix(payload, ")") {
+		return option.HeadlessRule{}, E.New("invalid logical rule payload: ", payload)
+	}
+	var (
+		subRules []option.HeadlessRule
+		depth    int
+		start    int
+	)
+	payload = payload[1 : len(payload)-1]
+	for i := 0; i < len(payload); i++ {
+		switch payload[i] {
+		case '(':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return option.HeadlessRule{}, E.New("unbalanced parentheses in logical rule")
+			}
+			if depth == 0 {
+				subRule, err := parseClassicalRule(payload[start:i])
+				if err != nil {
+					return option.HeadlessRule{}, err
+				}
+				subRules = append(subRules, subRule)
+			}
+		}
+	}
+	if depth != 0 {
+		return option.HeadlessRule{}, E.New("unbalanced parentheses in logical rule")
+	}
+	rule := option.LogicalHeadlessRule{
+		Rules: subRules,
+	}
+	switch ruleType {
+	case "AND":
+		rule.Mode = C.LogicalTypeAnd
+	case "OR":
+		rule.Mode = C.LogicalTypeOr
+	case "NOT":
+		if len(subRules) != 1 {
+			return option.HeadlessRule{}, E.New("NOT rule requires exactly one sub-rule")
+		}
+		rule.Mode = C.LogicalTypeAnd
+		rule.Invert = true
+	}
+	if len(subRules) == 0 {
+		return option.HeadlessRule{}, E.New("empty logical rule")
+	}
+	return option.HeadlessRule{
+		Type:           C.RuleTypeLogical,
+		LogicalOptions: rule,
+	}, nil
+}
+
+func parsePrefix(value string) (string, error) {
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		address, addrErr := netip.ParseAddr(value)
+		if addrErr != nil {
+			return "", E.Cause(err, "invalid IP CIDR: ", value)
+		}
+		prefix = netip.PrefixFrom(address, address.BitLen())
+	}
+	return prefix.Masked().String(), nil
+}
+
+func parsePorts(value string) ([]uint16, []string, error) {
+	var (
+		ports      []uint16
+		portRanges []string
+	)
+	for _, portString := range strings.Split(value, "/") {
+		portStart, portEnd, isRange := strings.Cut(portString, "-")
+		start, err := strconv.ParseUint(portStart, 10, 16)
+		if err != nil {
+			return nil, nil, E.Cause(err, "invalid port: ", portString)
+		}
+		if !isRange {
+			ports = append(ports, uint16(start))
+			continue
+		}
+		end, err := strconv.ParseUint(portEnd, 10, 16)
+		if err != nil {
+			return nil, nil, E.Cause(err, "invalid port range: ", portString)
+		}
+		portRanges = append(portRanges, strconv.FormatUint(start, 10)+":"+strconv.FormatUint(end, 10))
+	}
+	return ports, portRanges, nil
+}
+
+// wildcardToRegex converts a Clash wildcard domain, where * matches exactly one label.
+func wildcardToRegex(domain string) string {
+	return "^" + strings.ReplaceAll(regexp.QuoteMeta(domain), `\*`, `[^.]+`) + "$"
+}
+
+func ruleGroup(rule option.DefaultHeadlessRule) string {
+	switch {
+	case len(rule.Domain) > 0, len(rule.DomainSuffix) > 0, len(rule.DomainKeyword) > 0, len(rule.DomainRegex) > 0:
+		return "domain"
+	case len(rule.IPCIDR) > 0:
+		return "ip_cidr"
+	case len(rule.SourceIPCIDR) > 0:
+		return "source_ip_cidr"
+	case len(rule.Port) > 0, len(rule.PortRange) > 0:
+		return "port"
+	case len(rule.SourcePort) > 0, len(rule.SourcePortRange) > 0:
+		return "source_port"
+	case len(rule.ProcessName) > 0:
+		return "process_name"
+	case len(rule.ProcessPath) > 0:
+		return "process_path"
+	case len(rule.ProcessPathRegex) > 0:
+		return "process_path_regex"
+	default:
+		return "network"
+	}
+}
+
+func mergeRule(rule *option.DefaultHeadlessRule, item option.DefaultHeadlessRule) {
+	rule.Domain = append(rule.Domain, item.Domain...)
+	rule.DomainSuffix = append(rule.DomainSuffix, item.DomainSuffix...)
+	rule.DomainKeyword = append(rule.DomainKeyword, item.DomainKeyword...)
+	rule.DomainRegex = append(rule.DomainRegex, item.DomainRegex...)
+	rule.IPCIDR = append(rule.IPCIDR, item.IPCIDR...)
+	rule.SourceIPCIDR = append(rule.SourceIPCIDR, item.SourceIPCIDR...)
+	rule.Port = append(rule.Port, item.Port...)
+	rule.PortRange = append(rule.PortRange, item.PortRange...)
+	rule.SourcePort = append(rule.SourcePort, item.SourcePort...)
+	rule.SourcePortRange = append(rule.SourcePortRange, item.SourcePortRange...)
+	rule.ProcessName = append(rule.ProcessName, item.ProcessName...)
+	rule.ProcessPath = append(rule.ProcessPath, item.ProcessPath...)
+	rule.ProcessPathRegex = append(rule.ProcessPathRegex, item.ProcessPathRegex...)
+	rule.Network = append(rule.Network, item.Network...)
+}
+
+func singleRule(rule option.DefaultHeadlessRule) []option.HeadlessRule {
+	if !rule.IsValid() {
+		return nil
+	}
+	return []option.HeadlessRule{{
+		Type:           C.RuleTypeDefault,
+		DefaultOptions: rule,
+	}}
+}
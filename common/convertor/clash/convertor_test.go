@@ -0,0 +1,59 @@
+package clash_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/convertor/clash"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/route/rule"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertDomain(t *testing.T) {
+	t.Parallel()
+	rules, _, err := clash.Convert([]byte(`
+payload:
+  - '+.example.org'
+  - 'example.com'
+  - '.example.net'
+  - '*.example.edu'
+`), C.RuleSetBehaviorDomain)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	headlessRule, err := rule.NewHeadlessRule(context.Background(), rules[0])
+	require.NoError(t, err)
+	for _, domain := range []string{"example.org", "www.example.org", "example.com", "www.example.net", "www.example.edu"} {
+		require.True(t, headlessRule.Match(&adapter.InboundContext{Domain: domain}), domain)
+	}
+	for _, domain := range []string{"www.example.com", "example.net", "example.edu", "a.b.example.edu"} {
+		require.False(t, headlessRule.Match(&adapter.InboundContext{Domain: domain}), domain)
+	}
+}
+
+func TestConvertClassical(t *testing.T) {
+	t.Parallel()
+	rules, warnings, err := clash.Convert([]byte(`
+# comment
+DOMAIN-SUFFIX,example.org
+IP-CIDR,1.1.1.0/24,no-resolve
+DOMAIN,example.com
+GEOIP,CN
+AND,((DOMAIN-KEYWORD,sagernet),(NETWORK,UDP))
+DST-PORT,8000-9000/443
+`), C.RuleSetBehaviorClassical)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	require.Len(t, rules, 4)
+	require.Equal(t, []string{"example.com"}, []string(rules[0].DefaultOptions.Domain))
+	require.Equal(t, []string{"example.org"}, []string(rules[0].DefaultOptions.DomainSuffix))
+	require.Equal(t, []string{"1.1.1.0/24"}, []string(rules[1].DefaultOptions.IPCIDR))
+	require.Equal(t, []uint16{443}, []uint16(rules[2].DefaultOptions.Port))
+	require.Equal(t, []string{"8000:9000"}, []string(rules[2].DefaultOptions.PortRange))
+	require.Equal(t, C.RuleTypeLogical, rules[3].Type)
+	require.Len(t, rules[3].LogicalOptions.Rules, 2)
+	_, _, err = clash.Convert([]byte("IP-CIDR,invalid"), C.RuleSetBehaviorClassical)
+	require.ErrorContains(t, err, "line 1")
+}
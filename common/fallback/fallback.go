@@ -0,0 +1,182 @@
+package fallback
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+const maxRequestLineLen = 2048
+
+type Fallback struct {
+	serverName    []string
+	alpn          []string
+	path          string
+	inbound       string
+	destination   M.Socksaddr
+	proxyProtocol uint8
+}
+
+type Fallbacks struct {
+	fallbacks []*Fallback
+	readPath  bool
+}
+
+func New(options []option.InboundFallbackOptions, tlsEnabled bool) (*Fallbacks, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	var fallbacks Fallbacks
+	for i, fallbackOptions := range options {
+		fallback := &Fallback{
+			serverName:    fallbackOptions.ServerName,
+			alpn:          fallbackOptions.ALPN,
+			path:          fallbackOptions.Path,
+			inbound:       fallbackOptions.Inbound,
+			proxyProtocol: fallbackOptions.ProxyProtocol,
+		}
+		if (len(fallback.serverName) > 0 || len(fallback.alpn) > 0) && !tlsEnabled {
+			return nil, E.New("fallback[", i, "]: server_name and alpn match is not supported without TLS")
+		}
+		if fallback.path != "" && !strings.HasPrefix(fallback.path, "/") {
+			return nil, E.New("fallback[", i, "]: path must start with /")
+		}
+		if fallback.proxyProtocol > 2 {
+			return nil, E.New("fallback[", i, "]: unknown proxy protocol version: ", fallback.proxyProtocol)
+		}
+		if fallback.inbound != "" {
+			if fallbackOptions.Server != "" || fallbackOptions.ServerPort != 0 {
+				return nil, E.New("fallback[", i, "]: inbound and server are mutually exclusive")
+			}
+			if fallback.proxyProtocol != 0 {
+				return nil, E.New("fallback[", i, "]: proxy protocol is not supported for inbound fallback")
+			}
+		} else {
+			fallback.destination = fallbackOptions.Build()
+			if !fallback.destination.IsValid() || fallback.destination.Port == 0 {
+				return nil, E.New("fallback[", i, "]: invalid fallback address: ", fallback.destination)
+			}
+		}
+		if fallback.path != "" {
+			fallbacks.readPath = true
+		}
+		fallbacks.fallbacks = append(fallbacks.fallbacks, fallback)
+	}
+	return &fallbacks, nil
+}
+
+// Match returns the first fallback matching the TLS server name, ALPN and HTTP request path of the connection.
+//
+// The returned connection must be used in place of the original one, as the request line may be consumed.
+func (f *Fallbacks) Match(conn net.Conn) (*Fallback, net.Conn, error) {
+	var serverName, alpn string
+	if tlsConn, loaded := common.Cast[tls.Conn](conn); loaded {
+		connectionState := tlsConn.ConnectionState()
+		serverName = connectionState.ServerName
+		alpn = connectionState.NegotiatedProtocol
+	}
+	var path string
+	if f.readPath {
+		var err error
+		path, conn, err = readRequestPath(conn)
+		if err != nil {
+			return nil, conn, err
+		}
+	}
+	for _, fallback := range f.fallbacks {
+		if fallback.match(serverName, alpn, path) {
+			return fallback, conn, nil
+		}
+	}
+	return nil, conn, nil
+}
+
+func (f *Fallback) match(serverName string, alpn string, path string) bool {
+	if len(f.serverName) > 0 && !common.Any(f.serverName, func(it string) bool {
+		return strings.EqualFold(it, serverName)
+	}) {
+		return false
+	}
+	if len(f.alpn) > 0 && !common.Contains(f.alpn, alpn) {
+		return false
+	}
+	if f.path != "" && f.path != path {
+		return false
+	}
+	return true
+}
+
+// Apply updates the metadata to route the connection to the fallback destination or inbound.
+func (f *Fallback) Apply(conn net.Conn, metadata *adapter.InboundContext) net.Conn {
+	if f.inbound != "" {
+		//nolint:staticcheck
+		metadata.InboundDetour = f.inbound
+		return conn
+	}
+	metadata.Destination = f.destination
+	if f.proxyProtocol != 0 {
+		header := buf.As(proxyProtocolHeader(f.proxyProtocol, metadata.Source, M.SocksaddrFromNet(conn.LocalAddr()).Unwrap()))
+		conn = bufio.NewCachedConn(conn, header.ToOwned())
+	}
+	return conn
+}
+
+func (f *Fallback) String() string {
+	if f.inbound != "" {
+		return F.ToString("inbound/", f.inbound)
+	}
+	return f.destination.String()
+}
+
+func readRequestPath(conn net.Conn) (string, net.Conn, error) {
+	err := conn.SetReadDeadline(time.Now().Add(C.TCPTimeout))
+	if err != nil {
+		return "", conn, err
+	}
+	buffer := buf.NewSize(maxRequestLineLen)
+	for !buffer.IsFull() && !bytes.Contains(buffer.Bytes(), []byte("\r\n")) {
+		_, err = buffer.ReadOnceFrom(conn)
+		if err != nil {
+			break
+		}
+	}
+	deadlineErr := conn.SetReadDeadline(time.Time{})
+	if deadlineErr != nil {
+		buffer.Release()
+		return "", conn, deadlineErr
+	}
+	if buffer.IsEmpty() {
+		buffer.Release()
+		return "", conn, E.Cause(err, "read request line")
+	}
+	conn = bufio.NewCachedConn(conn, buffer)
+	return parseRequestPath(buffer.Bytes()), conn, nil
+}
+
+func parseRequestPath(content []byte) string {
+	lineEnd := bytes.Index(content, []byte("\r\n"))
+	if lineEnd < 0 {
+		return ""
+	}
+	requestLine := strings.Split(string(content[:lineEnd]), " ")
+	if len(requestLine) != 3 || !strings.HasPrefix(requestLine[2], "HTTP/") {
+		return ""
+	}
+	path := requestLine[1]
+	if queryIndex := strings.IndexByte(path, '?'); queryIndex >= 0 {
+		path = path[:queryIndex]
+	}
+	return path
+}
@@ -0,0 +1,50 @@
+package fallback
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackMatch(t *testing.T) {
+	t.Parallel()
+	fallbacks, err := New([]option.InboundFallbackOptions{
+		{ALPN: []string{"h2"}, Server: "127.0.0.1", ServerPort: 2000},
+		{ServerName: []string{"api.example.com"}, Path: "/ws", Server: "127.0.0.1", ServerPort: 2001},
+		{Inbound: "web"},
+	}, true)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:2000", fallbacks.fallbacks[0].String())
+	require.True(t, fallbacks.fallbacks[0].match("example.com", "h2", ""))
+	require.False(t, fallbacks.fallbacks[0].match("example.com", "http/1.1", ""))
+	require.True(t, fallbacks.fallbacks[1].match("API.example.com", "http/1.1", "/ws"))
+	require.False(t, fallbacks.fallbacks[1].match("api.example.com", "http/1.1", "/"))
+	require.True(t, fallbacks.fallbacks[2].match("", "", ""))
+	_, err = New([]option.InboundFallbackOptions{{ALPN: []string{"h2"}, Server: "127.0.0.1", ServerPort: 2000}}, false)
+	require.Error(t, err)
+	_, err = New([]option.InboundFallbackOptions{{Inbound: "web", ProxyProtocol: 1}}, false)
+	require.Error(t, err)
+}
+
+func TestParseRequestPath(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "/ws", parseRequestPath([]byte("GET /ws?ed=2048 HTTP/1.1\r\nHost: example.com\r\n")))
+	require.Equal(t, "", parseRequestPath([]byte("PRI * HTTP/2.0")))
+	require.Equal(t, "", parseRequestPath([]byte{0x00, 0x01, '\r', '\n'}))
+}
+
+func TestProxyProtocolHeader(t *testing.T) {
+	t.Parallel()
+	source := M.SocksaddrFrom(netip.MustParseAddr("192.168.1.2"), 50000)
+	destination := M.SocksaddrFrom(netip.MustParseAddr("10.0.0.1"), 443)
+	require.Equal(t, "PROXY TCP4 192.168.1.2 10.0.0.1 50000 443\r\n", string(proxyProtocolHeader(1, source, destination)))
+	header := proxyProtocolHeader(2, source, destination)
+	require.Len(t, header, 16+12)
+	require.Equal(t, proxyProtocolV2Signature, header[:12])
+	require.Equal(t, []byte{0x21, 0x11, 0x00, 12}, header[12:16])
+	require.Equal(t, "PROXY UNKNOWN\r\n", string(proxyProtocolHeader(1, M.Socksaddr{}, destination)))
+}
@@ -0,0 +1,47 @@
+package fallback
+
+import (
+	"encoding/binary"
+	"net/netip"
+
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+var proxyProtocolV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+func proxyProtocolHeader(version uint8, source M.Socksaddr, destination M.Socksaddr) []byte {
+	sourceAddr, destinationAddr := source.Addr, destination.Addr
+	valid := source.IsIP() && destination.IsIP()
+	if valid && sourceAddr.Is4() != destinationAddr.Is4() {
+		sourceAddr = netip.AddrFrom16(sourceAddr.As16())
+		destinationAddr = netip.AddrFrom16(destinationAddr.As16())
+	}
+	if version == 1 {
+		if !valid {
+			return []byte("PROXY UNKNOWN\r\n")
+		}
+		family := "TCP4"
+		if !sourceAddr.Is4() {
+			family = "TCP6"
+		}
+		return []byte(F.ToString("PROXY ", family, " ", sourceAddr, " ", destinationAddr, " ", source.Port, " ", destination.Port, "\r\n"))
+	}
+	header := append([]byte{}, proxyProtocolV2Signature...)
+	if !valid {
+		return append(header, 0x20, 0x00, 0x00, 0x00)
+	}
+	if sourceAddr.Is4() {
+		header = append(header, 0x21, 0x11, 0x00, 12)
+		sourceBytes, destinationBytes := sourceAddr.As4(), destinationAddr.As4()
+		header = append(header, sourceBytes[:]...)
+		header = append(header, destinationBytes[:]...)
+	} else {
+		header = append(header, 0x21, 0x21, 0x00, 36)
+		sourceBytes, destinationBytes := sourceAddr.As16(), destinationAddr.As16()
+		header = append(header, sourceBytes[:]...)
+		header = append(header, destinationBytes[:]...)
+	}
+	header = binary.BigEndian.AppendUint16(header, source.Port)
+	return binary.BigEndian.AppendUint16(header, destination.Port)
+}
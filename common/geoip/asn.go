@@ -0,0 +1,159 @@
+package geoip
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/oschwald/maxminddb-golang"
+	"go4.org/netipx"
+)
+
+type ASN struct {
+	Number       uint32
+	Organization string
+}
+
+func (a ASN) String() string {
+	if a.Organization == "" {
+		return "AS" + strconv.FormatUint(uint64(a.Number), 10)
+	}
+	return "AS" + strconv.FormatUint(uint64(a.Number), 10) + " " + a.Organization
+}
+
+// asnRecord covers both the MaxMind/DB-IP and the ipinfo ASN database layouts.
+type asnRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+	ASN          string `maxminddb:"asn"`
+	Name         string `maxminddb:"name"`
+	ASName       string `maxminddb:"as_name"`
+}
+
+func (r *asnRecord) Parse() (ASN, bool) {
+	if r.Number > 0 {
+		return ASN{r.Number, r.Organization}, true
+	}
+	if r.ASN == "" {
+		return ASN{}, false
+	}
+	number, err := ParseASNumber(r.ASN)
+	if err != nil {
+		return ASN{}, false
+	}
+	organization := r.ASName
+	if organization == "" {
+		organization = r.Name
+	}
+	return ASN{number, organization}, true
+}
+
+type ASNReader struct {
+	reader *maxminddb.Reader
+}
+
+func OpenASN(path string) (*ASNReader, error) {
+	database, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(strings.ToLower(database.Metadata.DatabaseType), "asn") {
+		database.Close()
+		return nil, E.New("incorrect database type, expected an ASN database, got ", database.Metadata.DatabaseType)
+	}
+	return &ASNReader{database}, nil
+}
+
+func (r *ASNReader) Lookup(addr netip.Addr) (ASN, bool) {
+	var record asnRecord
+	err := r.reader.Lookup(addr.Unmap().AsSlice(), &record)
+	if err != nil {
+		return ASN{}, false
+	}
+	return record.Parse()
+}
+
+func (r *ASNReader) Range(f func(prefix netip.Prefix, asn ASN) error) error {
+	networks := r.reader.Networks(maxminddb.SkipAliasedNetworks)
+	for networks.Next() {
+		var record asnRecord
+		ipNet, err := networks.Network(&record)
+		if err != nil {
+			return err
+		}
+		asn, loaded := record.Parse()
+		if !loaded {
+			continue
+		}
+		prefix, loaded := netipx.FromStdIPNet(ipNet)
+		if !loaded {
+			continue
+		}
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		err = f(prefix, asn)
+		if err != nil {
+			return err
+		}
+	}
+	return networks.Err()
+}
+
+func (r *ASNReader) Close() error {
+	return r.reader.Close()
+}
+
+func ParseASNumber(value string) (uint32, error) {
+	if len(value) > 2 && strings.EqualFold(value[:2], "AS") {
+		value = value[2:]
+	}
+	number, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(number), nil
+}
+
+type ASNMatcher struct {
+	numbers  map[uint32]bool
+	keywords []string
+}
+
+// NewASNMatcher accepts AS numbers (with or without the AS prefix) and
+// case-insensitive organization keywords.
+func NewASNMatcher(values []string) (*ASNMatcher, error) {
+	matcher := &ASNMatcher{
+		numbers: make(map[uint32]bool),
+	}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, E.New("empty ASN value")
+		}
+		number, err := ParseASNumber(value)
+		if err == nil {
+			matcher.numbers[number] = true
+		} else {
+			matcher.keywords = append(matcher.keywords, strings.ToLower(value))
+		}
+	}
+	return matcher, nil
+}
+
+func (m *ASNMatcher) Match(asn ASN) bool {
+	if m.numbers[asn.Number] {
+		return true
+	}
+	if len(m.keywords) > 0 && asn.Organization != "" {
+		organization := strings.ToLower(asn.Organization)
+		for _, keyword := range m.keywords {
+			if strings.Contains(organization, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package geoip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestASNMatcher(t *testing.T) {
+	t.Parallel()
+	matcher, err := NewASNMatcher([]string{"AS13335", "15169", "amazon"})
+	require.NoError(t, err)
+	require.True(t, matcher.Match(ASN{Number: 13335}))
+	require.True(t, matcher.Match(ASN{Number: 15169, Organization: "GOOGLE"}))
+	require.True(t, matcher.Match(ASN{Number: 16509, Organization: "AMAZON-02"}))
+	require.False(t, matcher.Match(ASN{Number: 8075, Organization: "MICROSOFT-CORP-MSN-AS-BLOCK"}))
+	_, err = NewASNMatcher([]string{""})
+	require.Error(t, err)
+}
+
+func TestASNRecord(t *testing.T) {
+	t.Parallel()
+	record := asnRecord{ASN: "AS13335", Name: "Cloudflare, Inc."}
+	asn, loaded := record.Parse()
+	require.True(t, loaded)
+	require.Equal(t, ASN{13335, "Cloudflare, Inc."}, asn)
+	record = asnRecord{Number: 15169, Organization: "GOOGLE"}
+	asn, loaded = record.Parse()
+	require.True(t, loaded)
+	require.Equal(t, "AS15169 GOOGLE", asn.String())
+	_, loaded = (&asnRecord{}).Parse()
+	require.False(t, loaded)
+}
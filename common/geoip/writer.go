@@ -0,0 +1,254 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/netip"
+	"sort"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// Write writes a sing-geoip MMDB database mapping the prefixes of each code to the code.
+// More specific prefixes take precedence over overlapping ones.
+func Write(writer io.Writer, codes map[string][]netip.Prefix) error {
+	type codePrefix struct {
+		code   string
+		prefix netip.Prefix
+	}
+	codeList := make([]string, 0, len(codes))
+	for code := range codes {
+		codeList = append(codeList, code)
+	}
+	sort.Strings(codeList)
+	var (
+		prefixes []codePrefix
+		data     mmdbEncoder
+	)
+	dataOffset := make(map[string]int)
+	for _, code := range codeList {
+		dataOffset[code] = data.Len()
+		data.writeString(code)
+		for _, prefix := range codes[code] {
+			if !prefix.IsValid() {
+				return E.New("invalid prefix in ", code)
+			}
+			prefixes = append(prefixes, codePrefix{code, prefix.Masked()})
+		}
+	}
+	sort.SliceStable(prefixes, func(i, j int) bool {
+		return mmdbPrefixBits(prefixes[i].prefix) < mmdbPrefixBits(prefixes[j].prefix)
+	})
+	root := &mmdbNode{}
+	for _, prefix := range prefixes {
+		root.insert(prefix.prefix, dataOffset[prefix.code])
+	}
+	root.alias()
+	nodes := root.index()
+	nodeCount := len(nodes)
+	recordSize := 24
+	if nodeCount+16+data.Len() >= 1<<24 {
+		recordSize = 32
+	}
+	record := func(child *mmdbNode) uint32 {
+		switch {
+		case child == nil:
+			return uint32(nodeCount)
+		case child.leaf:
+			return uint32(nodeCount + 16 + child.data)
+		default:
+			return uint32(child.id)
+		}
+	}
+	var tree bytes.Buffer
+	for _, node := range nodes {
+		for _, child := range node.children {
+			value := record(child)
+			if recordSize == 24 {
+				tree.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+			} else {
+				tree.Write(binary.BigEndian.AppendUint32(nil, value))
+			}
+		}
+	}
+	tree.Write(make([]byte, 16))
+	tree.Write(data.Bytes())
+	tree.WriteString("\xab\xcd\xefMaxMind.com")
+	var metadata mmdbEncoder
+	metadata.writeControl(7, 9)
+	metadata.writeString("binary_format_major_version")
+	metadata.writeUint(5, 2)
+	metadata.writeString("binary_format_minor_version")
+	metadata.writeUint(5, 0)
+	metadata.writeString("build_epoch")
+	metadata.writeUint(9, uint64(time.Now().Unix()))
+	metadata.writeString("database_type")
+	metadata.writeString("sing-geoip")
+	metadata.writeString("description")
+	metadata.writeControl(7, 1)
+	metadata.writeString("en")
+	metadata.writeString("Built by sing-box geoip build")
+	metadata.writeString("ip_version")
+	metadata.writeUint(5, 6)
+	metadata.writeString("languages")
+	metadata.writeControl(11, len(codeList))
+	for _, code := range codeList {
+		metadata.writeString(code)
+	}
+	metadata.writeString("node_count")
+	metadata.writeUint(6, uint64(nodeCount))
+	metadata.writeString("record_size")
+	metadata.writeUint(5, uint64(recordSize))
+	tree.Write(metadata.Bytes())
+	_, err := writer.Write(tree.Bytes())
+	return err
+}
+
+// mmdbNode is a node of the search tree, IPv4 addresses are stored in ::/96 and aliased from ::ffff:0:0/96.
+type mmdbNode struct {
+	children [2]*mmdbNode
+	leaf     bool
+	data     int
+	id       int
+}
+
+func mmdbPrefixBits(prefix netip.Prefix) int {
+	if prefix.Addr().Is4() {
+		return 96 + prefix.Bits()
+	}
+	return prefix.Bits()
+}
+
+func (n *mmdbNode) insert(prefix netip.Prefix, data int) {
+	var address [16]byte
+	if prefix.Addr().Is4() {
+		address4 := prefix.Addr().As4()
+		copy(address[12:], address4[:])
+	} else {
+		address = prefix.Addr().As16()
+	}
+	prefixBits := mmdbPrefixBits(prefix)
+	if prefixBits == 0 {
+		// the root must stay a node
+		n.split()
+		n.children[0] = &mmdbNode{leaf: true, data: data}
+		n.children[1] = &mmdbNode{leaf: true, data: data}
+		return
+	}
+	node := n
+	for i := 0; i < prefixBits-1; i++ {
+		node.split()
+		bit := address[i>>3] >> (7 - i&7) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &mmdbNode{}
+		}
+		node = node.children[bit]
+	}
+	node.split()
+	bit := address[(prefixBits-1)>>3] >> (7 - (prefixBits-1)&7) & 1
+	node.children[bit] = &mmdbNode{leaf: true, data: data}
+}
+
+// split pushes the data of a leaf down to its children.
+func (n *mmdbNode) split() {
+	if !n.leaf {
+		return
+	}
+	n.children[0] = &mmdbNode{leaf: true, data: n.data}
+	n.children[1] = &mmdbNode{leaf: true, data: n.data}
+	n.leaf = false
+}
+
+// alias points ::ffff:0:0/96 to the IPv4 subtree, so that IPv4-mapped addresses are found as well.
+func (n *mmdbNode) alias() {
+	node := n
+	for i := 0; i < 96; i++ {
+		if node == nil || node.leaf {
+			return
+		}
+		node = node.children[0]
+	}
+	if node == nil {
+		return
+	}
+	ipv4Node := node
+	node = n
+	for i := 0; i < 95; i++ {
+		bit := 0
+		if i >= 80 {
+			bit = 1
+		}
+		node.split()
+		if node.children[bit] == nil {
+			node.children[bit] = &mmdbNode{}
+		}
+		node = node.children[bit]
+	}
+	node.split()
+	node.children[1] = ipv4Node
+}
+
+// index numbers the nodes breadth first.
+func (n *mmdbNode) index() []*mmdbNode {
+	visited := make(map[*mmdbNode]bool)
+	nodes := []*mmdbNode{n}
+	visited[n] = true
+	for i := 0; i < len(nodes); i++ {
+		nodes[i].id = i
+		for _, child := range nodes[i].children {
+			if child != nil && !child.leaf && !visited[child] {
+				visited[child] = true
+				nodes = append(nodes, child)
+			}
+		}
+	}
+	return nodes
+}
+
+// mmdbEncoder writes the data section format of MaxMind DB.
+type mmdbEncoder struct {
+	bytes.Buffer
+}
+
+func (e *mmdbEncoder) writeControl(dataType int, size int) {
+	var control byte
+	if dataType <= 7 {
+		control = byte(dataType << 5)
+	}
+	var sizeBytes []byte
+	switch {
+	case size < 29:
+		control |= byte(size)
+	case size < 285:
+		control |= 29
+		sizeBytes = []byte{byte(size - 29)}
+	case size < 65821:
+		control |= 30
+		sizeBytes = binary.BigEndian.AppendUint16(nil, uint16(size-285))
+	default:
+		control |= 31
+		size -= 65821
+		sizeBytes = []byte{byte(size >> 16), byte(size >> 8), byte(size)}
+	}
+	e.WriteByte(control)
+	if dataType > 7 {
+		e.WriteByte(byte(dataType - 7))
+	}
+	e.Write(sizeBytes)
+}
+
+func (e *mmdbEncoder) writeString(value string) {
+	e.writeControl(2, len(value))
+	e.WriteString(value)
+}
+
+func (e *mmdbEncoder) writeUint(dataType int, value uint64) {
+	valueBytes := binary.BigEndian.AppendUint64(nil, value)
+	for len(valueBytes) > 0 && valueBytes[0] == 0 {
+		valueBytes = valueBytes[1:]
+	}
+	e.writeControl(dataType, len(valueBytes))
+	e.Write(valueBytes)
+}
@@ -0,0 +1,38 @@
+package geoip
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	var buffer bytes.Buffer
+	err := Write(&buffer, map[string][]netip.Prefix{
+		"cn":      {netip.MustParsePrefix("1.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")},
+		"private": {netip.MustParsePrefix("1.2.3.0/24"), netip.MustParsePrefix("10.0.0.0/8")},
+	})
+	require.NoError(t, err)
+	database, err := maxminddb.FromBytes(buffer.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "sing-geoip", database.Metadata.DatabaseType)
+	require.Equal(t, []string{"cn", "private"}, database.Metadata.Languages)
+	reader := &Reader{database}
+	for address, code := range map[string]string{
+		"1.1.1.1":         "cn",
+		"1.2.3.4":         "private",
+		"::ffff:1.2.3.4":  "private",
+		"10.1.1.1":        "private",
+		"2001:db8::1":     "cn",
+		"2.2.2.2":         "unknown",
+		"2001:db9::1":     "unknown",
+		"::ffff:11.0.0.1": "unknown",
+	} {
+		require.Equal(t, code, reader.Lookup(netip.MustParseAddr(address)), address)
+	}
+	require.NoError(t, database.Verify())
+}
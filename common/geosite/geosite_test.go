@@ -2,6 +2,8 @@ package geosite_test
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/sagernet/sing-box/common/geosite"
@@ -32,3 +34,46 @@ func TestGeosite(t *testing.T) {
 		Value: "example.org",
 	}}, items)
 }
+
+func TestReadSource(t *testing.T) {
+	t.Parallel()
+	directory := t.TempDir()
+	for name, content := range map[string]string{
+		"example": `
+# comment
+example.org @cn
+full:www.example.com
+keyword:example @ads # trailing comment
+include:extra @-ads
+`,
+		"extra": `
+regexp:^ad[0-9]+\.example\.net$ @ads
+domain:Example.NET &other
+`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(directory, name), []byte(content), 0o644))
+	}
+	domains, err := geosite.ReadSource(directory)
+	require.NoError(t, err)
+	require.Equal(t, []geosite.Item{
+		{geosite.RuleTypeDomain, "example.org"},
+		{geosite.RuleTypeDomain, "www.example.com"},
+		{geosite.RuleTypeDomain, "example.net"},
+		{geosite.RuleTypeDomainSuffix, ".example.org"},
+		{geosite.RuleTypeDomainSuffix, ".example.net"},
+		{geosite.RuleTypeDomainKeyword, "example"},
+	}, domains["example"])
+	require.Equal(t, []geosite.Item{
+		{geosite.RuleTypeDomain, "example.org"},
+		{geosite.RuleTypeDomainSuffix, ".example.org"},
+	}, domains["example@cn"])
+	require.Len(t, domains["example@!ads"], 5)
+	require.Len(t, domains["extra"], 3)
+	require.Equal(t, []geosite.Item{
+		{geosite.RuleTypeDomain, "example.net"},
+		{geosite.RuleTypeDomainSuffix, ".example.net"},
+	}, domains["other"])
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "loop"), []byte("include:loop"), 0o644))
+	_, err = geosite.ReadSource(directory)
+	require.ErrorContains(t, err, "circular include")
+}
@@ -0,0 +1,215 @@
+package geosite
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type sourceEntry struct {
+	Item
+	attributes []string
+}
+
+type sourceInclude struct {
+	code       string
+	attributes []string
+	excluded   []string
+}
+
+type sourceList struct {
+	entries  []sourceEntry
+	includes []sourceInclude
+}
+
+// ReadSource reads a v2fly domain-list-community style data directory, where each file is a category named by the file name.
+//
+// Besides the categories themselves, `<category>@<attribute>` and `<category>@!<attribute>` categories
+// are generated for every attribute used in a category.
+func ReadSource(path string) (map[string][]Item, error) {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	lists := make(map[string]*sourceList)
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+		code := strings.ToLower(dirEntry.Name())
+		err = readSourceFile(filepath.Join(path, dirEntry.Name()), code, lists)
+		if err != nil {
+			return nil, E.Cause(err, "read ", dirEntry.Name())
+		}
+	}
+	resolved := make(map[string][]sourceEntry)
+	for code := range lists {
+		_, err = resolveSourceList(code, lists, resolved, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	domains := make(map[string][]Item)
+	for code, entries := range resolved {
+		domains[code] = compileSourceEntries(entries, nil)
+		var attributes []string
+		for _, entry := range entries {
+			attributes = append(attributes, entry.attributes...)
+		}
+		for _, attribute := range common.Uniq(attributes) {
+			domains[code+"@"+attribute] = compileSourceEntries(entries, func(entry sourceEntry) bool {
+				return common.Contains(entry.attributes, attribute)
+			})
+			domains[code+"@!"+attribute] = compileSourceEntries(entries, func(entry sourceEntry) bool {
+				return !common.Contains(entry.attributes, attribute)
+			})
+		}
+	}
+	return domains, nil
+}
+
+func readSourceFile(path string, code string, lists map[string]*sourceList) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	list := sourceListOf(lists, code)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var (
+			attributes   []string
+			affiliations []string
+		)
+		for _, field := range fields[1:] {
+			switch {
+			case len(field) > 1 && field[0] == '@':
+				attributes = append(attributes, strings.ToLower(field[1:]))
+			case len(field) > 1 && field[0] == '&':
+				affiliations = append(affiliations, strings.ToLower(field[1:]))
+			default:
+				return E.New("line ", lineNumber, ": invalid field: ", field)
+			}
+		}
+		ruleType, value, found := strings.Cut(fields[0], ":")
+		if !found {
+			ruleType, value = "domain", fields[0]
+		}
+		if ruleType == "include" {
+			include := sourceInclude{code: strings.ToLower(value)}
+			for _, attribute := range attributes {
+				if strings.HasPrefix(attribute, "-") {
+					include.excluded = append(include.excluded, attribute[1:])
+				} else {
+					include.attributes = append(include.attributes, attribute)
+				}
+			}
+			list.includes = append(list.includes, include)
+			continue
+		}
+		entry := sourceEntry{attributes: attributes}
+		switch ruleType {
+		case "domain":
+			entry.Type = RuleTypeDomainSuffix
+		case "full":
+			entry.Type = RuleTypeDomain
+		case "keyword":
+			entry.Type = RuleTypeDomainKeyword
+		case "regexp":
+			entry.Type = RuleTypeDomainRegex
+		default:
+			return E.New("line ", lineNumber, ": unknown rule type: ", ruleType)
+		}
+		if entry.Type == RuleTypeDomainRegex {
+			entry.Value = value
+		} else {
+			entry.Value = strings.ToLower(value)
+		}
+		if entry.Value == "" {
+			return E.New("line ", lineNumber, ": empty value")
+		}
+		list.entries = append(list.entries, entry)
+		for _, affiliation := range affiliations {
+			affiliationList := sourceListOf(lists, affiliation)
+			affiliationList.entries = append(affiliationList.entries, entry)
+		}
+	}
+	return scanner.Err()
+}
+
+func sourceListOf(lists map[string]*sourceList, code string) *sourceList {
+	list := lists[code]
+	if list == nil {
+		list = &sourceList{}
+		lists[code] = list
+	}
+	return list
+}
+
+func resolveSourceList(code string, lists map[string]*sourceList, resolved map[string][]sourceEntry, stack []string) ([]sourceEntry, error) {
+	if entries, loaded := resolved[code]; loaded {
+		return entries, nil
+	}
+	if common.Contains(stack, code) {
+		return nil, E.New("circular include: ", strings.Join(append(stack, code), " -> "))
+	}
+	list := lists[code]
+	if list == nil {
+		return nil, E.New("include missing category: ", code)
+	}
+	entries := append([]sourceEntry(nil), list.entries...)
+	for _, include := range list.includes {
+		includedEntries, err := resolveSourceList(include.code, lists, resolved, append(stack, code))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range includedEntries {
+			if common.All(include.attributes, func(attribute string) bool {
+				return common.Contains(entry.attributes, attribute)
+			}) && !common.Any(include.excluded, func(attribute string) bool {
+				return common.Contains(entry.attributes, attribute)
+			}) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	resolved[code] = entries
+	return entries, nil
+}
+
+func compileSourceEntries(entries []sourceEntry, filter func(entry sourceEntry) bool) []Item {
+	itemMap := make(map[Item]bool)
+	var items []Item
+	for _, entry := range entries {
+		if filter != nil && !filter(entry) {
+			continue
+		}
+		var entryItems []Item
+		if entry.Type == RuleTypeDomainSuffix {
+			// domain: of domain-list-community matches the domain and its subdomains
+			entryItems = []Item{{RuleTypeDomain, entry.Value}, {RuleTypeDomainSuffix, "." + entry.Value}}
+		} else {
+			entryItems = []Item{entry.Item}
+		}
+		for _, item := range entryItems {
+			if !itemMap[item] {
+				itemMap[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Type < items[j].Type
+	})
+	return items
+}
@@ -146,3 +146,16 @@ func ReadUvarint(r io.ByteReader) (uint64, error) {
 	}
 	return uint64(b8) + uint64(b7)<<8 + uint64(b6)<<16 + uint64(b5)<<24 + uint64(b4)<<32 + uint64(b3)<<40 + uint64(b2)<<48 + uint64(b1)<<56, nil
 }
+
+func AppendUvarint(b []byte, value uint64) []byte {
+	switch {
+	case value <= 63:
+		return append(b, uint8(value))
+	case value <= 16383:
+		return append(b, uint8(value>>8)|0x40, uint8(value))
+	case value <= 1073741823:
+		return append(b, uint8(value>>24)|0x80, uint8(value>>16), uint8(value>>8), uint8(value))
+	default:
+		return append(b, uint8(value>>56)|0xc0, uint8(value>>48), uint8(value>>40), uint8(value>>32), uint8(value>>24), uint8(value>>16), uint8(value>>8), uint8(value))
+	}
+}
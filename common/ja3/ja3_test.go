@@ -0,0 +1,50 @@
+package ja3_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/ja3"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readClientHello(t *testing.T, nextProtos []string) []byte {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	go tls.Client(client, &tls.Config{
+		ServerName: "www.google.com",
+		NextProtos: nextProtos,
+	}).Handshake()
+	clientHello := make([]byte, 4096)
+	n, err := server.Read(clientHello)
+	require.NoError(t, err)
+	return clientHello[:n]
+}
+
+func TestComputeALPN(t *testing.T) {
+	t.Parallel()
+	fingerprint, err := ja3.Compute(readClientHello(t, []string{"h2", "http/1.1"}))
+	require.NoError(t, err)
+	require.Equal(t, "www.google.com", fingerprint.ServerName)
+	require.Equal(t, []string{"h2", "http/1.1"}, fingerprint.ALPN)
+}
+
+func TestComputeMalformedALPN(t *testing.T) {
+	t.Parallel()
+	clientHello := readClientHello(t, []string{"h2", "http/1.1"})
+	alpnExtension := []byte{0x00, 0x10, 0x00, 0x0e, 0x00, 0x0c, 0x02, 'h', '2'}
+	index := bytes.Index(clientHello, alpnExtension)
+	require.NotEqual(t, -1, index)
+	// break the length of the protocol name list
+	clientHello[index+5] = 0x0d
+	fingerprint, err := ja3.Compute(clientHello)
+	require.NoError(t, err)
+	require.Equal(t, "www.google.com", fingerprint.ServerName)
+	require.Empty(t, fingerprint.ALPN)
+}
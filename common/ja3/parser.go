@@ -26,6 +26,7 @@ const (
 	ecpfExtensionHeaderLen                int    = 1
 	versionExtensionHeaderLen             int    = 1
 	signatureAlgorithmsExtensionHeaderLen int    = 2
+	alpnExtensionHeaderLen                int    = 2
 	contentType                           uint8  = 22
 	handshakeType                         uint8  = 1
 	sniExtensionType                      uint16 = 0
@@ -34,6 +35,7 @@ const (
 	ecpfExtensionType                     uint16 = 11
 	versionExtensionType                  uint16 = 43
 	signatureAlgorithmsExtensionType      uint16 = 13
+	alpnExtensionType                     uint16 = 16
 
 	// Versions
 	// The bitmask covers the versions SSL3.0 to TLS1.2
@@ -176,6 +178,7 @@ func (j *ClientHello) parseExtensions(exs []byte) error {
 	var ellipticCurvePF []uint8
 	var versions []uint16
 	var signatureAlgorithms []uint16
+	var alpn []string
 	for len(exs) > 0 {
 
 		// Check if we can decode the next fields
@@ -275,6 +278,9 @@ func (j *ClientHello) parseExtensions(exs []byte) error {
 			for i := 0; i < int(ssaLen); i += 2 {
 				signatureAlgorithms = append(signatureAlgorithms, binary.BigEndian.Uint16(sex[2:][i:]))
 			}
+		case alpnExtensionType: // Extensions: application_layer_protocol_negotiation
+			// ALPN is not part of the fingerprint, so a malformed extension is ignored
+			alpn = parseALPN(sex)
 		}
 		exs = exs[4+exLen:]
 	}
@@ -284,9 +290,32 @@ func (j *ClientHello) parseExtensions(exs []byte) error {
 	j.EllipticCurvePF = ellipticCurvePF
 	j.Versions = versions
 	j.SignatureAlgorithms = signatureAlgorithms
+	j.ALPN = alpn
 	return nil
 }
 
+// parseALPN returns the protocols of an ALPN extension, or nil if it is malformed.
+func parseALPN(sex []byte) []string {
+	if len(sex) < alpnExtensionHeaderLen {
+		return nil
+	}
+	alpnLen := binary.BigEndian.Uint16(sex)
+	sex = sex[alpnExtensionHeaderLen:]
+	if len(sex) != int(alpnLen) {
+		return nil
+	}
+	var alpn []string
+	for len(sex) > 0 {
+		protoLen := int(sex[0])
+		if protoLen == 0 || len(sex) < 1+protoLen {
+			return nil
+		}
+		alpn = append(alpn, string(sex[1:1+protoLen]))
+		sex = sex[1+protoLen:]
+	}
+	return alpn
+}
+
 // marshalJA3 into a byte string
 func (j *ClientHello) marshalJA3() {
 	// An uint16 can contain numbers with up to 5 digits and an uint8 can contain numbers with up to 3 digits, but we
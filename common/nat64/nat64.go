@@ -0,0 +1,42 @@
+package nat64
+
+import (
+	"net/netip"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// ValidatePrefix checks that prefix is an IPv6 prefix of a length allowed by RFC 6052.
+func ValidatePrefix(prefix netip.Prefix) error {
+	if !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return E.New("NAT64 prefix must be IPv6: ", prefix)
+	}
+	switch prefix.Bits() {
+	case 32, 40, 48, 56, 64, 96:
+		return nil
+	default:
+		return E.New("invalid NAT64 prefix length: ", prefix.Bits(), ", must be one of 32, 40, 48, 56, 64 or 96")
+	}
+}
+
+// Address embeds the IPv4 address into the prefix as described in RFC 6052,
+// skipping bits 64 to 71 which must be zero.
+// Addresses other than IPv4 are returned unchanged.
+func Address(prefix netip.Prefix, address netip.Addr) netip.Addr {
+	address = address.Unmap()
+	if !address.Is4() {
+		return address
+	}
+	ipv6 := prefix.Masked().Addr().As16()
+	ipv6[8] = 0
+	ipv4 := address.As4()
+	index := prefix.Bits() / 8
+	for _, octet := range ipv4 {
+		if index == 8 {
+			index++
+		}
+		ipv6[index] = octet
+		index++
+	}
+	return netip.AddrFrom16(ipv6)
+}
@@ -0,0 +1,12 @@
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+)
+
+// Resolver resolves hardware addresses of hosts on local networks from the neighbour table.
+type Resolver interface {
+	Lookup(address netip.Addr) (net.HardwareAddr, bool)
+	Close() error
+}
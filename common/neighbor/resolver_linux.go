@@ -0,0 +1,130 @@
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/sagernet/netlink"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"golang.org/x/sys/unix"
+)
+
+var _ Resolver = (*linuxResolver)(nil)
+
+type linuxResolver struct {
+	logger    logger.Logger
+	access    sync.RWMutex
+	neighbors map[netip.Addr]net.HardwareAddr
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewResolver(logger logger.Logger) (Resolver, error) {
+	resolver := &linuxResolver{
+		logger:    logger,
+		neighbors: make(map[netip.Addr]net.HardwareAddr),
+		done:      make(chan struct{}),
+	}
+	updates, subscriptionDone, err := resolver.subscribe()
+	if err != nil {
+		return nil, E.Cause(err, "subscribe neighbor updates")
+	}
+	go resolver.loopUpdate(updates, subscriptionDone)
+	return resolver, nil
+}
+
+func (r *linuxResolver) subscribe() (<-chan netlink.NeighUpdate, chan struct{}, error) {
+	updates := make(chan netlink.NeighUpdate, 64)
+	subscriptionDone := make(chan struct{})
+	err := netlink.NeighSubscribeWithOptions(updates, subscriptionDone, netlink.NeighSubscribeOptions{
+		ErrorCallback: func(err error) {
+			select {
+			case <-r.done:
+			default:
+				r.logger.Error(E.Cause(err, "neighbor subscription"))
+			}
+		},
+		ListExisting: true,
+	})
+	if err != nil {
+		close(subscriptionDone)
+		return nil, nil, err
+	}
+	return updates, subscriptionDone, nil
+}
+
+func (r *linuxResolver) loopUpdate(updates <-chan netlink.NeighUpdate, subscriptionDone chan struct{}) {
+	for {
+		select {
+		case <-r.done:
+			close(subscriptionDone)
+			for range updates {
+			}
+			return
+		case update, loaded := <-updates:
+			if loaded {
+				r.update(update)
+				continue
+			}
+			// the subscription stops on any receive error, e.g. ENOBUFS when the table changes too fast
+			close(subscriptionDone)
+			updates, subscriptionDone = r.resubscribe()
+			if updates == nil {
+				return
+			}
+		}
+	}
+}
+
+func (r *linuxResolver) resubscribe() (<-chan netlink.NeighUpdate, chan struct{}) {
+	for {
+		select {
+		case <-r.done:
+			return nil, nil
+		case <-time.After(time.Second):
+		}
+		updates, subscriptionDone, err := r.subscribe()
+		if err != nil {
+			r.logger.Error(E.Cause(err, "resubscribe neighbor updates"))
+			continue
+		}
+		// updates may have been lost, rebuild the table from the new listing
+		r.access.Lock()
+		r.neighbors = make(map[netip.Addr]net.HardwareAddr)
+		r.access.Unlock()
+		return updates, subscriptionDone
+	}
+}
+
+func (r *linuxResolver) update(update netlink.NeighUpdate) {
+	address := M.AddrFromIP(update.IP).Unmap()
+	if !address.IsValid() {
+		return
+	}
+	r.access.Lock()
+	defer r.access.Unlock()
+	if update.Type == unix.RTM_DELNEIGH || len(update.HardwareAddr) == 0 || update.State&(netlink.NUD_FAILED|netlink.NUD_INCOMPLETE) != 0 {
+		delete(r.neighbors, address)
+	} else {
+		r.neighbors[address] = update.HardwareAddr
+	}
+}
+
+func (r *linuxResolver) Lookup(address netip.Addr) (net.HardwareAddr, bool) {
+	r.access.RLock()
+	defer r.access.RUnlock()
+	hardwareAddr, loaded := r.neighbors[address.Unmap()]
+	return hardwareAddr, loaded
+}
+
+func (r *linuxResolver) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.done)
+	})
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package neighbor
+
+import (
+	"os"
+
+	"github.com/sagernet/sing/common/logger"
+)
+
+func NewResolver(_ logger.Logger) (Resolver, error) {
+	return nil, os.ErrInvalid
+}
@@ -20,6 +20,11 @@ var ErrNotFound = E.New("process not found")
 type Config struct {
 	Logger         log.ContextLogger
 	PackageManager tun.PackageManager
+
+	// Linux only, since each requires additional reads from /proc for every connection
+	FindCmdline   bool
+	FindAncestors bool
+	FindCgroup    bool
 }
 
 type Info struct {
@@ -28,6 +33,14 @@ type Info struct {
 	PackageName string
 	User        string
 	UserId      int32
+
+	// Linux only
+	ProcessCmdline    string
+	ParentProcessName string
+	// AncestorPaths contains the executable paths of the parent processes, the closest first.
+	AncestorPaths []string
+	// CgroupPath is the cgroup v2 path without the leading slash, e.g. system.slice/foo.service.
+	CgroupPath string
 }
 
 func FindProcessInfo(searcher Searcher, ctx context.Context, network string, source netip.AddrPort, destination netip.AddrPort) (*Info, error) {
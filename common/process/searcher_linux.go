@@ -13,10 +13,11 @@ var _ Searcher = (*linuxSearcher)(nil)
 
 type linuxSearcher struct {
 	logger log.ContextLogger
+	config Config
 }
 
 func NewSearcher(config Config) (Searcher, error) {
-	return &linuxSearcher{config.Logger}, nil
+	return &linuxSearcher{config.Logger, config}, nil
 }
 
 func (s *linuxSearcher) FindProcessInfo(ctx context.Context, network string, source netip.AddrPort, destination netip.AddrPort) (*Info, error) {
@@ -24,12 +25,25 @@ func (s *linuxSearcher) FindProcessInfo(ctx context.Context, network string, sou
 	if err != nil {
 		return nil, err
 	}
-	processPath, err := resolveProcessNameByProcSearch(inode, uid)
+	pid, processPath, err := resolveProcessNameByProcSearch(inode, uid)
 	if err != nil {
 		s.logger.DebugContext(ctx, "find process path: ", err)
 	}
-	return &Info{
+	info := &Info{
+		ProcessID:   pid,
 		UserId:      int32(uid),
 		ProcessPath: processPath,
-	}, nil
+	}
+	if pid != 0 {
+		if s.config.FindCmdline {
+			info.ProcessCmdline = resolveProcessCmdline(pathProc, pid)
+		}
+		if s.config.FindAncestors {
+			info.ParentProcessName, info.AncestorPaths = resolveProcessAncestors(pathProc, pid)
+		}
+		if s.config.FindCgroup {
+			info.CgroupPath = resolveProcessCgroup(pathProc, pid)
+		}
+	}
+	return info, nil
 }
@@ -10,6 +10,7 @@ import (
 	"net/netip"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"syscall"
 	"unicode"
@@ -150,10 +151,10 @@ func unpackSocketDiagResponse(msg *syscall.NetlinkMessage) (inode, uid uint32) {
 	return
 }
 
-func resolveProcessNameByProcSearch(inode, uid uint32) (string, error) {
+func resolveProcessNameByProcSearch(inode, uid uint32) (uint32, string, error) {
 	files, err := os.ReadDir(pathProc)
 	if err != nil {
-		return "", err
+		return 0, "", err
 	}
 
 	buffer := make([]byte, syscall.PathMax)
@@ -166,7 +167,7 @@ func resolveProcessNameByProcSearch(inode, uid uint32) (string, error) {
 
 		info, err := f.Info()
 		if err != nil {
-			return "", err
+			return 0, "", err
 		}
 		if info.Sys().(*syscall.Stat_t).Uid != uid {
 			continue
@@ -187,12 +188,86 @@ func resolveProcessNameByProcSearch(inode, uid uint32) (string, error) {
 			}
 
 			if bytes.Equal(buffer[:n], socket) {
-				return os.Readlink(path.Join(processPath, "exe"))
+				pid, _ := strconv.ParseUint(f.Name(), 10, 32)
+				exePath, err := os.Readlink(path.Join(processPath, "exe"))
+				return uint32(pid), exePath, err
 			}
 		}
 	}
 
-	return "", fmt.Errorf("process of uid(%d),inode(%d) not found", uid, inode)
+	return 0, "", fmt.Errorf("process of uid(%d),inode(%d) not found", uid, inode)
+}
+
+// resolveProcessCmdline returns the arguments of the process joined by spaces.
+func resolveProcessCmdline(procRoot string, pid uint32) string {
+	content, err := os.ReadFile(path.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	return strings.ReplaceAll(strings.TrimRight(string(content), "\x00"), "\x00", " ")
+}
+
+// resolveProcessCgroup returns the cgroup v2 path of the process,
+// which is empty for processes in cgroup v1 only hierarchies.
+func resolveProcessCgroup(procRoot string, pid uint32) string {
+	content, err := os.ReadFile(path.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if cgroupPath, found := strings.CutPrefix(line, "0::"); found {
+			return strings.TrimPrefix(cgroupPath, "/")
+		}
+	}
+	return ""
+}
+
+// resolveProcessAncestors walks the parent processes up to init, the closest first.
+// Executable paths that cannot be read, e.g. of processes of other users, are skipped,
+// and the name of such a parent process is taken from its command name.
+func resolveProcessAncestors(procRoot string, pid uint32) (parentName string, ancestorPaths []string) {
+	for depth := 0; depth < 64; depth++ {
+		ppid, err := resolveParentProcessID(procRoot, pid)
+		if err != nil || ppid == 0 || ppid == pid {
+			return
+		}
+		pid = ppid
+		processPath := path.Join(procRoot, strconv.FormatUint(uint64(pid), 10))
+		exePath, err := os.Readlink(path.Join(processPath, "exe"))
+		if err == nil {
+			ancestorPaths = append(ancestorPaths, exePath)
+		}
+		if depth == 0 {
+			if exePath != "" {
+				parentName = path.Base(exePath)
+			} else {
+				comm, _ := os.ReadFile(path.Join(processPath, "comm"))
+				parentName = strings.TrimSpace(string(comm))
+			}
+		}
+	}
+	return
+}
+
+func resolveParentProcessID(procRoot string, pid uint32) (uint32, error) {
+	stat, err := os.ReadFile(path.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// the command name in parentheses may contain spaces and parentheses
+	commEnd := bytes.LastIndexByte(stat, ')')
+	if commEnd == -1 {
+		return 0, E.New("invalid stat of process ", pid)
+	}
+	fields := strings.Fields(string(stat[commEnd+1:]))
+	if len(fields) < 2 {
+		return 0, E.New("invalid stat of process ", pid)
+	}
+	ppid, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(ppid), nil
 }
 
 func isPid(s string) bool {
@@ -0,0 +1,122 @@
+//go:build linux
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testProcess struct {
+	pid     uint32
+	stat    string
+	exe     string
+	comm    string
+	cmdline string
+	cgroup  string
+}
+
+func writeTestProcesses(t *testing.T, processes ...testProcess) string {
+	procRoot := t.TempDir()
+	for _, process := range processes {
+		processPath := filepath.Join(procRoot, strconv.FormatUint(uint64(process.pid), 10))
+		require.NoError(t, os.Mkdir(processPath, 0o755))
+		for name, content := range map[string]string{
+			"stat":    process.stat,
+			"comm":    process.comm,
+			"cmdline": process.cmdline,
+			"cgroup":  process.cgroup,
+		} {
+			if content != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(processPath, name), []byte(content), 0o644))
+			}
+		}
+		if process.exe != "" {
+			require.NoError(t, os.Symlink(process.exe, filepath.Join(processPath, "exe")))
+		}
+	}
+	return procRoot
+}
+
+func TestResolveParentProcessID(t *testing.T) {
+	t.Parallel()
+	procRoot := writeTestProcesses(t,
+		testProcess{pid: 10, stat: "10 (bash) S 1 10 10 0 -1 4194560 1000 0 0 0\n"},
+		testProcess{pid: 11, stat: "11 (my (odd) ) name) R 10 11 10 34816 11 4194304\n"},
+		testProcess{pid: 12, stat: "12 (no-close S 10 12"},
+		testProcess{pid: 13, stat: "13 (short) S"},
+	)
+	ppid, err := resolveParentProcessID(procRoot, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), ppid)
+	ppid, err = resolveParentProcessID(procRoot, 11)
+	require.NoError(t, err)
+	require.Equal(t, uint32(10), ppid)
+	_, err = resolveParentProcessID(procRoot, 12)
+	require.Error(t, err)
+	_, err = resolveParentProcessID(procRoot, 13)
+	require.Error(t, err)
+	_, err = resolveParentProcessID(procRoot, 14)
+	require.Error(t, err)
+}
+
+func TestResolveProcessAncestors(t *testing.T) {
+	t.Parallel()
+	procRoot := writeTestProcesses(t,
+		testProcess{pid: 1, stat: "1 (systemd) S 0 1 1 0", exe: "/usr/lib/systemd/systemd"},
+		testProcess{pid: 100, stat: "100 (sshd: user@pts/0) S 1 100 100 0", comm: "sshd\n"},
+		testProcess{pid: 200, stat: "200 (bash) S 100 200 200 0", exe: "/usr/bin/bash"},
+		testProcess{pid: 300, stat: "300 (curl) S 200 300 200 0", exe: "/usr/bin/curl"},
+		testProcess{pid: 400, stat: "400 (loop) S 400 400 400 0", exe: "/usr/bin/loop"},
+	)
+	parentName, ancestorPaths := resolveProcessAncestors(procRoot, 300)
+	require.Equal(t, "bash", parentName)
+	require.Equal(t, []string{"/usr/bin/bash", "/usr/lib/systemd/systemd"}, ancestorPaths)
+
+	parentName, ancestorPaths = resolveProcessAncestors(procRoot, 200)
+	require.Equal(t, "sshd", parentName)
+	require.Equal(t, []string{"/usr/lib/systemd/systemd"}, ancestorPaths)
+
+	parentName, ancestorPaths = resolveProcessAncestors(procRoot, 1)
+	require.Empty(t, parentName)
+	require.Empty(t, ancestorPaths)
+
+	parentName, ancestorPaths = resolveProcessAncestors(procRoot, 400)
+	require.Empty(t, parentName)
+	require.Empty(t, ancestorPaths)
+
+	parentName, ancestorPaths = resolveProcessAncestors(procRoot, 500)
+	require.Empty(t, parentName)
+	require.Empty(t, ancestorPaths)
+}
+
+func TestResolveProcessCmdline(t *testing.T) {
+	t.Parallel()
+	procRoot := writeTestProcesses(t,
+		testProcess{pid: 10, stat: "10 (curl) S 1", cmdline: "curl\x00-x\x00socks5://127.0.0.1:1080\x00https://example.com/a b\x00"},
+	)
+	require.Equal(t, "curl -x socks5://127.0.0.1:1080 https://example.com/a b", resolveProcessCmdline(procRoot, 10))
+	require.Empty(t, resolveProcessCmdline(procRoot, 11))
+}
+
+func TestResolveProcessCgroup(t *testing.T) {
+	t.Parallel()
+	procRoot := writeTestProcesses(t,
+		testProcess{pid: 10, cgroup: "0::/system.slice/foo.service\n"},
+		testProcess{pid: 11, cgroup: "12:cpu,cpuacct:/user.slice\n" +
+			"11:memory:/user.slice/user-1000.slice\n" +
+			"1:name=systemd:/user.slice/user-1000.slice/session-2.scope\n" +
+			"0::/user.slice/user-1000.slice/session-2.scope\n"},
+		testProcess{pid: 12, cgroup: "12:cpu,cpuacct:/\n1:name=systemd:/init.scope\n"},
+		testProcess{pid: 13, cgroup: "0::/\n"},
+	)
+	require.Equal(t, "system.slice/foo.service", resolveProcessCgroup(procRoot, 10))
+	require.Equal(t, "user.slice/user-1000.slice/session-2.scope", resolveProcessCgroup(procRoot, 11))
+	require.Empty(t, resolveProcessCgroup(procRoot, 12))
+	require.Empty(t, resolveProcessCgroup(procRoot, 13))
+	require.Empty(t, resolveProcessCgroup(procRoot, 14))
+}
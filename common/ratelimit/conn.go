@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"golang.org/x/time/rate"
+)
+
+// Conn limits the inbound side of a connection: reads are counted as upload and writes as download.
+type Conn struct {
+	net.Conn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	bucket    *Bucket
+	closeOnce sync.Once
+}
+
+func NewConn(ctx context.Context, conn net.Conn, bucket *Bucket) *Conn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Conn{
+		Conn:   conn,
+		ctx:    ctx,
+		cancel: cancel,
+		bucket: bucket,
+	}
+}
+
+func (c *Conn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 {
+		waitErr := wait(c.ctx, c.bucket.upload, n)
+		if err == nil {
+			err = waitErr
+		}
+	}
+	return
+}
+
+func (c *Conn) Write(p []byte) (n int, err error) {
+	if c.bucket.download == nil {
+		return c.Conn.Write(p)
+	}
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > c.bucket.download.Burst() {
+			chunk = chunk[:c.bucket.download.Burst()]
+		}
+		err = wait(c.ctx, c.bucket.download, len(chunk))
+		if err != nil {
+			return
+		}
+		var written int
+		written, err = c.Conn.Write(chunk)
+		n += written
+		if err != nil {
+			return
+		}
+		p = p[written:]
+	}
+	return
+}
+
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.bucket.Release()
+	})
+	return c.Conn.Close()
+}
+
+func (c *Conn) Upstream() any {
+	return c.Conn
+}
+
+// PacketConn limits the inbound side of a packet connection: reads are counted as upload and writes as download.
+type PacketConn struct {
+	N.PacketConn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	bucket    *Bucket
+	closeOnce sync.Once
+}
+
+func NewPacketConn(ctx context.Context, conn N.PacketConn, bucket *Bucket) *PacketConn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &PacketConn{
+		PacketConn: conn,
+		ctx:        ctx,
+		cancel:     cancel,
+		bucket:     bucket,
+	}
+}
+
+func (c *PacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err != nil {
+		return
+	}
+	err = wait(c.ctx, c.bucket.upload, buffer.Len())
+	return
+}
+
+func (c *PacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	err := wait(c.ctx, c.bucket.download, buffer.Len())
+	if err != nil {
+		buffer.Release()
+		return err
+	}
+	return c.PacketConn.WritePacket(buffer, destination)
+}
+
+func (c *PacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.bucket.Release()
+	})
+	return c.PacketConn.Close()
+}
+
+func (c *PacketConn) Upstream() any {
+	return c.PacketConn
+}
+
+func wait(ctx context.Context, limiter *rate.Limiter, n int) error {
+	if limiter == nil {
+		return nil
+	}
+	for n > 0 {
+		chunk := n
+		if chunk > limiter.Burst() {
+			chunk = limiter.Burst()
+		}
+		err := limiter.WaitN(ctx, chunk)
+		if err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
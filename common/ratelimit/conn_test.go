@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBytesPerSecond = 256 * 1024
+	// the full burst is available at once, the rest must take at least half a second
+	testTransferSize = testBytesPerSecond + testBytesPerSecond/2
+)
+
+func TestConnUpload(t *testing.T) {
+	t.Parallel()
+	limited, remote := net.Pipe()
+	conn := NewConn(context.Background(), limited, NewGroup(testBytesPerSecond, 0, true).Acquire(""))
+	defer conn.Close()
+	defer remote.Close()
+	elapsed := measureTransfer(t, remote, conn)
+	require.GreaterOrEqual(t, elapsed, 400*time.Millisecond)
+	require.Less(t, elapsed, 3*time.Second)
+	require.Less(t, measureTransfer(t, conn, remote), 400*time.Millisecond)
+}
+
+func TestConnDownload(t *testing.T) {
+	t.Parallel()
+	limited, remote := net.Pipe()
+	conn := NewConn(context.Background(), limited, NewGroup(0, testBytesPerSecond, true).Acquire(""))
+	defer conn.Close()
+	defer remote.Close()
+	elapsed := measureTransfer(t, conn, remote)
+	require.GreaterOrEqual(t, elapsed, 400*time.Millisecond)
+	require.Less(t, elapsed, 3*time.Second)
+	require.Less(t, measureTransfer(t, remote, conn), 400*time.Millisecond)
+}
+
+func TestConnCloseCancelsWait(t *testing.T) {
+	t.Parallel()
+	limited, remote := net.Pipe()
+	conn := NewConn(context.Background(), limited, NewGroup(0, minBurst, true).Acquire(""))
+	defer remote.Close()
+	go io.Copy(io.Discard, remote)
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(make([]byte, 10*minBurst))
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	conn.Close()
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("write not canceled by close")
+	}
+}
+
+func measureTransfer(t *testing.T, writer io.Writer, reader io.Reader) time.Duration {
+	startAt := time.Now()
+	writeDone := make(chan error, 1)
+	go func() {
+		data := make([]byte, 16*1024)
+		var err error
+		for written := 0; written < testTransferSize && err == nil; written += len(data) {
+			_, err = writer.Write(data)
+		}
+		writeDone <- err
+	}()
+	_, err := io.ReadFull(reader, make([]byte, testTransferSize))
+	require.NoError(t, err)
+	require.NoError(t, <-writeDone)
+	return time.Since(startAt)
+}
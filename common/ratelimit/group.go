@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const minBurst = 64 * 1024
+
+// Group holds token buckets shared by connections with the same key.
+type Group struct {
+	uploadBytesPerSecond   uint64
+	downloadBytesPerSecond uint64
+	perConnection          bool
+	access                 sync.Mutex
+	buckets                map[string]*Bucket
+}
+
+type Bucket struct {
+	group    *Group
+	key      string
+	refs     int
+	upload   *rate.Limiter
+	download *rate.Limiter
+}
+
+func NewGroup(uploadBytesPerSecond uint64, downloadBytesPerSecond uint64, perConnection bool) *Group {
+	return &Group{
+		uploadBytesPerSecond:   uploadBytesPerSecond,
+		downloadBytesPerSecond: downloadBytesPerSecond,
+		perConnection:          perConnection,
+		buckets:                make(map[string]*Bucket),
+	}
+}
+
+// Acquire returns the bucket for the key, the bucket must be released when the connection is closed.
+func (g *Group) Acquire(key string) *Bucket {
+	if g.perConnection {
+		return g.newBucket(key)
+	}
+	g.access.Lock()
+	defer g.access.Unlock()
+	bucket, loaded := g.buckets[key]
+	if !loaded {
+		bucket = g.newBucket(key)
+		g.buckets[key] = bucket
+	}
+	bucket.refs++
+	return bucket
+}
+
+func (g *Group) newBucket(key string) *Bucket {
+	return &Bucket{
+		group:    g,
+		key:      key,
+		upload:   newLimiter(g.uploadBytesPerSecond),
+		download: newLimiter(g.downloadBytesPerSecond),
+	}
+}
+
+func (b *Bucket) Release() {
+	if b.group.perConnection {
+		return
+	}
+	b.group.access.Lock()
+	defer b.group.access.Unlock()
+	b.refs--
+	if b.refs == 0 {
+		delete(b.group.buckets, b.key)
+	}
+}
+
+func newLimiter(bytesPerSecond uint64) *rate.Limiter {
+	if bytesPerSecond == 0 {
+		return nil
+	}
+	burst := bytesPerSecond
+	if burst < minBurst {
+		burst = minBurst
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(burst))
+}
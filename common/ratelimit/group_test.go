@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupShared(t *testing.T) {
+	t.Parallel()
+	group := NewGroup(1000, 0, false)
+	bucketA := group.Acquire("a")
+	require.Same(t, bucketA, group.Acquire("a"))
+	require.NotSame(t, bucketA, group.Acquire("b"))
+	require.Nil(t, bucketA.download)
+	bucketA.Release()
+	require.Contains(t, group.buckets, "a")
+	bucketA.Release()
+	require.NotContains(t, group.buckets, "a")
+}
+
+func TestGroupPerConnection(t *testing.T) {
+	t.Parallel()
+	group := NewGroup(1000, 1000, true)
+	bucket := group.Acquire("")
+	require.NotSame(t, bucket, group.Acquire(""))
+	bucket.Release()
+	require.Empty(t, group.buckets)
+}
@@ -0,0 +1,72 @@
+//go:build with_script
+
+package script
+
+import (
+	"net/netip"
+	"path/filepath"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+func newMetadata(metadata *adapter.InboundContext) starlark.Value {
+	domain := metadata.Domain
+	if domain == "" {
+		domain = metadata.Destination.Fqdn
+	}
+	var sourceIP, destinationIP string
+	if metadata.Source.IsIP() {
+		sourceIP = metadata.Source.Addr.String()
+	}
+	if metadata.Destination.IsIP() {
+		destinationIP = metadata.Destination.Addr.String()
+	}
+	var sourceMACAddress string
+	if metadata.SourceMACAddress != nil {
+		sourceMACAddress = metadata.SourceMACAddress.String()
+	}
+	fields := starlark.StringDict{
+		"network":               starlark.String(metadata.Network),
+		"inbound":               starlark.String(metadata.Inbound),
+		"inbound_type":          starlark.String(metadata.InboundType),
+		"ip_version":            starlark.MakeInt(int(metadata.IPVersion)),
+		"source_ip":             starlark.String(sourceIP),
+		"source_port":           starlark.MakeInt(int(metadata.Source.Port)),
+		"source_mac_address":    starlark.String(sourceMACAddress),
+		"source_device":         starlark.String(metadata.SourceDevice),
+		"destination":           starlark.String(metadata.Destination.AddrString()),
+		"destination_ip":        starlark.String(destinationIP),
+		"destination_port":      starlark.MakeInt(int(metadata.Destination.Port)),
+		"destination_addresses": starlark.Tuple(common.Map(metadata.DestinationAddresses, addrValue)),
+		"domain":                starlark.String(domain),
+		"protocol":              starlark.String(metadata.Protocol),
+		"client":                starlark.String(metadata.Client),
+		"http_method":           starlark.String(metadata.HTTPMethod),
+		"http_path":             starlark.String(metadata.HTTPPath),
+		"http_user_agent":       starlark.String(metadata.HTTPUserAgent),
+		"auth_user":             starlark.String(metadata.User),
+		"process_name":          starlark.String(""),
+		"process_path":          starlark.String(""),
+		"package_name":          starlark.String(""),
+		"user":                  starlark.String(""),
+		"user_id":               starlark.MakeInt(-1),
+	}
+	if processInfo := metadata.ProcessInfo; processInfo != nil {
+		if processInfo.ProcessPath != "" {
+			fields["process_name"] = starlark.String(filepath.Base(processInfo.ProcessPath))
+			fields["process_path"] = starlark.String(processInfo.ProcessPath)
+		}
+		fields["package_name"] = starlark.String(processInfo.PackageName)
+		fields["user"] = starlark.String(processInfo.User)
+		fields["user_id"] = starlark.MakeInt(int(processInfo.UserId))
+	}
+	return starlarkstruct.FromStringDict(starlark.String("metadata"), fields)
+}
+
+func addrValue(addr netip.Addr) starlark.Value {
+	return starlark.String(addr.String())
+}
@@ -0,0 +1,29 @@
+package script
+
+import (
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/logger"
+)
+
+type Options struct {
+	Logger  logger.Logger
+	Name    string
+	Content string
+	// State is shared between scripts to keep values across script updates,
+	// a new one will be created if nil.
+	State *State
+	// MatchRuleSet implements the match_rule_set builtin.
+	MatchRuleSet func(tag string, metadata *adapter.InboundContext) (bool, error)
+}
+
+// State keeps values set by scripts through the state module.
+type State struct {
+	access sync.Mutex
+	values map[string]any
+}
+
+func NewState() *State {
+	return &State{values: make(map[string]any)}
+}
@@ -0,0 +1,12 @@
+//go:build !with_script
+
+package script
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+func New(options Options) (adapter.Script, error) {
+	return nil, E.New(`script is not included in this build, rebuild with -tags with_script`)
+}
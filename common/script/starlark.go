@@ -0,0 +1,255 @@
+//go:build with_script
+
+package script
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/lib/math"
+	"go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// maxExecutionSteps bounds a single call, so that a faulty script can not stall routing.
+const maxExecutionSteps = 1000000
+
+const metadataKey = "metadata"
+
+var _ adapter.Script = (*Script)(nil)
+
+type Script struct {
+	logger       logger.Logger
+	name         string
+	state        *State
+	matchRuleSet func(tag string, metadata *adapter.InboundContext) (bool, error)
+	functions    map[string]*starlark.Function
+}
+
+func New(options Options) (adapter.Script, error) {
+	script := &Script{
+		logger:       options.Logger,
+		name:         options.Name,
+		state:        options.State,
+		matchRuleSet: options.MatchRuleSet,
+		functions:    make(map[string]*starlark.Function),
+	}
+	if script.state == nil {
+		script.state = NewState()
+	}
+	fileOptions := &syntax.FileOptions{
+		Set:             true,
+		While:           true,
+		TopLevelControl: true,
+	}
+	globals, err := starlark.ExecFileOptions(fileOptions, script.newThread(), script.name, options.Content, script.predeclared())
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	globals.Freeze()
+	for name, value := range globals {
+		function, isFunction := value.(*starlark.Function)
+		if isFunction {
+			script.functions[name] = function
+		}
+	}
+	return script, nil
+}
+
+func (s *Script) HasFunction(function string) bool {
+	_, loaded := s.functions[function]
+	return loaded
+}
+
+func (s *Script) Call(function string, metadata *adapter.InboundContext) (any, error) {
+	scriptFunction, loaded := s.functions[function]
+	if !loaded {
+		return nil, E.New("function not found: ", function)
+	}
+	thread := s.newThread()
+	thread.SetLocal(metadataKey, metadata)
+	var arguments starlark.Tuple
+	if scriptFunction.NumParams() > 0 {
+		arguments = starlark.Tuple{newMetadata(metadata)}
+	}
+	result, err := starlark.Call(thread, scriptFunction, arguments, nil)
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	switch value := result.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(value), nil
+	case starlark.String:
+		return string(value), nil
+	case starlark.Int:
+		intValue, ok := value.Int64()
+		if !ok {
+			return nil, E.New("returned integer out of range: ", value)
+		}
+		return intValue, nil
+	case starlark.Float:
+		return float64(value), nil
+	default:
+		return nil, E.New("unsupported return type: ", result.Type())
+	}
+}
+
+func (s *Script) newThread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: s.name,
+		Print: func(thread *starlark.Thread, message string) {
+			s.logger.Info(s.name, ": ", message)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxExecutionSteps)
+	return thread
+}
+
+func (s *Script) predeclared() starlark.StringDict {
+	return starlark.StringDict{
+		"json":           json.Module,
+		"math":           math.Module,
+		"time":           time.Module,
+		"state":          s.state.module(),
+		"random":         starlark.NewBuiltin("random", builtinRandom),
+		"match_rule_set": starlark.NewBuiltin("match_rule_set", s.builtinMatchRuleSet),
+	}
+}
+
+func builtinRandom(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 0)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Float(rand.Float64()), nil
+}
+
+func (s *Script) builtinMatchRuleSet(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) > 0 || len(args) == 0 {
+		return nil, E.New(builtin.Name(), ": expected one or more rule-set tags")
+	}
+	metadata, loaded := thread.Local(metadataKey).(*adapter.InboundContext)
+	if !loaded {
+		return nil, E.New(builtin.Name(), ": not available while loading the script")
+	}
+	if s.matchRuleSet == nil {
+		return nil, E.New(builtin.Name(), ": rule-set is not available")
+	}
+	for _, arg := range args {
+		tag, isString := starlark.AsString(arg)
+		if !isString {
+			return nil, E.New(builtin.Name(), ": expected string, got ", arg.Type())
+		}
+		matched, err := s.matchRuleSet(tag, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return starlark.True, nil
+		}
+	}
+	return starlark.False, nil
+}
+
+func (s *State) module() *starlarkstruct.Module {
+	return &starlarkstruct.Module{
+		Name: "state",
+		Members: starlark.StringDict{
+			"get":    starlark.NewBuiltin("get", s.builtinGet),
+			"set":    starlark.NewBuiltin("set", s.builtinSet),
+			"incr":   starlark.NewBuiltin("incr", s.builtinIncr),
+			"delete": starlark.NewBuiltin("delete", s.builtinDelete),
+		},
+	}
+}
+
+func (s *State) builtinGet(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		key          string
+		defaultValue starlark.Value = starlark.None
+	)
+	err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 1, &key, &defaultValue)
+	if err != nil {
+		return nil, err
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	value, loaded := s.values[key]
+	if !loaded {
+		return defaultValue, nil
+	}
+	return value.(starlark.Value), nil
+}
+
+func (s *State) builtinSet(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		key   string
+		value starlark.Value
+	)
+	err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 2, &key, &value)
+	if err != nil {
+		return nil, err
+	}
+	switch value.(type) {
+	case starlark.NoneType, starlark.Bool, starlark.Int, starlark.Float, starlark.String:
+	default:
+		return nil, E.New(builtin.Name(), ": unsupported value type: ", value.Type())
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.values[key] = value
+	return starlark.None, nil
+}
+
+func (s *State) builtinIncr(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		key   string
+		delta = starlark.MakeInt(1)
+	)
+	err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 1, &key, &delta)
+	if err != nil {
+		return nil, err
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	current := starlark.MakeInt(0)
+	if value, loaded := s.values[key]; loaded {
+		intValue, isInt := value.(starlark.Int)
+		if !isInt {
+			return nil, E.New(builtin.Name(), ": value of ", key, " is not an int")
+		}
+		current = intValue
+	}
+	current = current.Add(delta)
+	s.values[key] = current
+	return current, nil
+}
+
+func (s *State) builtinDelete(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 1, &key)
+	if err != nil {
+		return nil, err
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	delete(s.values, key)
+	return starlark.None, nil
+}
+
+func scriptError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) && len(evalErr.CallStack) > 0 {
+		return E.New(evalErr.CallStack.At(0).Pos, ": ", evalErr.Msg)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+//go:build with_script
+
+package script
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScript(t *testing.T) {
+	t.Parallel()
+	script, err := New(Options{
+		Logger: logger.NOP(),
+		Name:   "test.star",
+		Content: `
+def is_ads(metadata):
+    return metadata.domain.endswith(".ads.example")
+
+def route(metadata):
+    if metadata.destination_port == 22:
+        return "ssh"
+    if match_rule_set("cn"):
+        return "direct"
+    return None
+
+def count():
+    return state.incr("count")
+
+def loop():
+    while True:
+        pass
+`,
+		MatchRuleSet: func(tag string, metadata *adapter.InboundContext) (bool, error) {
+			return tag == "cn" && metadata.Destination.Port == 80, nil
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, script.HasFunction("route"))
+	require.False(t, script.HasFunction("main"))
+
+	result, err := script.Call("is_ads", &adapter.InboundContext{Domain: "tracker.ads.example"})
+	require.NoError(t, err)
+	require.Equal(t, true, result)
+
+	result, err = script.Call("route", &adapter.InboundContext{Destination: M.ParseSocksaddr("1.1.1.1:22")})
+	require.NoError(t, err)
+	require.Equal(t, "ssh", result)
+	result, err = script.Call("route", &adapter.InboundContext{Destination: M.ParseSocksaddr("1.1.1.1:80")})
+	require.NoError(t, err)
+	require.Equal(t, "direct", result)
+	result, err = script.Call("route", &adapter.InboundContext{Destination: M.ParseSocksaddr("1.1.1.1:443")})
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	for i := int64(1); i <= 3; i++ {
+		result, err = script.Call("count", &adapter.InboundContext{})
+		require.NoError(t, err)
+		require.Equal(t, i, result)
+	}
+
+	_, err = script.Call("loop", &adapter.InboundContext{})
+	require.Error(t, err)
+	_, err = script.Call("main", &adapter.InboundContext{})
+	require.Error(t, err)
+
+	_, err = New(Options{Logger: logger.NOP(), Name: "invalid.star", Content: "def main(:"})
+	require.Error(t, err)
+}
@@ -28,3 +28,14 @@ func TestSniffHTTP1WithPort(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, metadata.Domain, "www.gov.cn")
 }
+
+func TestSniffHTTP1Request(t *testing.T) {
+	t.Parallel()
+	pkt := "POST /api/v1/update?channel=stable HTTP/1.1\r\nHost: www.google.com\r\nUser-Agent: apt/2.7.14\r\n\r\n"
+	var metadata adapter.InboundContext
+	err := sniff.HTTPHost(context.Background(), &metadata, strings.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, metadata.HTTPMethod, "POST")
+	require.Equal(t, metadata.HTTPPath, "/api/v1/update")
+	require.Equal(t, metadata.HTTPUserAgent, "apt/2.7.14")
+}
@@ -11,9 +11,10 @@ import (
 	"os"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/internal/qtls"
 	"github.com/sagernet/sing-box/common/ja3"
-	"github.com/sagernet/sing-box/common/sniff/internal/qtls"
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/buf"
 	E "github.com/sagernet/sing/common/exceptions"
 
@@ -311,6 +312,8 @@ find:
 		return ErrClientHelloFragmented
 	}
 	metadata.Domain = fingerprint.ServerName
+	metadata.ALPN = fingerprint.ALPN
+	metadata.ECH = common.Contains(fingerprint.Extensions, extensionEncryptedClientHello)
 	for metadata.Client == "" {
 		if len(frameTypeList) == 1 {
 			metadata.Client = C.ClientFirefox
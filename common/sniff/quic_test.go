@@ -26,6 +26,7 @@ func TestSniffQUICChromium(t *testing.T) {
 	err = sniff.QUICClientHello(context.Background(), &metadata, pkt)
 	require.NoError(t, err)
 	require.Equal(t, metadata.Domain, "google.com")
+	require.Equal(t, metadata.ALPN, []string{"h3"})
 }
 
 func TestSniffUQUICChrome115(t *testing.T) {
@@ -1,18 +1,26 @@
 package sniff
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"io"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/ja3"
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/bufio"
 )
 
+const extensionEncryptedClientHello uint16 = 0xfe0d
+
 func TLSClientHello(ctx context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
-	var clientHello *tls.ClientHelloInfo
-	err := tls.Server(bufio.NewReadOnlyConn(reader), &tls.Config{
+	var (
+		clientHello *tls.ClientHelloInfo
+		record      bytes.Buffer
+	)
+	err := tls.Server(bufio.NewReadOnlyConn(io.TeeReader(reader, &record)), &tls.Config{
 		GetConfigForClient: func(argHello *tls.ClientHelloInfo) (*tls.Config, error) {
 			clientHello = argHello
 			return nil, nil
@@ -21,6 +29,12 @@ func TLSClientHello(ctx context.Context, metadata *adapter.InboundContext, reade
 	if clientHello != nil {
 		metadata.Protocol = C.ProtocolTLS
 		metadata.Domain = clientHello.ServerName
+		metadata.ALPN = clientHello.SupportedProtos
+		// ClientHelloInfo does not expose extensions before go1.24
+		fingerprint, fingerprintErr := ja3.Compute(record.Bytes())
+		if fingerprintErr == nil {
+			metadata.ECH = common.Contains(fingerprint.Extensions, extensionEncryptedClientHello)
+		}
 		return nil
 	}
 	return err
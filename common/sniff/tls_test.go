@@ -0,0 +1,31 @@
+package sniff_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffTLS(t *testing.T) {
+	t.Parallel()
+	client, server := net.Pipe()
+	defer client.Close()
+	go tls.Client(client, &tls.Config{
+		ServerName: "www.google.com",
+		NextProtos: []string{"h2", "http/1.1"},
+	}).Handshake()
+	var metadata adapter.InboundContext
+	err := sniff.TLSClientHello(context.Background(), &metadata, server)
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolTLS, metadata.Protocol)
+	require.Equal(t, "www.google.com", metadata.Domain)
+	require.Equal(t, []string{"h2", "http/1.1"}, metadata.ALPN)
+	require.False(t, metadata.ECH)
+}
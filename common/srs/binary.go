@@ -2,11 +2,13 @@ package srs
 
 import (
 	"bufio"
+	"bytes"
 	"compress/zlib"
 	"encoding/binary"
 	"io"
 	"net/netip"
 
+	"github.com/sagernet/sing-box/common/srs/mapped"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
@@ -44,6 +46,48 @@ const (
 	ruleItemFinal uint8 = 0xFF
 )
 
+// ReadFile reads a rule-set from a file opened by mapped.Open.
+// Domain and IP items of version 4 rule-sets are matched directly from the mapping.
+func ReadFile(file *mapped.File, recover bool) (option.PlainRuleSetCompat, error) {
+	return readBytes(file.Bytes(), file, recover)
+}
+
+// ReadBytes reads a rule-set from content, which must not be modified afterwards
+// since version 4 rule-sets reference it.
+func ReadBytes(content []byte, recover bool) (option.PlainRuleSetCompat, error) {
+	return readBytes(content, nil, recover)
+}
+
+func readBytes(content []byte, owner any, recover bool) (ruleSetCompat option.PlainRuleSetCompat, err error) {
+	if len(content) < 4 || [3]byte(content) != MagicBytes {
+		err = E.New("invalid sing-box rule-set file")
+		return
+	}
+	version := content[3]
+	if version < C.RuleSetVersion4 {
+		return Read(bytes.NewReader(content), recover)
+	}
+	if version > C.RuleSetVersion4 {
+		return ruleSetCompat, E.New("unsupported version: ", version)
+	}
+	body := content[4:]
+	reader := &mappedReader{bytes.NewReader(body), body, owner}
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return
+	}
+	ruleSetCompat.Version = version
+	ruleSetCompat.Options.Rules = make([]option.HeadlessRule, length)
+	for i := uint64(0); i < length; i++ {
+		ruleSetCompat.Options.Rules[i], err = readRule(reader, recover)
+		if err != nil {
+			err = E.Cause(err, "read rule[", i, "]")
+			return
+		}
+	}
+	return
+}
+
 func Read(reader io.Reader, recover bool) (ruleSetCompat option.PlainRuleSetCompat, err error) {
 	var magicBytes [3]byte
 	_, err = io.ReadFull(reader, magicBytes[:])
@@ -59,9 +103,16 @@ func Read(reader io.Reader, recover bool) (ruleSetCompat option.PlainRuleSetComp
 	if err != nil {
 		return ruleSetCompat, err
 	}
-	if version > C.RuleSetVersionCurrent {
+	if version > C.RuleSetVersion4 {
 		return ruleSetCompat, E.New("unsupported version: ", version)
 	}
+	if version >= C.RuleSetVersion4 {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return ruleSetCompat, err
+		}
+		return readBytes(append(append(MagicBytes[:], version), content...), nil, recover)
+	}
 	compressReader, err := zlib.NewReader(reader)
 	if err != nil {
 		return
@@ -92,11 +143,16 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet, generateVersion uint8)
 	if err != nil {
 		return err
 	}
-	compressWriter, err := zlib.NewWriterLevel(writer, zlib.BestCompression)
-	if err != nil {
-		return err
+	// version 4 rule-sets are left uncompressed to be matched from a memory-mapped file
+	var compressWriter io.WriteCloser
+	if generateVersion < C.RuleSetVersion4 {
+		compressWriter, err = zlib.NewWriterLevel(writer, zlib.BestCompression)
+		if err != nil {
+			return err
+		}
+		writer = compressWriter
 	}
-	bWriter := bufio.NewWriter(compressWriter)
+	bWriter := bufio.NewWriter(writer)
 	_, err = varbin.WriteUvarint(bWriter, uint64(len(ruleSet.Rules)))
 	if err != nil {
 		return err
@@ -111,7 +167,7 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet, generateVersion uint8)
 	if err != nil {
 		return err
 	}
-	return compressWriter.Close()
+	return common.Close(compressWriter)
 }
 
 func readRule(reader varbin.Reader, recover bool) (rule option.HeadlessRule, err error) {
@@ -165,6 +221,18 @@ func readDefaultRule(reader varbin.Reader, recover bool) (rule option.DefaultHea
 		case ruleItemNetwork:
 			rule.Network, err = readRuleItemString(reader)
 		case ruleItemDomain:
+			if mReader, isMapped := reader.(*mappedReader); isMapped {
+				var set *mapped.DomainSet
+				set, err = mReader.readDomainSet()
+				if err != nil {
+					return
+				}
+				rule.MappedDomainSet = set
+				if recover {
+					rule.Domain, rule.DomainSuffix = set.Dump()
+				}
+				break
+			}
 			var matcher *domain.Matcher
 			matcher, err = domain.ReadMatcher(reader)
 			if err != nil {
@@ -179,6 +247,16 @@ func readDefaultRule(reader varbin.Reader, recover bool) (rule option.DefaultHea
 		case ruleItemDomainRegex:
 			rule.DomainRegex, err = readRuleItemString(reader)
 		case ruleItemSourceIPCIDR:
+			if mReader, isMapped := reader.(*mappedReader); isMapped {
+				rule.MappedSourceIPSet, err = mReader.readIPSet()
+				if err != nil {
+					return
+				}
+				if recover {
+					rule.SourceIPCIDR, err = dumpIPSet(rule.MappedSourceIPSet)
+				}
+				break
+			}
 			rule.SourceIPSet, err = readIPSet(reader)
 			if err != nil {
 				return
@@ -187,6 +265,16 @@ func readDefaultRule(reader varbin.Reader, recover bool) (rule option.DefaultHea
 				rule.SourceIPCIDR = common.Map(rule.SourceIPSet.Prefixes(), netip.Prefix.String)
 			}
 		case ruleItemIPCIDR:
+			if mReader, isMapped := reader.(*mappedReader); isMapped {
+				rule.MappedIPSet, err = mReader.readIPSet()
+				if err != nil {
+					return
+				}
+				if recover {
+					rule.IPCIDR, err = dumpIPSet(rule.MappedIPSet)
+				}
+				break
+			}
 			rule.IPSet, err = readIPSet(reader)
 			if err != nil {
 				return
@@ -268,7 +356,11 @@ func writeDefaultRule(writer varbin.Writer, rule option.DefaultHeadlessRule, gen
 		if err != nil {
 			return err
 		}
-		err = domain.NewMatcher(rule.Domain, rule.DomainSuffix, generateVersion == C.RuleSetVersion1).Write(writer)
+		if generateVersion >= C.RuleSetVersion4 {
+			err = writeSection(writer, mapped.EncodeDomainSet(rule.Domain, rule.DomainSuffix))
+		} else {
+			err = domain.NewMatcher(rule.Domain, rule.DomainSuffix, generateVersion == C.RuleSetVersion1).Write(writer)
+		}
 		if err != nil {
 			return err
 		}
@@ -286,13 +378,13 @@ func writeDefaultRule(writer varbin.Writer, rule option.DefaultHeadlessRule, gen
 		}
 	}
 	if len(rule.SourceIPCIDR) > 0 {
-		err = writeRuleItemCIDR(writer, ruleItemSourceIPCIDR, rule.SourceIPCIDR)
+		err = writeRuleItemCIDR(writer, ruleItemSourceIPCIDR, rule.SourceIPCIDR, generateVersion)
 		if err != nil {
 			return E.Cause(err, "source_ip_cidr")
 		}
 	}
 	if len(rule.IPCIDR) > 0 {
-		err = writeRuleItemCIDR(writer, ruleItemIPCIDR, rule.IPCIDR)
+		err = writeRuleItemCIDR(writer, ruleItemIPCIDR, rule.IPCIDR, generateVersion)
 		if err != nil {
 			return E.Cause(err, "ipcidr")
 		}
@@ -438,7 +530,7 @@ func writeRuleItemUint16(writer varbin.Writer, itemType uint8, value []uint16) e
 	return varbin.Write(writer, binary.BigEndian, value)
 }
 
-func writeRuleItemCIDR(writer varbin.Writer, itemType uint8, value []string) error {
+func writeRuleItemCIDR(writer varbin.Writer, itemType uint8, value []string, generateVersion uint8) error {
 	var builder netipx.IPSetBuilder
 	for i, prefixString := range value {
 		prefix, err := netip.ParsePrefix(prefixString)
@@ -461,6 +553,9 @@ func writeRuleItemCIDR(writer varbin.Writer, itemType uint8, value []string) err
 	if err != nil {
 		return err
 	}
+	if generateVersion >= C.RuleSetVersion4 {
+		return writeSection(writer, mapped.EncodeIPSet(ipSet))
+	}
 	return writeIPSet(writer, ipSet)
 }
 
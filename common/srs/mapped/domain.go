@@ -0,0 +1,383 @@
+package mapped
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+	"unicode/utf8"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// DomainSet is a succinct trie of reversed domains, laid out so that it can be matched
+// without decoding. The layout follows the domain matcher of sing, mod from https://github.com/openacid/succinct.
+//
+// Encoding (little endian):
+//
+//	uint32 leaves words, uint32 label bitmap words, uint32 ranks, uint32 selects, uint32 labels
+//	[]uint64 leaves, []uint64 label bitmap, []int32 ranks, []int32 selects, []byte labels
+type DomainSet struct {
+	owner       any
+	leaves      []byte
+	labelBitmap []byte
+	ranks       []byte
+	selects     []byte
+	labels      []byte
+}
+
+const (
+	prefixLabel = '\r'
+	rootLabel   = '\n'
+
+	domainSetHeaderLength = 5 * 4
+)
+
+// EncodeDomainSet builds a domain set matching domains exactly and domain suffixes,
+// where a suffix with a leading dot only matches subdomains.
+func EncodeDomainSet(domains []string, domainSuffix []string) []byte {
+	keys := make([]string, 0, len(domains)+len(domainSuffix))
+	for _, domain := range domainSuffix {
+		if domain == "" {
+			continue
+		}
+		if domain[0] == '.' {
+			keys = append(keys, reverseDomain(string(prefixLabel)+domain))
+		} else {
+			keys = append(keys, reverseDomain(string(rootLabel)+domain))
+		}
+	}
+	for _, domain := range domains {
+		if domain == "" {
+			continue
+		}
+		keys = append(keys, reverseDomain(domain))
+	}
+	sort.Strings(keys)
+	keys = uniqSorted(keys)
+	var leaves, labelBitmap []uint64
+	var labels []byte
+	if len(keys) > 0 {
+		leaves, labelBitmap, labels = buildTrie(keys)
+	}
+	selects, ranks := indexSelect32R64(labelBitmap)
+	data := make([]byte, domainSetHeaderLength, domainSetHeaderLength+8*(len(leaves)+len(labelBitmap))+4*(len(ranks)+len(selects))+len(labels))
+	binary.LittleEndian.PutUint32(data[0:], uint32(len(leaves)))
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(labelBitmap)))
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(ranks)))
+	binary.LittleEndian.PutUint32(data[12:], uint32(len(selects)))
+	binary.LittleEndian.PutUint32(data[16:], uint32(len(labels)))
+	for _, word := range leaves {
+		data = binary.LittleEndian.AppendUint64(data, word)
+	}
+	for _, word := range labelBitmap {
+		data = binary.LittleEndian.AppendUint64(data, word)
+	}
+	for _, rank := range ranks {
+		data = binary.LittleEndian.AppendUint32(data, uint32(rank))
+	}
+	for _, selectIndex := range selects {
+		data = binary.LittleEndian.AppendUint32(data, uint32(selectIndex))
+	}
+	return append(data, labels...)
+}
+
+// NewDomainSet validates data and references it without copying.
+// owner is kept alive as long as the set, for data backed by a mapping.
+func NewDomainSet(data []byte, owner any) (*DomainSet, error) {
+	if len(data) < domainSetHeaderLength {
+		return nil, E.New("domain set: short header")
+	}
+	var lengths [5]int
+	total := domainSetHeaderLength
+	for i := range lengths {
+		lengths[i] = int(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	sizes := [5]int{8 * lengths[0], 8 * lengths[1], 4 * lengths[2], 4 * lengths[3], lengths[4]}
+	for _, size := range sizes {
+		total += size
+	}
+	if total != len(data) {
+		return nil, E.New("domain set: size mismatch")
+	}
+	if lengths[0] == 0 {
+		return &DomainSet{owner: owner}, nil
+	}
+	set := &DomainSet{owner: owner}
+	offset := domainSetHeaderLength
+	fields := [5]*[]byte{&set.leaves, &set.labelBitmap, &set.ranks, &set.selects, &set.labels}
+	for i, field := range fields {
+		*field = data[offset : offset+sizes[i] : offset+sizes[i]]
+		offset += sizes[i]
+	}
+	err := set.validate()
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// validate checks the whole trie structure, since matching indexes the sections without bounds checks.
+// In the label bitmap, every node is a run of zeros, one per child label, terminated by a one,
+// and nodes are numbered in breadth-first order, so a child always comes after its parent.
+func (s *DomainSet) validate() error {
+	labelCount := len(s.labels)
+	nodeCount := labelCount + 1
+	bitCount := labelCount + nodeCount
+	bitmapWords := len(s.labelBitmap) / 8
+	if bitmapWords != (bitCount+63)>>6 || len(s.ranks)/4 != bitmapWords+1 || len(s.selects)/4 != (nodeCount+31)>>5 {
+		return E.New("domain set: bad index size")
+	}
+	leafCount := len(s.leaves) * 8
+	if leafCount < nodeCount {
+		return E.New("domain set: bad leaves size")
+	}
+	for i := nodeCount; i < leafCount; i++ {
+		if getBit(s.leaves, i) != 0 {
+			return E.New("domain set: bad leaves padding")
+		}
+	}
+	if getBit(s.leaves, 0) != 0 {
+		return E.New("domain set: empty key")
+	}
+	var nodeId, childId int
+	for i := 0; i < bitmapWords<<6; i++ {
+		if i&63 == 0 && int(word32(s.ranks, i>>6)) != nodeId {
+			return E.New("domain set: bad rank")
+		}
+		if getBit(s.labelBitmap, i) == 0 {
+			if i >= bitCount {
+				continue
+			}
+			childId++
+			if childId <= nodeId {
+				return E.New("domain set: bad node order")
+			}
+			continue
+		}
+		if i >= bitCount {
+			return E.New("domain set: bad label bitmap padding")
+		}
+		if nodeId&31 == 0 && int(word32(s.selects, nodeId>>5)) != i {
+			return E.New("domain set: bad select")
+		}
+		nodeId++
+	}
+	if nodeId != nodeCount || int(word32(s.ranks, bitmapWords)) != nodeCount {
+		return E.New("domain set: bad node count")
+	}
+	return nil
+}
+
+func (s *DomainSet) Match(domain string) bool {
+	if len(s.leaves) == 0 {
+		return false
+	}
+	return s.has(reverseDomain(domain))
+}
+
+// Len returns the number of keys in the set.
+func (s *DomainSet) Len() int {
+	var count int
+	for i := 0; i < len(s.leaves)/8; i++ {
+		count += bits.OnesCount64(word64(s.leaves, i))
+	}
+	return count
+}
+
+// Dump returns the domains and domain suffixes in the set.
+func (s *DomainSet) Dump() (domainList []string, suffixList []string) {
+	if len(s.leaves) == 0 {
+		return
+	}
+	for _, key := range s.keys() {
+		key = reverseDomain(key)
+		switch key[0] {
+		case prefixLabel, rootLabel:
+			suffixList = append(suffixList, key[1:])
+		default:
+			domainList = append(domainList, key)
+		}
+	}
+	sort.Strings(domainList)
+	sort.Strings(suffixList)
+	return
+}
+
+func (s *DomainSet) has(key string) bool {
+	var nodeId, bmIdx int
+	for i := 0; i < len(key); i++ {
+		currentChar := key[i]
+		for ; ; bmIdx++ {
+			if getBit(s.labelBitmap, bmIdx) != 0 {
+				return false
+			}
+			nextLabel := s.labels[bmIdx-nodeId]
+			if nextLabel == prefixLabel {
+				return true
+			}
+			if nextLabel == rootLabel {
+				nextNodeId := s.countZeros(bmIdx + 1)
+				hasNext := getBit(s.leaves, nextNodeId) != 0
+				if currentChar == '.' && hasNext {
+					return true
+				}
+			}
+			if nextLabel == currentChar {
+				break
+			}
+		}
+		nodeId = s.countZeros(bmIdx + 1)
+		bmIdx = s.selectIthOne(nodeId-1) + 1
+	}
+	if getBit(s.leaves, nodeId) != 0 {
+		return true
+	}
+	for ; ; bmIdx++ {
+		if getBit(s.labelBitmap, bmIdx) != 0 {
+			return false
+		}
+		nextLabel := s.labels[bmIdx-nodeId]
+		if nextLabel == prefixLabel || nextLabel == rootLabel {
+			return true
+		}
+	}
+}
+
+func (s *DomainSet) keys() []string {
+	var result []string
+	var currentKey []byte
+	var traverse func(int, int)
+	traverse = func(nodeId, bmIdx int) {
+		if getBit(s.leaves, nodeId) != 0 {
+			result = append(result, string(currentKey))
+		}
+		for ; ; bmIdx++ {
+			if getBit(s.labelBitmap, bmIdx) != 0 {
+				return
+			}
+			currentKey = append(currentKey, s.labels[bmIdx-nodeId])
+			nextNodeId := s.countZeros(bmIdx + 1)
+			traverse(nextNodeId, s.selectIthOne(nextNodeId-1)+1)
+			currentKey = currentKey[:len(currentKey)-1]
+		}
+	}
+	traverse(0, 0)
+	return result
+}
+
+func (s *DomainSet) countZeros(i int) int {
+	wordI := i >> 6
+	return i - int(word32(s.ranks, wordI)) - bits.OnesCount64(word64(s.labelBitmap, wordI)&(1<<uint(i&63)-1))
+}
+
+func (s *DomainSet) selectIthOne(i int) int {
+	wordI := int(word32(s.selects, i>>5)) >> 6
+	for ; int(word32(s.ranks, wordI+1)) <= i; wordI++ {
+	}
+	w := word64(s.labelBitmap, wordI)
+	findIth := i - int(word32(s.ranks, wordI))
+	for ; findIth > 0; findIth-- {
+		w &= w - 1
+	}
+	return wordI<<6 + bits.TrailingZeros64(w)
+}
+
+func buildTrie(keys []string) (leaves, labelBitmap []uint64, labels []byte) {
+	lIdx := 0
+	type qElt struct{ s, e, col int }
+	queue := []qElt{{0, len(keys), 0}}
+	for i := 0; i < len(queue); i++ {
+		elt := queue[i]
+		if elt.col == len(keys[elt.s]) {
+			// a leaf node
+			elt.s++
+			setBit(&leaves, i)
+		}
+		for j := elt.s; j < elt.e; {
+			frm := j
+			for ; j < elt.e && keys[j][elt.col] == keys[frm][elt.col]; j++ {
+			}
+			queue = append(queue, qElt{frm, j, elt.col + 1})
+			labels = append(labels, keys[frm][elt.col])
+			growBitmap(&labelBitmap, lIdx)
+			lIdx++
+		}
+		setBit(&labelBitmap, lIdx)
+		lIdx++
+	}
+	// leaves must cover every node id reachable by the label bitmap
+	growBitmap(&leaves, len(queue))
+	return
+}
+
+func indexSelect32R64(words []uint64) (selects []int32, ranks []int32) {
+	ith := -1
+	for i := 0; i < len(words)<<6; i++ {
+		if words[i>>6]&(1<<uint(i&63)) != 0 {
+			ith++
+			if ith&31 == 0 {
+				selects = append(selects, int32(i))
+			}
+		}
+	}
+	ranks = make([]int32, len(words)+1)
+	var n int32
+	for i, word := range words {
+		ranks[i] = n
+		n += int32(bits.OnesCount64(word))
+	}
+	ranks[len(words)] = n
+	return
+}
+
+func setBit(bm *[]uint64, i int) {
+	growBitmap(bm, i)
+	(*bm)[i>>6] |= 1 << uint(i&63)
+}
+
+func growBitmap(bm *[]uint64, i int) {
+	for i>>6 >= len(*bm) {
+		*bm = append(*bm, 0)
+	}
+}
+
+func getBit(bm []byte, i int) uint64 {
+	return word64(bm, i>>6) & (1 << uint(i&63))
+}
+
+func word64(data []byte, i int) uint64 {
+	return binary.LittleEndian.Uint64(data[i<<3:])
+}
+
+func word32(data []byte, i int) int32 {
+	return int32(binary.LittleEndian.Uint32(data[i<<2:]))
+}
+
+func uniqSorted(keys []string) []string {
+	if len(keys) == 0 {
+		return keys
+	}
+	result := keys[:1]
+	for _, key := range keys[1:] {
+		if key != result[len(result)-1] {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+func reverseDomain(domain string) string {
+	l := len(domain)
+	b := make([]byte, l)
+	for i := 0; i < l; {
+		r, n := utf8.DecodeRuneInString(domain[i:])
+		i += n
+		if r == utf8.RuneError && n == 1 {
+			// invalid bytes are kept as is, the replacement rune would not fit
+			b[l-i] = domain[i-1]
+		} else {
+			utf8.EncodeRune(b[l-i:], r)
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,11 @@
+package mapped
+
+// File is a read-only view of a rule-set file, memory-mapped where supported.
+// The mapping is released when the file and every set referencing it are unreachable.
+type File struct {
+	data []byte
+}
+
+func (f *File) Bytes() []byte {
+	return f.data
+}
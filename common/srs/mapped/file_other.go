@@ -0,0 +1,16 @@
+//go:build !unix
+
+package mapped
+
+import (
+	"context"
+	"os"
+)
+
+func Open(ctx context.Context, path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{data}, nil
+}
@@ -0,0 +1,52 @@
+//go:build unix
+
+package mapped
+
+import (
+	"context"
+	"io"
+	"os"
+	"runtime"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"golang.org/x/sys/unix"
+)
+
+// Open maps a private copy of the file created in the temporary directory and unlinked at once,
+// since a mapping of the file itself faults when it is truncated, e.g. rewritten in place.
+// The file is read into memory if the copy cannot be created.
+func Open(ctx context.Context, path string) (*File, error) {
+	sourceFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Close()
+	copyFile, err := filemanager.CreateTemp(ctx, "rule-set-*.srs")
+	if err != nil {
+		data, readErr := io.ReadAll(sourceFile)
+		if readErr != nil {
+			return nil, readErr
+		}
+		return &File{data}, nil
+	}
+	defer copyFile.Close()
+	os.Remove(copyFile.Name())
+	size, err := io.Copy(copyFile, sourceFile)
+	if err != nil {
+		return nil, E.Cause(err, "copy rule-set file")
+	}
+	if size == 0 {
+		return &File{}, nil
+	}
+	data, err := unix.Mmap(int(copyFile.Fd()), 0, int(size), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: path, Err: err}
+	}
+	file := &File{data}
+	runtime.SetFinalizer(file, func(file *File) {
+		_ = unix.Munmap(file.data)
+	})
+	return file, nil
+}
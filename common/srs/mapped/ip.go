@@ -0,0 +1,121 @@
+package mapped
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"sort"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"go4.org/netipx"
+)
+
+// IPSet is a sorted list of disjoint address ranges, matched by binary search.
+//
+// Encoding (little endian counts, big endian addresses):
+//
+//	uint32 IPv4 ranges, uint32 IPv6 ranges
+//	[][2][4]byte IPv4 ranges, [][2][16]byte IPv6 ranges
+type IPSet struct {
+	owner any
+	ipv4  []byte
+	ipv6  []byte
+}
+
+const ipSetHeaderLength = 2 * 4
+
+func EncodeIPSet(set *netipx.IPSet) []byte {
+	var ipv4, ipv6 []netipx.IPRange
+	for _, ipRange := range set.Ranges() {
+		if ipRange.From().Is4() {
+			ipv4 = append(ipv4, ipRange)
+		} else {
+			ipv6 = append(ipv6, ipRange)
+		}
+	}
+	data := make([]byte, ipSetHeaderLength, ipSetHeaderLength+8*len(ipv4)+32*len(ipv6))
+	binary.LittleEndian.PutUint32(data[0:], uint32(len(ipv4)))
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(ipv6)))
+	for _, ipRange := range ipv4 {
+		data = append(data, ipRange.From().AsSlice()...)
+		data = append(data, ipRange.To().AsSlice()...)
+	}
+	for _, ipRange := range ipv6 {
+		data = append(data, ipRange.From().AsSlice()...)
+		data = append(data, ipRange.To().AsSlice()...)
+	}
+	return data
+}
+
+// NewIPSet validates data and references it without copying.
+// owner is kept alive as long as the set, for data backed by a mapping.
+func NewIPSet(data []byte, owner any) (*IPSet, error) {
+	if len(data) < ipSetHeaderLength {
+		return nil, E.New("ip set: short header")
+	}
+	ipv4Length := 8 * int(binary.LittleEndian.Uint32(data[0:]))
+	ipv6Length := 32 * int(binary.LittleEndian.Uint32(data[4:]))
+	if ipSetHeaderLength+ipv4Length+ipv6Length != len(data) {
+		return nil, E.New("ip set: size mismatch")
+	}
+	ipv6Offset := ipSetHeaderLength + ipv4Length
+	return &IPSet{
+		owner: owner,
+		ipv4:  data[ipSetHeaderLength:ipv6Offset:ipv6Offset],
+		ipv6:  data[ipv6Offset:],
+	}, nil
+}
+
+func (s *IPSet) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	var (
+		ranges  []byte
+		address []byte
+	)
+	if addr.Is4() {
+		ranges = s.ipv4
+		address4 := addr.As4()
+		address = address4[:]
+	} else if addr.Is6() {
+		ranges = s.ipv6
+		address16 := addr.As16()
+		address = address16[:]
+	} else {
+		return false
+	}
+	addressLen := len(address)
+	rangeLen := 2 * addressLen
+	count := len(ranges) / rangeLen
+	// first range ending at or after the address
+	index := sort.Search(count, func(i int) bool {
+		to := ranges[i*rangeLen+addressLen : (i+1)*rangeLen]
+		return bytes.Compare(to, address) >= 0
+	})
+	if index == count {
+		return false
+	}
+	from := ranges[index*rangeLen : index*rangeLen+addressLen]
+	return bytes.Compare(from, address) <= 0
+}
+
+// Len returns the number of address ranges in the set.
+func (s *IPSet) Len() int {
+	return len(s.ipv4)/8 + len(s.ipv6)/32
+}
+
+// IPSet decodes the set into a netipx.IPSet.
+func (s *IPSet) IPSet() (*netipx.IPSet, error) {
+	var builder netipx.IPSetBuilder
+	for _, family := range []struct {
+		ranges     []byte
+		addressLen int
+	}{{s.ipv4, 4}, {s.ipv6, 16}} {
+		for i := 0; i+2*family.addressLen <= len(family.ranges); i += 2 * family.addressLen {
+			from, _ := netip.AddrFromSlice(family.ranges[i : i+family.addressLen])
+			to, _ := netip.AddrFromSlice(family.ranges[i+family.addressLen : i+2*family.addressLen])
+			builder.AddRange(netipx.IPRangeFrom(from, to))
+		}
+	}
+	return builder.IPSet()
+}
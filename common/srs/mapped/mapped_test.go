@@ -0,0 +1,153 @@
+package mapped_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/srs/mapped"
+	"github.com/sagernet/sing/common/domain"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"github.com/stretchr/testify/require"
+	"go4.org/netipx"
+)
+
+func TestDomainSet(t *testing.T) {
+	t.Parallel()
+	domains := []string{"example.com", "example.org", "sagernet.org", "例子.测试"}
+	domainSuffix := []string{".example.net", "example.edu", "org", ".cn", "example.edu"}
+	set, err := mapped.NewDomainSet(mapped.EncodeDomainSet(domains, domainSuffix), nil)
+	require.NoError(t, err)
+	matcher := domain.NewMatcher(domains, domainSuffix, false)
+	for _, testDomain := range []string{
+		"example.com", "www.example.com", "com", "example.org", "a.example.org",
+		"example.net", "www.example.net", "example.edu", "www.example.edu", "xexample.edu",
+		"cn", "example.cn", "sagernet.org", "例子.测试", "www.例子.测试", "测试", "",
+	} {
+		require.Equal(t, matcher.Match(testDomain), set.Match(testDomain), testDomain)
+	}
+	require.Equal(t, 8, set.Len())
+	dumpDomain, dumpSuffix := set.Dump()
+	require.Equal(t, []string{"example.com", "example.org", "sagernet.org", "例子.测试"}, dumpDomain)
+	require.Equal(t, []string{".cn", ".example.net", "example.edu", "org"}, dumpSuffix)
+	emptySet, err := mapped.NewDomainSet(mapped.EncodeDomainSet(nil, nil), nil)
+	require.NoError(t, err)
+	require.False(t, emptySet.Match("example.com"))
+	_, err = mapped.NewDomainSet([]byte{1, 2, 3}, nil)
+	require.Error(t, err)
+
+	domains = domains[:0]
+	domainSuffix = domainSuffix[:0]
+	for i := 0; i < 500; i++ {
+		domains = append(domains, "host"+strconv.Itoa(i)+".example.com")
+		domainSuffix = append(domainSuffix, ".zone"+strconv.Itoa(i*7)+".org")
+	}
+	set, err = mapped.NewDomainSet(mapped.EncodeDomainSet(domains, domainSuffix), nil)
+	require.NoError(t, err)
+	matcher = domain.NewMatcher(domains, domainSuffix, false)
+	for i := 0; i < 1000; i++ {
+		for _, testDomain := range []string{"host" + strconv.Itoa(i) + ".example.com", "www.zone" + strconv.Itoa(i) + ".org"} {
+			require.Equal(t, matcher.Match(testDomain), set.Match(testDomain), testDomain)
+		}
+	}
+	require.Equal(t, 1000, set.Len())
+}
+
+func TestIPSet(t *testing.T) {
+	t.Parallel()
+	var builder netipx.IPSetBuilder
+	for _, prefix := range []string{"1.1.1.0/24", "10.0.0.0/8", "2001:db8::/32", "fd00::1/128"} {
+		builder.AddPrefix(netip.MustParsePrefix(prefix))
+	}
+	ipSet, err := builder.IPSet()
+	require.NoError(t, err)
+	set, err := mapped.NewIPSet(mapped.EncodeIPSet(ipSet), nil)
+	require.NoError(t, err)
+	for _, testAddr := range []string{
+		"1.1.1.1", "1.1.2.1", "10.255.255.255", "11.0.0.0", "0.0.0.0", "::ffff:10.0.0.1",
+		"2001:db8::1", "2001:db9::", "fd00::1", "fd00::2", "::",
+	} {
+		addr := netip.MustParseAddr(testAddr)
+		require.Equal(t, ipSet.Contains(addr.Unmap()), set.Contains(addr), testAddr)
+	}
+	require.Equal(t, 4, set.Len())
+	decoded, err := set.IPSet()
+	require.NoError(t, err)
+	require.Equal(t, ipSet.Prefixes(), decoded.Prefixes())
+}
+
+func TestFileRewrite(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "domain.bin")
+	require.NoError(t, os.WriteFile(path, mapped.EncodeDomainSet([]string{"example.com"}, []string{".example.org"}), 0o644))
+	tempDir := t.TempDir()
+	file, err := mapped.Open(filemanager.WithDefault(context.Background(), "", tempDir, os.Getuid(), os.Getgid()), path)
+	require.NoError(t, err)
+	// the private copy is unlinked once mapped
+	tempFiles, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	require.Empty(t, tempFiles)
+	set, err := mapped.NewDomainSet(file.Bytes(), file)
+	require.NoError(t, err)
+	// rewrite the file in place, like os.Create does
+	rewriteFile, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, rewriteFile.Close())
+	require.True(t, set.Match("example.com"))
+	require.True(t, set.Match("www.example.org"))
+	require.False(t, set.Match("example.net"))
+}
+
+func testDomainSetData() []byte {
+	return mapped.EncodeDomainSet(
+		[]string{"example.com", "example.org", "sagernet.org", "例子.测试"},
+		[]string{".example.net", "example.edu", "org", ".cn"},
+	)
+}
+
+func exerciseDomainSet(set *mapped.DomainSet) {
+	for _, testDomain := range []string{"example.com", "www.example.net", "example.edu", "a.b.org", "cn", "例子.测试", "\xffexample.com", "\xe4\xbe", ""} {
+		set.Match(testDomain)
+	}
+	set.Len()
+	set.Dump()
+}
+
+func TestDomainSetCorrupted(t *testing.T) {
+	t.Parallel()
+	data := testDomainSetData()
+	corrupted := make([]byte, len(data))
+	for i := range data {
+		for _, value := range []byte{0x00, 0x01, 0x7F, 0x80, 0xFF, data[i] ^ 0x01, data[i] ^ 0x80} {
+			if value == data[i] {
+				continue
+			}
+			copy(corrupted, data)
+			corrupted[i] = value
+			set, err := mapped.NewDomainSet(corrupted, nil)
+			if err != nil {
+				continue
+			}
+			require.NotPanics(t, func() {
+				exerciseDomainSet(set)
+			}, "byte %d = %#x", i, value)
+		}
+	}
+}
+
+func FuzzDomainSet(f *testing.F) {
+	f.Add(testDomainSetData())
+	f.Add(mapped.EncodeDomainSet([]string{"a"}, nil))
+	f.Add(mapped.EncodeDomainSet(nil, nil))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		set, err := mapped.NewDomainSet(data, nil)
+		if err != nil {
+			return
+		}
+		exerciseDomainSet(set)
+	})
+}
@@ -0,0 +1,71 @@
+package srs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/netip"
+
+	"github.com/sagernet/sing-box/common/srs/mapped"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/varbin"
+)
+
+// mappedReader reads version 4 rule-sets, whose domain and IP items are
+// length-prefixed sections referenced in place instead of being decoded.
+type mappedReader struct {
+	*bytes.Reader
+	content []byte
+	owner   any
+}
+
+func (r *mappedReader) readSection() ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	offset := len(r.content) - r.Len()
+	if length > uint64(r.Len()) {
+		return nil, E.New("section out of range")
+	}
+	_, err = r.Seek(int64(length), io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end := offset + int(length)
+	return r.content[offset:end:end], nil
+}
+
+func (r *mappedReader) readDomainSet() (*mapped.DomainSet, error) {
+	section, err := r.readSection()
+	if err != nil {
+		return nil, err
+	}
+	return mapped.NewDomainSet(section, r.owner)
+}
+
+func (r *mappedReader) readIPSet() (*mapped.IPSet, error) {
+	section, err := r.readSection()
+	if err != nil {
+		return nil, err
+	}
+	return mapped.NewIPSet(section, r.owner)
+}
+
+func writeSection(writer varbin.Writer, section []byte) error {
+	_, err := varbin.WriteUvarint(writer, uint64(len(section)))
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(section)
+	return err
+}
+
+func dumpIPSet(set *mapped.IPSet) ([]string, error) {
+	ipSet, err := set.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	return common.Map(ipSet.Prefixes(), netip.Prefix.String), nil
+}
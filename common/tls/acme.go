@@ -90,6 +90,12 @@ func startACME(ctx context.Context, options option.InboundACMEOptions) (*tls.Con
 			solver.DNSProvider = &cloudflare.Provider{
 				APIToken: dnsOptions.CloudflareOptions.APIToken,
 			}
+		case C.DNSProviderRFC2136:
+			provider, err := newRFC2136Provider(dnsOptions.RFC2136Options)
+			if err != nil {
+				return nil, nil, err
+			}
+			solver.DNSProvider = provider
 		default:
 			return nil, nil, E.New("unsupported ACME DNS01 provider type: " + dnsOptions.Provider)
 		}
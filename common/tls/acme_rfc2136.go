@@ -0,0 +1,105 @@
+//go:build with_acme
+
+package tls
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/libdns/libdns"
+	mDNS "github.com/miekg/dns"
+)
+
+var _ interface {
+	libdns.RecordAppender
+	libdns.RecordDeleter
+} = (*rfc2136Provider)(nil)
+
+type rfc2136Provider struct {
+	server       string
+	keyName      string
+	keyAlgorithm string
+	key          string
+}
+
+func newRFC2136Provider(options option.ACMEDNS01RFC2136Options) (*rfc2136Provider, error) {
+	if options.Server == "" {
+		return nil, E.New("missing RFC2136 server")
+	}
+	if options.KeyName == "" || options.Key == "" {
+		return nil, E.New("missing RFC2136 TSIG key")
+	}
+	serverAddr := M.ParseSocksaddr(options.Server)
+	if serverAddr.Port == 0 {
+		serverAddr.Port = 53
+	}
+	keyAlgorithm := options.KeyAlgorithm
+	if keyAlgorithm == "" {
+		keyAlgorithm = "hmac-sha256"
+	}
+	return &rfc2136Provider{
+		server:       serverAddr.String(),
+		keyName:      mDNS.Fqdn(options.KeyName),
+		keyAlgorithm: mDNS.Fqdn(keyAlgorithm),
+		key:          options.Key,
+	}, nil
+}
+
+func (p *rfc2136Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return records, p.update(ctx, zone, records, false)
+}
+
+func (p *rfc2136Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return records, p.update(ctx, zone, records, true)
+}
+
+func (p *rfc2136Provider) update(ctx context.Context, zone string, records []libdns.Record, remove bool) error {
+	zone = mDNS.Fqdn(zone)
+	rrList := make([]mDNS.RR, 0, len(records))
+	for _, record := range records {
+		rr, err := rfc2136RecordToRR(record, zone)
+		if err != nil {
+			return E.Cause(err, "invalid record ", record.Name)
+		}
+		rrList = append(rrList, rr)
+	}
+	var message mDNS.Msg
+	message.SetUpdate(zone)
+	if remove {
+		message.Remove(rrList)
+	} else {
+		message.Insert(rrList)
+	}
+	message.SetTsig(p.keyName, p.keyAlgorithm, 300, time.Now().Unix())
+	client := &mDNS.Client{
+		Net:        "tcp",
+		TsigSecret: map[string]string{p.keyName: p.key},
+	}
+	response, _, err := client.ExchangeContext(ctx, &message, p.server)
+	if err != nil {
+		return E.Cause(err, "RFC2136 update ", zone)
+	}
+	// the server reports rejected updates only in the response code
+	if response.Rcode != mDNS.RcodeSuccess {
+		return E.New("RFC2136 update ", zone, ": ", mDNS.RcodeToString[response.Rcode])
+	}
+	return nil
+}
+
+func rfc2136RecordToRR(record libdns.Record, zone string) (mDNS.RR, error) {
+	header := mDNS.RR_Header{
+		Name:   libdns.AbsoluteName(record.Name, zone),
+		Class:  mDNS.ClassINET,
+		Ttl:    uint32(record.TTL / time.Second),
+		Rrtype: mDNS.StringToType[record.Type],
+	}
+	if header.Rrtype == mDNS.TypeTXT {
+		return &mDNS.TXT{Hdr: header, Txt: []string{record.Value}}, nil
+	}
+	return mDNS.NewRR(header.Name + " " + strconv.FormatUint(uint64(header.Ttl), 10) + " IN " + record.Type + " " + record.Value)
+}
@@ -0,0 +1,151 @@
+//go:build with_acme
+
+package tls
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/libdns/libdns"
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rfc2136TestKeyName = "acme."
+	rfc2136TestKey     = "c2luZy1ib3gtcmZjMjEzNi10ZXN0LWtleQ=="
+)
+
+type rfc2136TestServer struct {
+	access   sync.Mutex
+	rcode    int
+	messages []*mDNS.Msg
+	tsigErr  []error
+}
+
+func (s *rfc2136TestServer) ServeDNS(writer mDNS.ResponseWriter, request *mDNS.Msg) {
+	s.access.Lock()
+	s.messages = append(s.messages, request.Copy())
+	s.tsigErr = append(s.tsigErr, writer.TsigStatus())
+	rcode := s.rcode
+	s.access.Unlock()
+	response := new(mDNS.Msg)
+	response.SetRcode(request, rcode)
+	if tsig := request.IsTsig(); tsig != nil {
+		response.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	}
+	writer.WriteMsg(response)
+}
+
+func startRFC2136TestServer(t *testing.T) (*rfc2136TestServer, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	handler := &rfc2136TestServer{}
+	started := make(chan struct{})
+	server := &mDNS.Server{
+		Listener:          listener,
+		Handler:           handler,
+		TsigSecret:        map[string]string{rfc2136TestKeyName: rfc2136TestKey},
+		NotifyStartedFunc: func() { close(started) },
+		MsgAcceptFunc: func(mDNS.Header) mDNS.MsgAcceptAction {
+			return mDNS.MsgAccept
+		},
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() {
+		server.Shutdown()
+	})
+	return handler, listener.Addr().String()
+}
+
+func TestRFC2136ProviderDefaults(t *testing.T) {
+	t.Parallel()
+	provider, err := newRFC2136Provider(option.ACMEDNS01RFC2136Options{
+		Server:  "127.0.0.1",
+		KeyName: "acme",
+		Key:     rfc2136TestKey,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:53", provider.server)
+	require.Equal(t, mDNS.HmacSHA256, provider.keyAlgorithm)
+	require.Equal(t, rfc2136TestKeyName, provider.keyName)
+	_, err = newRFC2136Provider(option.ACMEDNS01RFC2136Options{Server: "127.0.0.1"})
+	require.Error(t, err)
+	_, err = newRFC2136Provider(option.ACMEDNS01RFC2136Options{KeyName: "acme", Key: rfc2136TestKey})
+	require.Error(t, err)
+}
+
+func TestRFC2136ProviderUpdate(t *testing.T) {
+	t.Parallel()
+	server, serverAddr := startRFC2136TestServer(t)
+	provider, err := newRFC2136Provider(option.ACMEDNS01RFC2136Options{
+		Server:  serverAddr,
+		KeyName: "acme",
+		Key:     rfc2136TestKey,
+	})
+	require.NoError(t, err)
+	record := libdns.Record{
+		Type:  "TXT",
+		Name:  "_acme-challenge.www",
+		Value: "dGVzdC1rZXktYXV0aG9yaXphdGlvbg",
+		TTL:   2 * time.Minute,
+	}
+	ctx := context.Background()
+	_, err = provider.AppendRecords(ctx, "example.org", []libdns.Record{record})
+	require.NoError(t, err)
+	_, err = provider.DeleteRecords(ctx, "example.org", []libdns.Record{record})
+	require.NoError(t, err)
+
+	server.access.Lock()
+	defer server.access.Unlock()
+	require.Len(t, server.messages, 2)
+	for i, message := range server.messages {
+		require.NoError(t, server.tsigErr[i])
+		require.Equal(t, mDNS.OpcodeUpdate, message.Opcode)
+		require.Equal(t, "example.org.", message.Question[0].Name)
+		tsig := message.IsTsig()
+		require.NotNil(t, tsig)
+		require.Equal(t, mDNS.HmacSHA256, tsig.Algorithm)
+		require.Len(t, message.Ns, 1)
+		txt, isTXT := message.Ns[0].(*mDNS.TXT)
+		require.True(t, isTXT)
+		require.Equal(t, "_acme-challenge.www.example.org.", txt.Hdr.Name)
+		require.Equal(t, []string{record.Value}, txt.Txt)
+	}
+	require.Equal(t, uint16(mDNS.ClassINET), server.messages[0].Ns[0].Header().Class)
+	require.Equal(t, uint32(120), server.messages[0].Ns[0].Header().Ttl)
+	require.Equal(t, uint16(mDNS.ClassNONE), server.messages[1].Ns[0].Header().Class)
+}
+
+func TestRFC2136ProviderRejected(t *testing.T) {
+	t.Parallel()
+	server, serverAddr := startRFC2136TestServer(t)
+	record := libdns.Record{Type: "TXT", Name: "_acme-challenge", Value: "token"}
+	for _, rcode := range []int{mDNS.RcodeRefused, mDNS.RcodeServerFailure} {
+		server.access.Lock()
+		server.rcode = rcode
+		server.access.Unlock()
+		provider, err := newRFC2136Provider(option.ACMEDNS01RFC2136Options{
+			Server:  serverAddr,
+			KeyName: "acme",
+			Key:     rfc2136TestKey,
+		})
+		require.NoError(t, err)
+		_, err = provider.AppendRecords(context.Background(), "example.org", []libdns.Record{record})
+		require.ErrorContains(t, err, mDNS.RcodeToString[rcode])
+	}
+	provider, err := newRFC2136Provider(option.ACMEDNS01RFC2136Options{
+		Server:  serverAddr,
+		KeyName: "acme",
+		Key:     "d3Jvbmcta2V5",
+	})
+	require.NoError(t, err)
+	_, err = provider.AppendRecords(context.Background(), "example.org", []libdns.Record{record})
+	require.Error(t, err)
+}
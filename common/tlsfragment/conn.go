@@ -34,76 +34,15 @@ func (c *Conn) Write(b []byte) (n int, err error) {
 		defer func() {
 			c.firstPacketWritten = true
 		}()
+		var splitIndexes []int
 		serverName := indexTLSServerName(b)
 		if serverName != nil {
-			if c.tcpConn != nil {
-				err = c.tcpConn.SetNoDelay(true)
-				if err != nil {
-					return
-				}
-			}
-			splits := strings.Split(string(b[serverName.Index:serverName.Index+serverName.Length]), ".")
-			currentIndex := serverName.Index
-			var striped bool
-			if len(splits) > 3 {
-				suffix := splits[len(splits)-3] + "." + splits[len(splits)-2] + "." + splits[len(splits)-1]
-				if publicSuffixMatcher().Match(suffix) {
-					splits = splits[:len(splits)-3]
-				}
-				striped = true
-			}
-			if !striped && len(splits) > 2 {
-				suffix := splits[len(splits)-2] + "." + splits[len(splits)-1]
-				if publicSuffixMatcher().Match(suffix) {
-					splits = splits[:len(splits)-2]
-				}
-				striped = true
-			}
-			if !striped && len(splits) > 1 {
-				suffix := splits[len(splits)-1]
-				if publicSuffixMatcher().Match(suffix) {
-					splits = splits[:len(splits)-1]
-				}
-			}
-			if len(splits) > 1 && common.Contains(publicPrefix, splits[0]) {
-				currentIndex += len(splits[0]) + 1
-				splits = splits[1:]
-			}
-			var splitIndexes []int
-			for i, split := range splits {
-				splitAt := rand.Intn(len(split))
-				splitIndexes = append(splitIndexes, currentIndex+splitAt)
-				currentIndex += len(split)
-				if i != len(splits)-1 {
-					currentIndex++
-				}
-			}
-			for i := 0; i <= len(splitIndexes); i++ {
-				var payload []byte
-				if i == 0 {
-					payload = b[:splitIndexes[i]]
-				} else if i == len(splitIndexes) {
-					payload = b[splitIndexes[i-1]:]
-				} else {
-					payload = b[splitIndexes[i-1]:splitIndexes[i]]
-				}
-				if c.tcpConn != nil && i != len(splitIndexes) {
-					err = writeAndWaitAck(c.ctx, c.tcpConn, payload, c.fallbackDelay)
-					if err != nil {
-						return
-					}
-				} else {
-					_, err = c.Conn.Write(payload)
-					if err != nil {
-						return
-					}
-				}
-			}
-			if c.tcpConn != nil {
-				err = c.tcpConn.SetNoDelay(false)
-				if err != nil {
-					return
-				}
+			splitIndexes = splitServerName(string(b[serverName.Index:serverName.Index+serverName.Length]), serverName.Index)
+		}
+		if len(splitIndexes) > 0 {
+			err = writeFragments(c.ctx, c.Conn, c.tcpConn, b, splitIndexes, c.fallbackDelay)
+			if err != nil {
+				return
 			}
 			return len(b), nil
 		}
@@ -122,3 +61,83 @@ func (c *Conn) WriterReplaceable() bool {
 func (c *Conn) Upstream() any {
 	return c.Conn
 }
+
+func writeFragments(ctx context.Context, conn net.Conn, tcpConn *net.TCPConn, b []byte, splitIndexes []int, fallbackDelay time.Duration) error {
+	if tcpConn != nil {
+		err := tcpConn.SetNoDelay(true)
+		if err != nil {
+			return err
+		}
+	}
+	for i := 0; i <= len(splitIndexes); i++ {
+		var payload []byte
+		if i == 0 {
+			payload = b[:splitIndexes[i]]
+		} else if i == len(splitIndexes) {
+			payload = b[splitIndexes[i-1]:]
+		} else {
+			payload = b[splitIndexes[i-1]:splitIndexes[i]]
+		}
+		if tcpConn != nil && i != len(splitIndexes) {
+			err := writeAndWaitAck(ctx, tcpConn, payload, fallbackDelay)
+			if err != nil {
+				return err
+			}
+		} else {
+			_, err := conn.Write(payload)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if tcpConn != nil {
+		return tcpConn.SetNoDelay(false)
+	}
+	return nil
+}
+
+func splitServerName(serverName string, currentIndex int) []int {
+	serverName = strings.TrimSuffix(serverName, ".")
+	if serverName == "" {
+		return nil
+	}
+	splits := strings.Split(serverName, ".")
+	var striped bool
+	if len(splits) > 3 {
+		suffix := splits[len(splits)-3] + "." + splits[len(splits)-2] + "." + splits[len(splits)-1]
+		if publicSuffixMatcher().Match(suffix) {
+			splits = splits[:len(splits)-3]
+		}
+		striped = true
+	}
+	if !striped && len(splits) > 2 {
+		suffix := splits[len(splits)-2] + "." + splits[len(splits)-1]
+		if publicSuffixMatcher().Match(suffix) {
+			splits = splits[:len(splits)-2]
+		}
+		striped = true
+	}
+	if !striped && len(splits) > 1 {
+		suffix := splits[len(splits)-1]
+		if publicSuffixMatcher().Match(suffix) {
+			splits = splits[:len(splits)-1]
+		}
+	}
+	if len(splits) > 1 && common.Contains(publicPrefix, splits[0]) {
+		currentIndex += len(splits[0]) + 1
+		splits = splits[1:]
+	}
+	var splitIndexes []int
+	for i, split := range splits {
+		// empty labels can not be split
+		if split != "" {
+			splitAt := rand.Intn(len(split))
+			splitIndexes = append(splitIndexes, currentIndex+splitAt)
+		}
+		currentIndex += len(split)
+		if i != len(splits)-1 {
+			currentIndex++
+		}
+	}
+	return splitIndexes
+}
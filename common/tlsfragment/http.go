@@ -0,0 +1,171 @@
+package tf
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+type HTTPOptions struct {
+	SplitHost      bool
+	MixHostCase    bool
+	ExtraHostSpace bool
+	FallbackDelay  time.Duration
+}
+
+// HTTPConn rewrites the Host header of the first plain HTTP request written to the connection.
+type HTTPConn struct {
+	net.Conn
+	tcpConn            *net.TCPConn
+	ctx                context.Context
+	options            HTTPOptions
+	firstPacketWritten bool
+}
+
+func NewHTTPConn(conn net.Conn, ctx context.Context, options HTTPOptions) *HTTPConn {
+	tcpConn, _ := N.UnwrapReader(conn).(*net.TCPConn)
+	return &HTTPConn{
+		Conn:    conn,
+		tcpConn: tcpConn,
+		ctx:     ctx,
+		options: options,
+	}
+}
+
+func (c *HTTPConn) Write(b []byte) (n int, err error) {
+	if !c.firstPacketWritten {
+		defer func() {
+			c.firstPacketWritten = true
+		}()
+		host := indexHTTPHost(b)
+		if host != nil {
+			payload, splitIndexes := mangleHTTPHost(b, host, c.options)
+			if len(splitIndexes) > 0 {
+				err = writeFragments(c.ctx, c.Conn, c.tcpConn, payload, splitIndexes, c.options.FallbackDelay)
+			} else {
+				_, err = c.Conn.Write(payload)
+			}
+			if err != nil {
+				return
+			}
+			return len(b), nil
+		}
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *HTTPConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *HTTPConn) WriterReplaceable() bool {
+	return c.firstPacketWritten
+}
+
+func (c *HTTPConn) Upstream() any {
+	return c.Conn
+}
+
+type httpHost struct {
+	Index       int
+	ValueIndex  int
+	ValueLength int
+}
+
+func indexHTTPHost(payload []byte) *httpHost {
+	requestLineEnd := bytes.Index(payload, []byte("\r\n"))
+	if requestLineEnd <= 0 {
+		return nil
+	}
+	requestLine := payload[:requestLineEnd]
+	if !bytes.HasPrefix(requestLine[bytes.LastIndexByte(requestLine, ' ')+1:], []byte("HTTP/1.")) {
+		return nil
+	}
+	for lineStart := requestLineEnd + 2; lineStart < len(payload); {
+		lineLength := bytes.Index(payload[lineStart:], []byte("\r\n"))
+		if lineLength <= 0 {
+			return nil
+		}
+		line := payload[lineStart : lineStart+lineLength]
+		if len(line) > 5 && strings.EqualFold(string(line[:5]), "host:") {
+			valueIndex := 5
+			for valueIndex < len(line) && (line[valueIndex] == ' ' || line[valueIndex] == '\t') {
+				valueIndex++
+			}
+			valueEnd := len(line)
+			for valueEnd > valueIndex && (line[valueEnd-1] == ' ' || line[valueEnd-1] == '\t') {
+				valueEnd--
+			}
+			if valueEnd == valueIndex {
+				return nil
+			}
+			return &httpHost{
+				Index:       lineStart,
+				ValueIndex:  lineStart + valueIndex,
+				ValueLength: valueEnd - valueIndex,
+			}
+		}
+		lineStart += lineLength + 2
+	}
+	return nil
+}
+
+func mangleHTTPHost(payload []byte, host *httpHost, options HTTPOptions) ([]byte, []int) {
+	var splitIndexes []int
+	newPayload := make([]byte, 0, len(payload)+8)
+	newPayload = append(newPayload, payload[:host.Index]...)
+	if options.MixHostCase {
+		newPayload = append(newPayload, mixCase("host")...)
+	} else {
+		newPayload = append(newPayload, payload[host.Index:host.Index+4]...)
+	}
+	if options.SplitHost {
+		splitIndexes = append(splitIndexes, host.Index+2)
+	}
+	newPayload = append(newPayload, ':')
+	if options.ExtraHostSpace {
+		spaces := 2 + rand.Intn(3)
+		for i := 0; i < spaces; i++ {
+			newPayload = append(newPayload, " \t"[rand.Intn(2)])
+		}
+	} else {
+		newPayload = append(newPayload, payload[host.Index+5:host.ValueIndex]...)
+	}
+	value := string(payload[host.ValueIndex : host.ValueIndex+host.ValueLength])
+	if options.SplitHost {
+		hostname := M.ParseSocksaddr(value).AddrString()
+		if hostname != "" && strings.HasPrefix(strings.ToLower(value), strings.ToLower(hostname)) {
+			splitIndexes = append(splitIndexes, splitServerName(strings.ToLower(hostname), len(newPayload))...)
+		}
+	}
+	if options.MixHostCase {
+		value = mixCase(value)
+	}
+	newPayload = append(newPayload, value...)
+	if options.ExtraHostSpace {
+		newPayload = append(newPayload, ' ')
+	}
+	newPayload = append(newPayload, payload[host.ValueIndex+host.ValueLength:]...)
+	return newPayload, splitIndexes
+}
+
+func mixCase(value string) string {
+	mixed := []byte(value)
+	for i, char := range mixed {
+		if rand.Intn(2) == 0 {
+			continue
+		}
+		if char >= 'a' && char <= 'z' {
+			mixed[i] = char - 'a' + 'A'
+		} else if char >= 'A' && char <= 'Z' {
+			mixed[i] = char - 'A' + 'a'
+		}
+	}
+	return string(mixed)
+}
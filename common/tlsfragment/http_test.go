@@ -0,0 +1,52 @@
+package tf
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMangleHTTPHost(t *testing.T) {
+	t.Parallel()
+	request := []byte("GET / HTTP/1.1\r\nUser-Agent: curl/8.0\r\nHost: www.example.com:8080\r\nAccept: */*\r\n\r\n")
+	host := indexHTTPHost(request)
+	require.NotNil(t, host)
+	require.Equal(t, "www.example.com:8080", string(request[host.ValueIndex:host.ValueIndex+host.ValueLength]))
+	payload, splitIndexes := mangleHTTPHost(request, host, HTTPOptions{
+		SplitHost:      true,
+		MixHostCase:    true,
+		ExtraHostSpace: true,
+	})
+	require.NotEmpty(t, splitIndexes)
+	for i := 1; i < len(splitIndexes); i++ {
+		require.Greater(t, splitIndexes[i], splitIndexes[i-1])
+	}
+	require.NotContains(t, string(payload), "Host: www.example.com")
+	parsedRequest, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(payload)))
+	require.NoError(t, err)
+	require.Equal(t, "www.example.com:8080", strings.ToLower(parsedRequest.Host))
+	require.Equal(t, "*/*", parsedRequest.Header.Get("Accept"))
+}
+
+func TestIndexHTTPHostInvalid(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, indexHTTPHost([]byte("SSH-2.0-OpenSSH_9.0\r\n")))
+	require.Nil(t, indexHTTPHost([]byte("GET / HTTP/1.1\r\nAccept: */*\r\n\r\n")))
+}
+
+func TestMangleHTTPHostEmptyLabel(t *testing.T) {
+	t.Parallel()
+	for _, hostname := range []string{"example.com.", "www..example.com", "www.example.com..", ".", ".."} {
+		request := []byte("GET / HTTP/1.1\r\nHost: " + hostname + "\r\n\r\n")
+		host := indexHTTPHost(request)
+		require.NotNil(t, host, hostname)
+		payload, splitIndexes := mangleHTTPHost(request, host, HTTPOptions{SplitHost: true})
+		for _, splitIndex := range splitIndexes {
+			require.Less(t, splitIndex, len(payload), hostname)
+		}
+	}
+}
@@ -40,7 +40,7 @@ func indexTLSServerName(payload []byte) *myServerName {
 	if serverName == nil {
 		return nil
 	}
-	serverName.Length += recordLayerHeaderLen
+	serverName.Index += recordLayerHeaderLen
 	return serverName
 }
 
@@ -80,7 +80,7 @@ func indexTLSServerNameFromHandshake(hs []byte) *myServerName {
 	if serverName == nil {
 		return nil
 	}
-	serverName.Index += currentIndex
+	serverName.Index += handshakeHeaderLen + randomDataLen + sessionIDHeaderLen + int(sessionIDLen) + currentIndex
 	return serverName
 }
 
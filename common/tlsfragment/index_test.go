@@ -0,0 +1,38 @@
+package tf
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexTLSServerName(t *testing.T) {
+	t.Parallel()
+	for _, serverName := range []string{"example.com", "www.google.com", "a.b.c.example.co.uk"} {
+		payload := readClientHello(t, serverName)
+		index := indexTLSServerName(payload)
+		require.NotNil(t, index, serverName)
+		require.Equal(t, serverName, string(payload[index.Index:index.Index+index.Length]))
+	}
+}
+
+func readClientHello(t *testing.T, serverName string) []byte {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		tls.Client(client, &tls.Config{ServerName: serverName}).Handshake()
+		client.Close()
+	}()
+	header := make([]byte, recordLayerHeaderLen)
+	_, err := io.ReadFull(server, header)
+	require.NoError(t, err)
+	payload := make([]byte, recordLayerHeaderLen+int(binary.BigEndian.Uint16(header[3:5])))
+	copy(payload, header)
+	_, err = io.ReadFull(server, payload[recordLayerHeaderLen:])
+	require.NoError(t, err)
+	return payload
+}
@@ -0,0 +1,738 @@
+package tf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/sagernet/sing-box/common/internal/qtls"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	quicMinInitialDatagramSize = 1200
+	quicPacketNumberLen        = 4
+	quicMaxPaddingPrefix       = 32
+)
+
+const (
+	quicPacketTypeInitial = iota
+	quicPacketType0RTT
+	quicPacketTypeHandshake
+	quicPacketTypeRetry
+)
+
+const (
+	quicFrameTypePadding         = 0x00
+	quicFrameTypePing            = 0x01
+	quicFrameTypeAck             = 0x02
+	quicFrameTypeAckECN          = 0x03
+	quicFrameTypeCrypto          = 0x06
+	quicFrameTypeConnectionClose = 0x1c
+)
+
+// QUICPacketConn splits the CRYPTO frames of client Initial packets across several Initial packets.
+//
+// Since every fragment consumes a packet number, packet numbers of following client Initial packets
+// are shifted, and ACK frames in server Initial packets are mapped back before delivered to the client.
+type QUICPacketConn struct {
+	net.PacketConn
+	reorder bool
+	padding bool
+
+	access              sync.Mutex
+	done                bool
+	clientKeys          *quicInitialKeys
+	serverKeys          *quicInitialKeys
+	largestClientPacket int64
+	largestServerPacket int64
+	packetNumbers       []quicPacketNumberMapping
+}
+
+type quicPacketNumberMapping struct {
+	origin uint64
+	start  uint64
+	end    uint64
+}
+
+func NewQUICPacketConn(conn net.PacketConn, reorder bool, padding bool) *QUICPacketConn {
+	return &QUICPacketConn{
+		PacketConn:          conn,
+		reorder:             reorder,
+		padding:             padding,
+		largestClientPacket: -1,
+		largestServerPacket: -1,
+	}
+}
+
+func (c *QUICPacketConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	c.access.Lock()
+	if c.done {
+		c.access.Unlock()
+		return c.PacketConn.WriteTo(p, addr)
+	}
+	datagrams, err := c.rewriteClientDatagram(p)
+	if err != nil {
+		c.done = true
+	}
+	c.access.Unlock()
+	if err != nil || datagrams == nil {
+		return c.PacketConn.WriteTo(p, addr)
+	}
+	for _, datagram := range datagrams {
+		_, err = c.PacketConn.WriteTo(datagram, addr)
+		if err != nil {
+			return
+		}
+	}
+	return len(p), nil
+}
+
+func (c *QUICPacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	n, addr, err = c.PacketConn.ReadFrom(p)
+	if err != nil {
+		return
+	}
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.done {
+		return
+	}
+	datagram, rewriteErr := c.rewriteServerDatagram(p[:n])
+	if rewriteErr != nil || datagram == nil || len(datagram) > len(p) {
+		return
+	}
+	n = copy(p, datagram)
+	return
+}
+
+func (c *QUICPacketConn) Upstream() any {
+	return c.PacketConn
+}
+
+func (c *QUICPacketConn) rewriteClientDatagram(datagram []byte) ([][]byte, error) {
+	var (
+		datagrams  [][]byte
+		coalesced  []byte
+		rewritten  bool
+		handshaken bool
+	)
+	for remaining := datagram; len(remaining) > 0; {
+		if remaining[0]&0x80 == 0 {
+			coalesced = append(coalesced, remaining...)
+			handshaken = true
+			break
+		}
+		header, err := parseQUICLongHeader(remaining)
+		if err != nil {
+			return nil, err
+		}
+		packet := remaining[:header.packetLen]
+		remaining = remaining[header.packetLen:]
+		switch header.packetType {
+		case quicPacketTypeInitial:
+			if c.clientKeys == nil {
+				c.clientKeys, err = newQUICInitialKeys(header.version, header.destConnID, "client in")
+				if err != nil {
+					return nil, err
+				}
+				c.serverKeys, err = newQUICInitialKeys(header.version, header.destConnID, "server in")
+				if err != nil {
+					return nil, err
+				}
+			}
+			packetNumber, payload, err := c.clientKeys.open(packet, header.headerLen, c.largestClientPacket)
+			if err != nil {
+				return nil, err
+			}
+			if int64(packetNumber) > c.largestClientPacket {
+				c.largestClientPacket = int64(packetNumber)
+			}
+			frames, err := parseQUICFrames(payload)
+			if err != nil {
+				return nil, err
+			}
+			groups := c.splitFrames(frames)
+			wirePacketNumber := packetNumber + c.shift()
+			c.packetNumbers = append(c.packetNumbers, quicPacketNumberMapping{
+				origin: packetNumber,
+				start:  wirePacketNumber,
+				end:    wirePacketNumber + uint64(len(groups)) - 1,
+			})
+			if len(groups) == 1 {
+				if wirePacketNumber == packetNumber {
+					coalesced = append(coalesced, packet...)
+				} else {
+					coalesced = append(coalesced, c.clientKeys.seal(header, wirePacketNumber, payload)...)
+					rewritten = true
+				}
+				continue
+			}
+			targetSize := quicMinInitialDatagramSize
+			if c.padding && len(datagram) > quicMinInitialDatagramSize {
+				targetSize += rand.Intn(len(datagram) - quicMinInitialDatagramSize + 1)
+			}
+			for i, group := range groups {
+				var framePayload []byte
+				if c.padding {
+					framePayload = make([]byte, rand.Intn(quicMaxPaddingPrefix)+1)
+				}
+				for _, frame := range group {
+					framePayload = frame.append(framePayload)
+				}
+				overhead := header.sealedHeaderLen() + c.clientKeys.aead.Overhead()
+				if overhead+len(framePayload) < targetSize {
+					framePayload = append(framePayload, make([]byte, targetSize-overhead-len(framePayload))...)
+				}
+				datagrams = append(datagrams, c.clientKeys.seal(header, wirePacketNumber+uint64(i), framePayload))
+			}
+			rewritten = true
+		case quicPacketTypeHandshake:
+			coalesced = append(coalesced, packet...)
+			handshaken = true
+		default:
+			coalesced = append(coalesced, packet...)
+		}
+	}
+	if handshaken {
+		c.done = true
+	}
+	if !rewritten {
+		return nil, nil
+	}
+	if c.reorder {
+		for i, j := 0, len(datagrams)-1; i < j; i, j = i+1, j-1 {
+			datagrams[i], datagrams[j] = datagrams[j], datagrams[i]
+		}
+	}
+	if len(coalesced) > 0 {
+		if len(datagrams) > 0 {
+			datagrams[len(datagrams)-1] = append(datagrams[len(datagrams)-1], coalesced...)
+		} else {
+			datagrams = append(datagrams, coalesced)
+		}
+	}
+	return datagrams, nil
+}
+
+func (c *QUICPacketConn) shift() uint64 {
+	if len(c.packetNumbers) == 0 {
+		return 0
+	}
+	last := c.packetNumbers[len(c.packetNumbers)-1]
+	return last.end - last.origin
+}
+
+func (c *QUICPacketConn) splitFrames(frames []quicFrame) [][]quicFrame {
+	var (
+		otherFrames  []quicFrame
+		cryptoFrames []quicFrame
+	)
+	for _, frame := range frames {
+		if frame.frameType == quicFrameTypeCrypto {
+			cryptoFrames = append(cryptoFrames, frame)
+		} else if frame.frameType != quicFrameTypePadding {
+			otherFrames = append(otherFrames, frame)
+		}
+	}
+	if len(cryptoFrames) == 0 {
+		return [][]quicFrame{frames}
+	}
+	sort.Slice(cryptoFrames, func(i, j int) bool {
+		return cryptoFrames[i].offset < cryptoFrames[j].offset
+	})
+	var splitIndexes []int
+	if cryptoFrames[0].offset == 0 {
+		var clientHello []byte
+		for _, frame := range cryptoFrames {
+			if frame.offset != uint64(len(clientHello)) {
+				break
+			}
+			clientHello = append(clientHello, frame.data...)
+		}
+		if len(clientHello) > 4 {
+			handshakeLen := int(clientHello[1])<<16 | int(clientHello[2])<<8 | int(clientHello[3])
+			if len(clientHello) >= 4+handshakeLen {
+				serverName := indexTLSServerNameFromHandshake(clientHello[:4+handshakeLen])
+				if serverName != nil {
+					splitIndexes = splitServerName(string(clientHello[serverName.Index:serverName.Index+serverName.Length]), serverName.Index)
+				}
+			}
+		}
+	}
+	groups := [][]quicFrame{otherFrames}
+	for _, frame := range cryptoFrames {
+		var frameSplitIndexes []uint64
+		for _, index := range splitIndexes {
+			if uint64(index) > frame.offset && uint64(index) < frame.offset+uint64(len(frame.data)) {
+				frameSplitIndexes = append(frameSplitIndexes, uint64(index))
+			}
+		}
+		if len(frameSplitIndexes) == 0 && len(frame.data) > 1 {
+			frameSplitIndexes = append(frameSplitIndexes, frame.offset+1+uint64(rand.Intn(len(frame.data)-1)))
+		}
+		currentOffset := frame.offset
+		for _, index := range append(frameSplitIndexes, frame.offset+uint64(len(frame.data))) {
+			groups = append(groups, []quicFrame{{
+				frameType: quicFrameTypeCrypto,
+				offset:    currentOffset,
+				data:      frame.data[currentOffset-frame.offset : index-frame.offset],
+			}})
+			currentOffset = index
+		}
+	}
+	if len(otherFrames) > 0 {
+		groups[1] = append(groups[0], groups[1]...)
+	}
+	return groups[1:]
+}
+
+func (c *QUICPacketConn) rewriteServerDatagram(datagram []byte) ([]byte, error) {
+	var (
+		newDatagram []byte
+		rewritten   bool
+	)
+	for remaining := datagram; len(remaining) > 0; {
+		if remaining[0]&0x80 == 0 {
+			newDatagram = append(newDatagram, remaining...)
+			break
+		}
+		header, err := parseQUICLongHeader(remaining)
+		if err != nil {
+			return nil, err
+		}
+		packet := remaining[:header.packetLen]
+		remaining = remaining[header.packetLen:]
+		switch header.packetType {
+		case quicPacketTypeRetry:
+			c.clientKeys = nil
+			c.serverKeys = nil
+			c.largestClientPacket = -1
+			c.largestServerPacket = -1
+			c.packetNumbers = nil
+			return nil, nil
+		case quicPacketTypeInitial:
+			if c.serverKeys == nil || c.shift() == 0 {
+				newDatagram = append(newDatagram, packet...)
+				continue
+			}
+			packetNumber, payload, err := c.serverKeys.open(packet, header.headerLen, c.largestServerPacket)
+			if err != nil {
+				return nil, err
+			}
+			if int64(packetNumber) > c.largestServerPacket {
+				c.largestServerPacket = int64(packetNumber)
+			}
+			frames, err := parseQUICFrames(payload)
+			if err != nil {
+				return nil, err
+			}
+			var newPayload []byte
+			for _, frame := range frames {
+				if frame.frameType == quicFrameTypeAck || frame.frameType == quicFrameTypeAckECN {
+					newPayload = c.appendMappedAck(newPayload, frame)
+				} else {
+					newPayload = append(newPayload, frame.raw...)
+				}
+			}
+			if len(newPayload) < quicPacketNumberLen {
+				newPayload = append(newPayload, make([]byte, quicPacketNumberLen-len(newPayload))...)
+			}
+			newDatagram = append(newDatagram, c.serverKeys.seal(header, packetNumber, newPayload)...)
+			rewritten = true
+		default:
+			newDatagram = append(newDatagram, packet...)
+		}
+	}
+	if !rewritten {
+		return nil, nil
+	}
+	return newDatagram, nil
+}
+
+func (c *QUICPacketConn) appendMappedAck(b []byte, frame quicFrame) []byte {
+	var acked []uint64
+	for _, mapping := range c.packetNumbers {
+		if frame.acked(mapping.start, mapping.end) {
+			acked = append(acked, mapping.origin)
+		}
+	}
+	if len(acked) == 0 {
+		return b
+	}
+	sort.Slice(acked, func(i, j int) bool {
+		return acked[i] > acked[j]
+	})
+	var ranges [][2]uint64
+	for _, packetNumber := range acked {
+		if len(ranges) > 0 && ranges[len(ranges)-1][0] == packetNumber+1 {
+			ranges[len(ranges)-1][0] = packetNumber
+		} else if len(ranges) == 0 || ranges[len(ranges)-1][0] > packetNumber {
+			ranges = append(ranges, [2]uint64{packetNumber, packetNumber})
+		}
+	}
+	b = append(b, frame.frameType)
+	b = qtls.AppendUvarint(b, ranges[0][1])
+	b = qtls.AppendUvarint(b, frame.ackDelay)
+	b = qtls.AppendUvarint(b, uint64(len(ranges)-1))
+	b = qtls.AppendUvarint(b, ranges[0][1]-ranges[0][0])
+	for i := 1; i < len(ranges); i++ {
+		b = qtls.AppendUvarint(b, ranges[i-1][0]-ranges[i][1]-2)
+		b = qtls.AppendUvarint(b, ranges[i][1]-ranges[i][0])
+	}
+	return append(b, frame.ackECN...)
+}
+
+type quicLongHeader struct {
+	firstByte  byte
+	version    uint32
+	packetType int
+	destConnID []byte
+	srcConnID  []byte
+	token      []byte
+	headerLen  int
+	packetLen  int
+}
+
+func parseQUICLongHeader(packet []byte) (*quicLongHeader, error) {
+	reader := bytes.NewReader(packet)
+	var header quicLongHeader
+	var err error
+	header.firstByte, err = reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Read(reader, binary.BigEndian, &header.version)
+	if err != nil {
+		return nil, err
+	}
+	if header.version != qtls.VersionDraft29 && header.version != qtls.Version1 && header.version != qtls.Version2 {
+		return nil, E.New("bad version")
+	}
+	header.packetType = int(header.firstByte&0x30) >> 4
+	if header.version == qtls.Version2 {
+		header.packetType = (header.packetType + 3) % 4
+	}
+	header.destConnID, err = readQUICConnectionID(reader)
+	if err != nil {
+		return nil, err
+	}
+	header.srcConnID, err = readQUICConnectionID(reader)
+	if err != nil {
+		return nil, err
+	}
+	if header.packetType == quicPacketTypeRetry {
+		header.headerLen = len(packet)
+		header.packetLen = len(packet)
+		return &header, nil
+	}
+	if header.packetType == quicPacketTypeInitial {
+		var tokenLen uint64
+		tokenLen, err = qtls.ReadUvarint(reader)
+		if err != nil {
+			return nil, err
+		}
+		if tokenLen > uint64(reader.Len()) {
+			return nil, os.ErrInvalid
+		}
+		header.token = make([]byte, tokenLen)
+		_, err = io.ReadFull(reader, header.token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	length, err := qtls.ReadUvarint(reader)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(reader.Len()) {
+		return nil, os.ErrInvalid
+	}
+	header.headerLen = len(packet) - reader.Len()
+	header.packetLen = header.headerLen + int(length)
+	return &header, nil
+}
+
+func readQUICConnectionID(reader *bytes.Reader) ([]byte, error) {
+	connIDLen, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if connIDLen > 20 {
+		return nil, E.New("bad connection id length")
+	}
+	connID := make([]byte, connIDLen)
+	_, err = io.ReadFull(reader, connID)
+	if err != nil {
+		return nil, err
+	}
+	return connID, nil
+}
+
+func (h *quicLongHeader) sealedHeaderLen() int {
+	headerLen := 1 + 4 + 1 + len(h.destConnID) + 1 + len(h.srcConnID) + 2 + quicPacketNumberLen
+	if h.packetType == quicPacketTypeInitial {
+		headerLen += len(qtls.AppendUvarint(nil, uint64(len(h.token)))) + len(h.token)
+	}
+	return headerLen
+}
+
+type quicInitialKeys struct {
+	aead             cipher.AEAD
+	headerProtection cipher.Block
+}
+
+func newQUICInitialKeys(version uint32, destConnID []byte, label string) (*quicInitialKeys, error) {
+	var (
+		salt                      []byte
+		keyLabel                  string
+		ivLabel                   string
+		hkdfHeaderProtectionLabel string
+	)
+	switch version {
+	case qtls.Version1:
+		salt = qtls.SaltV1
+	case qtls.Version2:
+		salt = qtls.SaltV2
+	default:
+		salt = qtls.SaltOld
+	}
+	switch version {
+	case qtls.Version2:
+		keyLabel = qtls.HKDFLabelKeyV2
+		ivLabel = qtls.HKDFLabelIVV2
+		hkdfHeaderProtectionLabel = qtls.HKDFLabelHeaderProtectionV2
+	default:
+		keyLabel = qtls.HKDFLabelKeyV1
+		ivLabel = qtls.HKDFLabelIVV1
+		hkdfHeaderProtectionLabel = qtls.HKDFLabelHeaderProtectionV1
+	}
+	initialSecret := hkdf.Extract(crypto.SHA256.New, destConnID, salt)
+	secret := qtls.HKDFExpandLabel(crypto.SHA256, initialSecret, []byte{}, label, crypto.SHA256.Size())
+	key := qtls.HKDFExpandLabel(crypto.SHA256, secret, []byte{}, keyLabel, 16)
+	iv := qtls.HKDFExpandLabel(crypto.SHA256, secret, []byte{}, ivLabel, 12)
+	hpKey := qtls.HKDFExpandLabel(crypto.SHA256, secret, []byte{}, hkdfHeaderProtectionLabel, 16)
+	block, err := aes.NewCipher(hpKey)
+	if err != nil {
+		return nil, err
+	}
+	return &quicInitialKeys{
+		aead:             qtls.AEADAESGCMTLS13(key, iv),
+		headerProtection: block,
+	}, nil
+}
+
+func (k *quicInitialKeys) open(packet []byte, headerLen int, largestPacketNumber int64) (uint64, []byte, error) {
+	if len(packet) < headerLen+4+aes.BlockSize {
+		return 0, nil, os.ErrInvalid
+	}
+	mask := make([]byte, aes.BlockSize)
+	k.headerProtection.Encrypt(mask, packet[headerLen+4:headerLen+4+aes.BlockSize])
+	header := make([]byte, headerLen+4)
+	copy(header, packet)
+	header[0] ^= mask[0] & 0x0f
+	packetNumberLen := int(header[0]&0x3) + 1
+	var truncatedPacketNumber uint64
+	for i := 0; i < packetNumberLen; i++ {
+		header[headerLen+i] ^= mask[i+1]
+		truncatedPacketNumber = truncatedPacketNumber<<8 | uint64(header[headerLen+i])
+	}
+	header = header[:headerLen+packetNumberLen]
+	packetNumber := decodeQUICPacketNumber(largestPacketNumber, truncatedPacketNumber, packetNumberLen)
+	nonce := make([]byte, k.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], packetNumber)
+	payload, err := k.aead.Open(nil, nonce, packet[headerLen+packetNumberLen:], header)
+	if err != nil {
+		return 0, nil, err
+	}
+	return packetNumber, payload, nil
+}
+
+func (k *quicInitialKeys) seal(header *quicLongHeader, packetNumber uint64, payload []byte) []byte {
+	packet := make([]byte, 0, header.sealedHeaderLen()+len(payload)+k.aead.Overhead())
+	packet = append(packet, header.firstByte&0xf0|(quicPacketNumberLen-1))
+	packet = binary.BigEndian.AppendUint32(packet, header.version)
+	packet = append(packet, uint8(len(header.destConnID)))
+	packet = append(packet, header.destConnID...)
+	packet = append(packet, uint8(len(header.srcConnID)))
+	packet = append(packet, header.srcConnID...)
+	if header.packetType == quicPacketTypeInitial {
+		packet = qtls.AppendUvarint(packet, uint64(len(header.token)))
+		packet = append(packet, header.token...)
+	}
+	length := quicPacketNumberLen + len(payload) + k.aead.Overhead()
+	packet = append(packet, uint8(length>>8)|0x40, uint8(length))
+	packetNumberOffset := len(packet)
+	packet = binary.BigEndian.AppendUint32(packet, uint32(packetNumber))
+	nonce := make([]byte, k.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], packetNumber)
+	packet = k.aead.Seal(packet, nonce, payload, packet)
+	mask := make([]byte, aes.BlockSize)
+	k.headerProtection.Encrypt(mask, packet[packetNumberOffset+4:packetNumberOffset+4+aes.BlockSize])
+	packet[0] ^= mask[0] & 0x0f
+	for i := 0; i < quicPacketNumberLen; i++ {
+		packet[packetNumberOffset+i] ^= mask[i+1]
+	}
+	return packet
+}
+
+func decodeQUICPacketNumber(largestPacketNumber int64, truncatedPacketNumber uint64, packetNumberLen int) uint64 {
+	expectedPacketNumber := uint64(largestPacketNumber + 1)
+	packetNumberWindow := uint64(1) << (packetNumberLen * 8)
+	packetNumberHalfWindow := packetNumberWindow / 2
+	packetNumberMask := packetNumberWindow - 1
+	candidatePacketNumber := expectedPacketNumber&^packetNumberMask | truncatedPacketNumber
+	if candidatePacketNumber+packetNumberHalfWindow <= expectedPacketNumber && candidatePacketNumber < (1<<62)-packetNumberWindow {
+		return candidatePacketNumber + packetNumberWindow
+	}
+	if candidatePacketNumber > expectedPacketNumber+packetNumberHalfWindow && candidatePacketNumber >= packetNumberWindow {
+		return candidatePacketNumber - packetNumberWindow
+	}
+	return candidatePacketNumber
+}
+
+type quicFrame struct {
+	frameType byte
+	raw       []byte
+
+	// CRYPTO
+	offset uint64
+	data   []byte
+
+	// ACK
+	ackRanges [][2]uint64
+	ackDelay  uint64
+	ackECN    []byte
+}
+
+func parseQUICFrames(payload []byte) ([]quicFrame, error) {
+	var frames []quicFrame
+	reader := bytes.NewReader(payload)
+	for reader.Len() > 0 {
+		start := len(payload) - reader.Len()
+		frameType, _ := reader.ReadByte()
+		frame := quicFrame{frameType: frameType}
+		switch frameType {
+		case quicFrameTypePadding, quicFrameTypePing:
+		case quicFrameTypeAck, quicFrameTypeAckECN:
+			largestAcked, err := qtls.ReadUvarint(reader)
+			if err != nil {
+				return nil, err
+			}
+			frame.ackDelay, err = qtls.ReadUvarint(reader)
+			if err != nil {
+				return nil, err
+			}
+			ackRangeCount, err := qtls.ReadUvarint(reader)
+			if err != nil {
+				return nil, err
+			}
+			firstAckRange, err := qtls.ReadUvarint(reader)
+			if err != nil {
+				return nil, err
+			}
+			if firstAckRange > largestAcked {
+				return nil, os.ErrInvalid
+			}
+			smallest := largestAcked - firstAckRange
+			frame.ackRanges = append(frame.ackRanges, [2]uint64{smallest, largestAcked})
+			for i := uint64(0); i < ackRangeCount; i++ {
+				gap, err := qtls.ReadUvarint(reader)
+				if err != nil {
+					return nil, err
+				}
+				ackRangeLen, err := qtls.ReadUvarint(reader)
+				if err != nil {
+					return nil, err
+				}
+				if gap+2+ackRangeLen > smallest {
+					return nil, os.ErrInvalid
+				}
+				largest := smallest - gap - 2
+				smallest = largest - ackRangeLen
+				frame.ackRanges = append(frame.ackRanges, [2]uint64{smallest, largest})
+			}
+			if frameType == quicFrameTypeAckECN {
+				ecnStart := len(payload) - reader.Len()
+				for i := 0; i < 3; i++ {
+					_, err = qtls.ReadUvarint(reader)
+					if err != nil {
+						return nil, err
+					}
+				}
+				frame.ackECN = payload[ecnStart : len(payload)-reader.Len()]
+			}
+		case quicFrameTypeCrypto:
+			var err error
+			frame.offset, err = qtls.ReadUvarint(reader)
+			if err != nil {
+				return nil, err
+			}
+			length, err := qtls.ReadUvarint(reader)
+			if err != nil {
+				return nil, err
+			}
+			if length > uint64(reader.Len()) {
+				return nil, os.ErrInvalid
+			}
+			index := len(payload) - reader.Len()
+			frame.data = payload[index : index+int(length)]
+			_, err = reader.Seek(int64(length), io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+		case quicFrameTypeConnectionClose:
+			for i := 0; i < 2; i++ {
+				_, err := qtls.ReadUvarint(reader) // Error Code, Frame Type
+				if err != nil {
+					return nil, err
+				}
+			}
+			length, err := qtls.ReadUvarint(reader) // Reason Phrase Length
+			if err != nil {
+				return nil, err
+			}
+			if length > uint64(reader.Len()) {
+				return nil, os.ErrInvalid
+			}
+			_, err = reader.Seek(int64(length), io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, E.New("unexpected frame type in initial packet: ", frameType)
+		}
+		frame.raw = payload[start : len(payload)-reader.Len()]
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+func (f *quicFrame) append(b []byte) []byte {
+	if f.frameType != quicFrameTypeCrypto {
+		return append(b, f.raw...)
+	}
+	b = append(b, quicFrameTypeCrypto)
+	b = qtls.AppendUvarint(b, f.offset)
+	b = qtls.AppendUvarint(b, uint64(len(f.data)))
+	return append(b, f.data...)
+}
+
+func (f *quicFrame) acked(start uint64, end uint64) bool {
+	for _, ackRange := range f.ackRanges {
+		if ackRange[0] <= start && end <= ackRange[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package tf
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	stdtls "github.com/sagernet/sing-box/common/tls"
+
+	"github.com/sagernet/quic-go"
+	"github.com/stretchr/testify/require"
+)
+
+type quicDatagramCounter struct {
+	net.PacketConn
+	access sync.Mutex
+	sizes  []int
+}
+
+func (c *quicDatagramCounter) WriteTo(p []byte, addr net.Addr) (int, error) {
+	c.access.Lock()
+	c.sizes = append(c.sizes, len(p))
+	c.access.Unlock()
+	return c.PacketConn.WriteTo(p, addr)
+}
+
+func TestQUICFragment(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		name    string
+		reorder bool
+		padding bool
+	}{
+		{"default", false, false},
+		{"reorder", true, false},
+		{"padding", false, true},
+		{"reorder_padding", true, true},
+	} {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			testQUICFragment(t, testCase.reorder, testCase.padding)
+		})
+	}
+}
+
+func testQUICFragment(t *testing.T, reorder bool, padding bool) {
+	const serverName = "www.example.com"
+	certificate, err := stdtls.GenerateCertificate(time.Now, serverName)
+	require.NoError(t, err)
+	listener, err := quic.ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{*certificate},
+		NextProtos:   []string{"test"},
+	}, nil)
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, acceptErr := listener.Accept(context.Background())
+		if acceptErr != nil {
+			return
+		}
+		stream, acceptErr := conn.AcceptStream(context.Background())
+		if acceptErr != nil {
+			return
+		}
+		defer stream.Close()
+		buffer := make([]byte, 4)
+		_, acceptErr = io.ReadFull(stream, buffer)
+		if acceptErr != nil {
+			return
+		}
+		_, _ = stream.Write(buffer)
+	}()
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	counter := &quicDatagramCounter{PacketConn: udpConn}
+	packetConn := NewQUICPacketConn(counter, reorder, padding)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := quic.Dial(ctx, packetConn, listener.Addr(), &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"test"},
+	}, nil)
+	require.NoError(t, err)
+	defer conn.CloseWithError(0, "")
+	stream, err := conn.OpenStreamSync(ctx)
+	require.NoError(t, err)
+	_, err = stream.Write([]byte("ping"))
+	require.NoError(t, err)
+	buffer := make([]byte, 4)
+	_, err = io.ReadFull(stream, buffer)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buffer))
+	packetConn.access.Lock()
+	require.NotEmpty(t, packetConn.packetNumbers)
+	fragments := int(packetConn.packetNumbers[0].end-packetConn.packetNumbers[0].start) + 1
+	packetConn.access.Unlock()
+	require.Greater(t, fragments, 1)
+	counter.access.Lock()
+	defer counter.access.Unlock()
+	for _, size := range counter.sizes[:fragments] {
+		require.GreaterOrEqual(t, size, quicMinInitialDatagramSize)
+	}
+}
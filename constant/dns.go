@@ -32,4 +32,5 @@ const (
 const (
 	DNSProviderAliDNS     = "alidns"
 	DNSProviderCloudflare = "cloudflare"
+	DNSProviderRFC2136    = "rfc2136"
 )
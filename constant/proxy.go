@@ -28,6 +28,7 @@ const (
 const (
 	TypeSelector = "selector"
 	TypeURLTest  = "urltest"
+	TypeRace     = "race"
 )
 
 func ProxyDisplayName(proxyType string) string {
@@ -80,6 +81,8 @@ func ProxyDisplayName(proxyType string) string {
 		return "Selector"
 	case TypeURLTest:
 		return "URLTest"
+	case TypeRace:
+		return "Race"
 	default:
 		return "Unknown"
 	}
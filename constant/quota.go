@@ -0,0 +1,8 @@
+package constant
+
+const (
+	QuotaResetNever   = "never"
+	QuotaResetDaily   = "daily"
+	QuotaResetWeekly  = "weekly"
+	QuotaResetMonthly = "monthly"
+)
@@ -14,14 +14,23 @@ const (
 	RuleSetTypeInline   = "inline"
 	RuleSetTypeLocal    = "local"
 	RuleSetTypeRemote   = "remote"
+	RuleSetTypeMutable  = "mutable"
 	RuleSetFormatSource = "source"
 	RuleSetFormatBinary = "binary"
+	RuleSetFormatClash  = "clash"
+)
+
+const (
+	RuleSetBehaviorDomain    = "domain"
+	RuleSetBehaviorIPCIDR    = "ipcidr"
+	RuleSetBehaviorClassical = "classical"
 )
 
 const (
 	RuleSetVersion1 = 1 + iota
 	RuleSetVersion2
 	RuleSetVersion3
+	RuleSetVersion4
 	RuleSetVersionCurrent = RuleSetVersion3
 )
 
@@ -33,6 +42,14 @@ const (
 	RuleActionTypeHijackDNS    = "hijack-dns"
 	RuleActionTypeSniff        = "sniff"
 	RuleActionTypeResolve      = "resolve"
+	RuleActionTypeScript       = "script"
+)
+
+const (
+	RuleActionLimitKeyRule       = "rule"
+	RuleActionLimitKeyAuthUser   = "auth_user"
+	RuleActionLimitKeySourceIP   = "source_ip"
+	RuleActionLimitKeyConnection = "connection"
 )
 
 const (
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/nat64"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
@@ -104,6 +105,9 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 		return &responseMessage, nil
 	}
 	question := message.Question[0]
+	if question.Qtype == dns.TypeAAAA && options.DNS64Prefix.IsValid() && options.Strategy != C.DomainStrategyIPv4Only {
+		return c.exchangeDNS64(ctx, transport, message, options, responseChecker)
+	}
 	if options.ClientSubnet.IsValid() {
 		message = SetClientSubnet(message, options.ClientSubnet, true)
 	}
@@ -240,6 +244,70 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 	return response, err
 }
 
+// exchangeDNS64 synthesizes AAAA records from A records with the DNS64 prefix
+// if the domain has no AAAA records, as described in RFC 6147.
+//
+// The AAAA response is not cached, so that cached lookups do not skip the synthesis.
+func (c *Client) exchangeDNS64(ctx context.Context, transport adapter.DNSTransport, message *dns.Msg, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) (*dns.Msg, error) {
+	prefix := options.DNS64Prefix
+	options.DNS64Prefix = netip.Prefix{}
+	options6 := options
+	options6.DisableCache = true
+	response, err := c.Exchange(ctx, transport, message, options6, responseChecker)
+	if err != nil || response.Rcode != dns.RcodeSuccess || common.Any(response.Answer, func(it dns.RR) bool {
+		return it.Header().Rrtype == dns.TypeAAAA
+	}) {
+		return response, err
+	}
+	question := message.Question[0]
+	options.Strategy = C.DomainStrategyAsIS
+	response4, err := c.Exchange(ctx, transport, &dns.Msg{
+		MsgHdr: dns.MsgHdr{
+			RecursionDesired: true,
+		},
+		Question: []dns.Question{{
+			Name:   question.Name,
+			Qtype:  dns.TypeA,
+			Qclass: question.Qclass,
+		}},
+	}, options, nil)
+	if err != nil || response4.Rcode != dns.RcodeSuccess {
+		return response, nil
+	}
+	var (
+		answer      []dns.RR
+		synthesized int
+	)
+	for _, record := range response4.Answer {
+		switch record := record.(type) {
+		case *dns.A:
+			synthesized++
+			address, _ := netip.AddrFromSlice(record.A)
+			answer = append(answer, &dns.AAAA{
+				Hdr: dns.RR_Header{
+					Name:   record.Hdr.Name,
+					Rrtype: dns.TypeAAAA,
+					Class:  record.Hdr.Class,
+					Ttl:    record.Hdr.Ttl,
+				},
+				AAAA: nat64.Address(prefix, address).AsSlice(),
+			})
+		case *dns.CNAME:
+			answer = append(answer, record)
+		}
+	}
+	if synthesized == 0 {
+		return response, nil
+	}
+	response = response.Copy()
+	response.Answer = answer
+	response.Ns = nil
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, "synthesized ", synthesized, " records with DNS64 prefix ", prefix)
+	}
+	return response, nil
+}
+
 func (c *Client) Lookup(ctx context.Context, transport adapter.DNSTransport, domain string, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) ([]netip.Addr, error) {
 	domain = FqdnToDomain(domain)
 	dnsName := dns.Fqdn(domain)
@@ -380,7 +448,8 @@ func (c *Client) lookupToExchange(ctx context.Context, transport adapter.DNSTran
 		Qtype:  qType,
 		Qclass: dns.ClassINET,
 	}
-	disableCache := c.disableCache || options.DisableCache
+	// responses to be synthesized are not cached
+	disableCache := c.disableCache || options.DisableCache || qType == dns.TypeAAAA && options.DNS64Prefix.IsValid()
 	if !disableCache {
 		cachedAddresses, err := c.questionCache(question, transport)
 		if err != ErrNotCached {
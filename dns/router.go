@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/netip"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
@@ -35,7 +36,9 @@ type Router struct {
 	transport             adapter.DNSTransportManager
 	outbound              adapter.OutboundManager
 	client                adapter.DNSClient
+	ruleAccess            sync.RWMutex
 	rules                 []adapter.DNSRule
+	ruleStatistics        []*adapter.RuleStatistics
 	defaultDomainStrategy C.DomainStrategy
 	dnsReverseMapping     freelru.Cache[netip.Addr, string]
 	platformInterface     platform.Interface
@@ -80,6 +83,7 @@ func (r *Router) Initialize(rules []option.DNSRule) error {
 			return E.Cause(err, "parse dns rule[", i, "]")
 		}
 		r.rules = append(r.rules, dnsRule)
+		r.ruleStatistics = append(r.ruleStatistics, new(adapter.RuleStatistics))
 	}
 	return nil
 }
@@ -106,6 +110,8 @@ func (r *Router) Start(stage adapter.StartStage) error {
 
 func (r *Router) Close() error {
 	monitor := taskmonitor.New(r.logger, C.StopTimeout)
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
 	var err error
 	for i, rule := range r.rules {
 		monitor.Start("close dns rule[", i, "]")
@@ -122,17 +128,19 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 	if metadata == nil {
 		panic("no context")
 	}
+	rules, ruleStatistics := r.RulesWithStatistics()
 	var currentRuleIndex int
 	if ruleIndex != -1 {
 		currentRuleIndex = ruleIndex + 1
 	}
-	for ; currentRuleIndex < len(r.rules); currentRuleIndex++ {
-		currentRule := r.rules[currentRuleIndex]
+	for ; currentRuleIndex < len(rules); currentRuleIndex++ {
+		currentRule := rules[currentRuleIndex]
 		if currentRule.WithAddressLimit() && !isAddressQuery {
 			continue
 		}
 		metadata.ResetRuleCache()
 		if currentRule.Match(metadata) {
+			ruleStatistics[currentRuleIndex].Matches.Add(1)
 			displayRuleIndex := currentRuleIndex
 			if displayRuleIndex != -1 {
 				displayRuleIndex += displayRuleIndex + 1
@@ -166,6 +174,9 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 				if action.ClientSubnet.IsValid() {
 					options.ClientSubnet = action.ClientSubnet
 				}
+				if action.DNS64Prefix.IsValid() {
+					options.DNS64Prefix = action.DNS64Prefix
+				}
 				if legacyTransport, isLegacy := transport.(adapter.LegacyDNSTransport); isLegacy {
 					if options.Strategy == C.DomainStrategyAsIS {
 						options.Strategy = legacyTransport.LegacyStrategy()
@@ -189,6 +200,9 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 				if action.ClientSubnet.IsValid() {
 					options.ClientSubnet = action.ClientSubnet
 				}
+				if action.DNS64Prefix.IsValid() {
+					options.DNS64Prefix = action.DNS64Prefix
+				}
 				r.logger.DebugContext(ctx, "match[", displayRuleIndex, "] => ", currentRule.Action())
 			case *R.RuleActionReject:
 				r.logger.DebugContext(ctx, "match[", displayRuleIndex, "] => ", currentRule.Action())
@@ -435,3 +449,48 @@ func (r *Router) ResetNetwork() {
 		transport.Reset()
 	}
 }
+
+func (r *Router) Rules() []adapter.DNSRule {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
+	return r.rules
+}
+
+func (r *Router) RulesWithStatistics() ([]adapter.DNSRule, []*adapter.RuleStatistics) {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
+	return r.rules, r.ruleStatistics
+}
+
+func (r *Router) ReloadRules(ctx context.Context, rules []option.DNSRule) (func(), error) {
+	newRules := make([]adapter.DNSRule, 0, len(rules))
+	for i, ruleOptions := range rules {
+		dnsRule, err := R.NewDNSRule(ctx, r.logger, ruleOptions, true)
+		if err == nil {
+			newRules = append(newRules, dnsRule)
+			err = dnsRule.Start()
+		}
+		if err != nil {
+			for _, rule := range newRules {
+				rule.Close()
+			}
+			return nil, E.Cause(err, "parse dns rule[", i, "]")
+		}
+	}
+	return func() {
+		r.ruleAccess.Lock()
+		oldRules := r.rules
+		r.rules = newRules
+		r.ruleStatistics = common.Map(newRules, func(it adapter.DNSRule) *adapter.RuleStatistics {
+			return new(adapter.RuleStatistics)
+		})
+		r.ruleAccess.Unlock()
+		for i, rule := range oldRules {
+			err := rule.Close()
+			if err != nil {
+				r.logger.Error(E.Cause(err, "close dns rule[", i, "]"))
+			}
+		}
+		r.ClearCache()
+	}, nil
+}
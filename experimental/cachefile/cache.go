@@ -23,12 +23,14 @@ var (
 	bucketExpand   = []byte("group_expand")
 	bucketMode     = []byte("clash_mode")
 	bucketRuleSet  = []byte("rule_set")
+	bucketQuota    = []byte("quota")
 
 	bucketNameList = []string{
 		string(bucketSelected),
 		string(bucketExpand),
 		string(bucketMode),
 		string(bucketRuleSet),
+		string(bucketQuota),
 		string(bucketRDRC),
 	}
 
@@ -316,3 +318,49 @@ func (c *CacheFile) SaveRuleSet(tag string, set *adapter.SavedRuleSet) error {
 		return bucket.Put([]byte(tag), setBinary)
 	})
 }
+
+func (c *CacheFile) LoadQuota(tag string) map[string]*adapter.SavedQuota {
+	quotas := make(map[string]*adapter.SavedQuota)
+	c.DB.View(func(t *bbolt.Tx) error {
+		bucket := c.bucket(t, bucketQuota)
+		if bucket == nil {
+			return nil
+		}
+		bucket = bucket.Bucket([]byte(tag))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var savedQuota adapter.SavedQuota
+			if savedQuota.UnmarshalBinary(v) == nil {
+				quotas[string(k)] = &savedQuota
+			}
+			return nil
+		})
+	})
+	return quotas
+}
+
+func (c *CacheFile) StoreQuota(tag string, quotas map[string]*adapter.SavedQuota) error {
+	return c.DB.Batch(func(t *bbolt.Tx) error {
+		bucket, err := c.createBucket(t, bucketQuota)
+		if err != nil {
+			return err
+		}
+		bucket, err = bucket.CreateBucketIfNotExists([]byte(tag))
+		if err != nil {
+			return err
+		}
+		for user, savedQuota := range quotas {
+			quotaBinary, err := savedQuota.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			err = bucket.Put([]byte(user), quotaBinary)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
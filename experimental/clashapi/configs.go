@@ -2,8 +2,12 @@ package clashapi
 
 import (
 	"net/http"
+	"os"
 
+	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -12,7 +16,7 @@ import (
 func configRouter(server *Server, logFactory log.Factory) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", getConfigs(server, logFactory))
-	r.Put("/", updateConfigs)
+	r.Put("/", updateConfigs(server))
 	r.Patch("/", patchConfigs(server))
 	return r
 }
@@ -66,6 +70,49 @@ func patchConfigs(server *Server) func(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func updateConfigs(w http.ResponseWriter, r *http.Request) {
-	render.NoContent(w, r)
+type updateConfigRequest struct {
+	Path    string `json:"path"`
+	Payload string `json:"payload"`
+}
+
+// updateConfigs reloads route rules, rule-sets and DNS rules from the given configuration,
+// other options are ignored.
+func updateConfigs(server *Server) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request updateConfigRequest
+		err := render.DecodeJSON(r.Body, &request)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		var content []byte
+		if request.Payload != "" {
+			content = []byte(request.Payload)
+		} else if request.Path != "" {
+			content, err = os.ReadFile(request.Path)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, newError(err.Error()))
+				return
+			}
+		} else {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		options, err := json.UnmarshalExtendedContext[option.Options](server.ctx, content)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		err = adapter.ReloadRules(server.router, options)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
 }
@@ -0,0 +1,73 @@
+package clashapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func quotaRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getQuotas(ctx))
+	r.Post("/{tag}/reset", resetQuota(ctx))
+	r.Post("/{tag}/{user}/reset", resetQuota(ctx))
+	return r
+}
+
+type Quota struct {
+	Tag       string     `json:"tag"`
+	User      string     `json:"user"`
+	Limit     uint64     `json:"limit"`
+	Used      uint64     `json:"used"`
+	LastReset time.Time  `json:"lastReset"`
+	NextReset *time.Time `json:"nextReset,omitempty"`
+}
+
+func getQuotas(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotaManager := service.FromContext[adapter.QuotaManager](ctx)
+		quotas := []Quota{}
+		if quotaManager != nil {
+			for _, status := range quotaManager.Quotas() {
+				quota := Quota{
+					Tag:       status.Tag,
+					User:      status.User,
+					Limit:     status.Limit,
+					Used:      status.Used,
+					LastReset: status.LastReset,
+				}
+				if !status.NextReset.IsZero() {
+					quota.NextReset = &status.NextReset
+				}
+				quotas = append(quotas, quota)
+			}
+		}
+		render.JSON(w, r, render.M{
+			"quotas": quotas,
+		})
+	}
+}
+
+func resetQuota(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotaManager := service.FromContext[adapter.QuotaManager](ctx)
+		if quotaManager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		err := quotaManager.ResetQuota(getEscapeParam(r, "tag"), getEscapeParam(r, "user"))
+		if err != nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
@@ -0,0 +1,70 @@
+package clashapi
+
+import (
+	"net/http"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func ruleSetRouter(router adapter.Router) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{tag}", getMutableRuleSet(router))
+	r.Patch("/{tag}", updateMutableRuleSet(router))
+	return r
+}
+
+type UpdateRuleSetRequest struct {
+	Add    adapter.MutableRuleSetContent `json:"add"`
+	Remove adapter.MutableRuleSetContent `json:"remove"`
+}
+
+func findMutableRuleSet(router adapter.Router, w http.ResponseWriter, r *http.Request) (adapter.MutableRuleSet, bool) {
+	ruleSet, loaded := router.RuleSet(getEscapeParam(r, "tag"))
+	if !loaded {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, ErrNotFound)
+		return nil, false
+	}
+	mutableRuleSet, isMutable := ruleSet.(adapter.MutableRuleSet)
+	if !isMutable {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError("rule-set is not mutable"))
+		return nil, false
+	}
+	return mutableRuleSet, true
+}
+
+func getMutableRuleSet(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ruleSet, loaded := findMutableRuleSet(router, w, r)
+		if !loaded {
+			return
+		}
+		render.JSON(w, r, ruleSet.Content())
+	}
+}
+
+func updateMutableRuleSet(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ruleSet, loaded := findMutableRuleSet(router, w, r)
+		if !loaded {
+			return
+		}
+		var req UpdateRuleSetRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		err := ruleSet.Update(req.Add, req.Remove)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
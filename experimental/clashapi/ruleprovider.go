@@ -1,58 +1,106 @@
 package clashapi
 
 import (
+	"context"
 	"net/http"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
-func ruleProviderRouter() http.Handler {
+func ruleProviderRouter(router adapter.Router) http.Handler {
 	r := chi.NewRouter()
-	r.Get("/", getRuleProviders)
+	r.Get("/", getRuleProviders(router))
 
 	r.Route("/{name}", func(r chi.Router) {
-		r.Use(parseProviderName, findRuleProviderByName)
+		r.Use(parseProviderName, findRuleProviderByName(router))
 		r.Get("/", getRuleProvider)
 		r.Put("/", updateRuleProvider)
 	})
 	return r
 }
 
-func getRuleProviders(w http.ResponseWriter, r *http.Request) {
-	render.JSON(w, r, render.M{
-		"providers": []string{},
-	})
+type RuleProvider struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	VehicleType string    `json:"vehicleType"`
+	Behavior    string    `json:"behavior"`
+	Format      string    `json:"format,omitempty"`
+	RuleCount   int       `json:"ruleCount"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func ruleProviderInfo(ruleSet adapter.RuleSet) RuleProvider {
+	status := ruleSet.Status()
+	provider := RuleProvider{
+		Name:      ruleSet.Name(),
+		Type:      "Rule",
+		Format:    status.Format,
+		RuleCount: status.RuleCount,
+		UpdatedAt: status.LastUpdated,
+	}
+	switch status.Type {
+	case C.RuleSetTypeRemote:
+		provider.VehicleType = "HTTP"
+	case C.RuleSetTypeLocal:
+		provider.VehicleType = "File"
+	default:
+		provider.VehicleType = "Inline"
+	}
+	switch status.Behavior {
+	case C.RuleSetBehaviorDomain:
+		provider.Behavior = "Domain"
+	case C.RuleSetBehaviorIPCIDR:
+		provider.Behavior = "IPCIDR"
+	default:
+		provider.Behavior = "Classical"
+	}
+	return provider
+}
+
+func getRuleProviders(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		providers := render.M{}
+		for _, ruleSet := range router.RuleSets() {
+			providers[ruleSet.Name()] = ruleProviderInfo(ruleSet)
+		}
+		render.JSON(w, r, render.M{
+			"providers": providers,
+		})
+	}
 }
 
 func getRuleProvider(w http.ResponseWriter, r *http.Request) {
-	// provider := r.Context().Value(CtxKeyProvider).(provider.RuleProvider)
-	// render.JSON(w, r, provider)
-	render.NoContent(w, r)
+	ruleSet := r.Context().Value(CtxKeyProvider).(adapter.RuleSet)
+	render.JSON(w, r, ruleProviderInfo(ruleSet))
 }
 
 func updateRuleProvider(w http.ResponseWriter, r *http.Request) {
-	/*provider := r.Context().Value(CtxKeyProvider).(provider.RuleProvider)
-	if err := provider.Update(); err != nil {
+	ruleSet := r.Context().Value(CtxKeyProvider).(adapter.RuleSet)
+	if err := ruleSet.Refresh(r.Context()); err != nil {
 		render.Status(r, http.StatusServiceUnavailable)
 		render.JSON(w, r, newError(err.Error()))
 		return
-	}*/
+	}
 	render.NoContent(w, r)
 }
 
-func findRuleProviderByName(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		/*name := r.Context().Value(CtxKeyProviderName).(string)
-		providers := tunnel.RuleProviders()
-		provider, exist := providers[name]
-		if !exist {*/
-		render.Status(r, http.StatusNotFound)
-		render.JSON(w, r, ErrNotFound)
-		//return
-		//}
-
-		// ctx := context.WithValue(r.Context(), CtxKeyProvider, provider)
-		// next.ServeHTTP(w, r.WithContext(ctx))
-	})
+func findRuleProviderByName(router adapter.Router) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.Context().Value(CtxKeyProviderName).(string)
+			ruleSet, loaded := router.RuleSet(name)
+			if !loaded {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrNotFound)
+				return
+			}
+			ctx := context.WithValue(r.Context(), CtxKeyProvider, ruleSet)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
@@ -9,32 +9,72 @@ import (
 	"github.com/go-chi/render"
 )
 
-func ruleRouter(router adapter.Router) http.Handler {
+func ruleRouter(router adapter.Router, dnsRouter adapter.DNSRouter) http.Handler {
 	r := chi.NewRouter()
-	r.Get("/", getRules(router))
+	r.Get("/", getRules(router, dnsRouter))
+	r.Post("/reset", resetRuleStatistics(router, dnsRouter))
 	return r
 }
 
 type Rule struct {
+	Type     string `json:"type"`
+	Payload  string `json:"payload"`
+	Proxy    string `json:"proxy"`
+	Matches  int64  `json:"matches"`
+	Upload   int64  `json:"upload"`
+	Download int64  `json:"download"`
+}
+
+type DNSRule struct {
 	Type    string `json:"type"`
 	Payload string `json:"payload"`
-	Proxy   string `json:"proxy"`
+	Action  string `json:"action"`
+	Matches int64  `json:"matches"`
 }
 
-func getRules(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+func getRules(router adapter.Router, dnsRouter adapter.DNSRouter) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rawRules := router.Rules()
+		rawRules, ruleStatistics := router.RulesWithStatistics()
 
 		var rules []Rule
-		for _, rule := range rawRules {
+		for i, rule := range rawRules {
+			statistics := ruleStatistics[i]
 			rules = append(rules, Rule{
+				Type:     rule.Type(),
+				Payload:  rule.String(),
+				Proxy:    rule.Action().String(),
+				Matches:  statistics.Matches.Load(),
+				Upload:   statistics.Upload.Load(),
+				Download: statistics.Download.Load(),
+			})
+		}
+		rawDNSRules, dnsRuleStatistics := dnsRouter.RulesWithStatistics()
+		var dnsRules []DNSRule
+		for i, rule := range rawDNSRules {
+			dnsRules = append(dnsRules, DNSRule{
 				Type:    rule.Type(),
 				Payload: rule.String(),
-				Proxy:   rule.Action().String(),
+				Action:  rule.Action().String(),
+				Matches: dnsRuleStatistics[i].Matches.Load(),
 			})
 		}
 		render.JSON(w, r, render.M{
-			"rules": rules,
+			"rules":    rules,
+			"dnsRules": dnsRules,
 		})
 	}
 }
+
+func resetRuleStatistics(router adapter.Router, dnsRouter adapter.DNSRouter) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, ruleStatistics := router.RulesWithStatistics()
+		for _, statistics := range ruleStatistics {
+			statistics.Reset()
+		}
+		_, dnsRuleStatistics := dnsRouter.RulesWithStatistics()
+		for _, statistics := range dnsRuleStatistics {
+			statistics.Reset()
+		}
+		render.NoContent(w, r)
+	}
+}
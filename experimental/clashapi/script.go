@@ -2,97 +2,155 @@ package clashapi
 
 import (
 	"net/http"
+	"net/netip"
+	"strconv"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/process"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
-func scriptRouter() http.Handler {
+func scriptRouter(router adapter.Router) http.Handler {
 	r := chi.NewRouter()
-	r.Post("/", testScript)
-	r.Patch("/", patchScript)
+	r.Post("/", testScript(router))
+	r.Patch("/", patchScript(router))
 	return r
 }
 
-/*type TestScriptRequest struct {
-	Script   *string    `json:"script"`
-	Metadata C.Metadata `json:"metadata"`
-}*/
+type TestScriptRequest struct {
+	Script   *string            `json:"script"`
+	Function string             `json:"function"`
+	Metadata TestScriptMetadata `json:"metadata"`
+}
+
+type TestScriptMetadata struct {
+	Network         string `json:"network"`
+	Type            string `json:"type"`
+	SourceIP        string `json:"sourceIP"`
+	DestinationIP   string `json:"destinationIP"`
+	SourcePort      string `json:"sourcePort"`
+	DestinationPort string `json:"destinationPort"`
+	Host            string `json:"host"`
+	ProcessPath     string `json:"processPath"`
+}
+
+func (m TestScriptMetadata) Build() (adapter.InboundContext, bool) {
+	var metadata adapter.InboundContext
+	switch m.Network {
+	case "", N.NetworkTCP:
+		metadata.Network = N.NetworkTCP
+	case N.NetworkUDP:
+		metadata.Network = N.NetworkUDP
+	default:
+		return metadata, false
+	}
+	metadata.InboundType = m.Type
+	destinationPort, err := strconv.ParseUint(m.DestinationPort, 10, 16)
+	if err != nil {
+		return metadata, false
+	}
+	if m.Host != "" {
+		metadata.Destination = M.Socksaddr{Fqdn: m.Host, Port: uint16(destinationPort)}
+		metadata.Domain = m.Host
+	} else {
+		destinationIP, err := netip.ParseAddr(m.DestinationIP)
+		if err != nil {
+			return metadata, false
+		}
+		metadata.Destination = M.SocksaddrFrom(destinationIP, uint16(destinationPort))
+	}
+	if m.SourceIP != "" {
+		sourceIP, err := netip.ParseAddr(m.SourceIP)
+		if err != nil {
+			return metadata, false
+		}
+		var sourcePort uint64
+		if m.SourcePort != "" {
+			sourcePort, err = strconv.ParseUint(m.SourcePort, 10, 16)
+			if err != nil {
+				return metadata, false
+			}
+		}
+		metadata.Source = M.SocksaddrFrom(sourceIP, uint16(sourcePort))
+	}
+	if metadata.Destination.IsIPv4() {
+		metadata.IPVersion = 4
+	} else if metadata.Destination.IsIPv6() {
+		metadata.IPVersion = 6
+	}
+	if m.ProcessPath != "" {
+		metadata.ProcessInfo = &process.Info{ProcessPath: m.ProcessPath, UserId: -1}
+	}
+	return metadata, true
+}
 
-func testScript(w http.ResponseWriter, r *http.Request) {
-	/*	req := TestScriptRequest{}
+func testScript(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req TestScriptRequest
 		if err := render.DecodeJSON(r.Body, &req); err != nil {
 			render.Status(r, http.StatusBadRequest)
 			render.JSON(w, r, ErrBadRequest)
 			return
 		}
-
-		fn := tunnel.ScriptFn()
-		if req.Script == nil && fn == nil {
+		script := router.Script()
+		if req.Script == nil && script == nil {
 			render.Status(r, http.StatusBadRequest)
 			render.JSON(w, r, newError("should send `script`"))
 			return
 		}
-
-		if !req.Metadata.Valid() {
+		metadata, ok := req.Metadata.Build()
+		if !ok {
 			render.Status(r, http.StatusBadRequest)
 			render.JSON(w, r, newError("metadata not valid"))
 			return
 		}
-
 		if req.Script != nil {
 			var err error
-			fn, err = script.ParseScript(*req.Script)
+			script, err = router.CompileScript(*req.Script)
 			if err != nil {
 				render.Status(r, http.StatusBadRequest)
 				render.JSON(w, r, newError(err.Error()))
 				return
 			}
 		}
-
-		ctx, _ := script.MakeContext(tunnel.ProxyProviders(), tunnel.RuleProviders())
-
-		thread := &starlark.Thread{}
-		ret, err := starlark.Call(thread, fn, starlark.Tuple{ctx, script.MakeMetadata(&req.Metadata)}, nil)
+		function := req.Function
+		if function == "" {
+			function = "main"
+		}
+		result, err := script.Call(function, &metadata)
 		if err != nil {
 			render.Status(r, http.StatusBadRequest)
 			render.JSON(w, r, newError(err.Error()))
 			return
 		}
-
-		elm, ok := ret.(starlark.String)
-		if !ok {
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, "script fn must return a string")
-			return
-		}
-
 		render.JSON(w, r, render.M{
-			"result": string(elm),
-		})*/
-	render.Status(r, http.StatusBadRequest)
-	render.JSON(w, r, newError("not implemented"))
+			"result": result,
+		})
+	}
 }
 
 type PatchScriptRequest struct {
 	Script string `json:"script"`
 }
 
-func patchScript(w http.ResponseWriter, r *http.Request) {
-	/*req := PatchScriptRequest{}
-	if err := render.DecodeJSON(r.Body, &req); err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, ErrBadRequest)
-		return
-	}
-
-	fn, err := script.ParseScript(req.Script)
-	if err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, newError(err.Error()))
-		return
+func patchScript(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PatchScriptRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		err := router.UpdateScript(req.Script)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
 	}
-
-	tunnel.UpdateScript(fn)*/
-	render.NoContent(w, r)
 }
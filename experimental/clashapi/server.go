@@ -116,13 +116,15 @@ func NewServer(ctx context.Context, logFactory log.ObservableFactory, options op
 		r.Get("/version", version)
 		r.Mount("/configs", configRouter(s, logFactory))
 		r.Mount("/proxies", proxyRouter(s, s.router))
-		r.Mount("/rules", ruleRouter(s.router))
+		r.Mount("/rules", ruleRouter(s.router, s.dnsRouter))
 		r.Mount("/connections", connectionRouter(s.router, trafficManager))
 		r.Mount("/providers/proxies", proxyProviderRouter())
-		r.Mount("/providers/rules", ruleProviderRouter())
-		r.Mount("/script", scriptRouter())
+		r.Mount("/providers/rules", ruleProviderRouter(s.router))
+		r.Mount("/script", scriptRouter(s.router))
+		r.Mount("/ruleSets", ruleSetRouter(s.router))
 		r.Mount("/profile", profileRouter())
 		r.Mount("/cache", cacheRouter(ctx))
+		r.Mount("/quotas", quotaRouter(ctx))
 		r.Mount("/dns", dnsRouter(s.dnsRouter))
 
 		s.setupMetaAPI(r)
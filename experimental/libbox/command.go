@@ -17,4 +17,9 @@ const (
 	CommandConnections
 	CommandCloseConnection
 	CommandGetDeprecatedNotes
+	CommandGetRuleStatistics
+	CommandResetRuleStatistics
+	CommandReloadRules
+	CommandGetMutableRuleSet
+	CommandUpdateMutableRuleSet
 )
@@ -0,0 +1,174 @@
+package libbox
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/varbin"
+)
+
+const (
+	MutableRuleSetItemDomain       = "domain"
+	MutableRuleSetItemDomainSuffix = "domain_suffix"
+	MutableRuleSetItemIPCIDR       = "ip_cidr"
+)
+
+type MutableRuleSetItem struct {
+	Type  string
+	Value string
+}
+
+type MutableRuleSetItemIterator interface {
+	HasNext() bool
+	Next() *MutableRuleSetItem
+}
+
+func (c *CommandClient) GetMutableRuleSet(tag string) (MutableRuleSetItemIterator, error) {
+	conn, err := c.directConnect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	err = binary.Write(conn, binary.BigEndian, uint8(CommandGetMutableRuleSet))
+	if err != nil {
+		return nil, err
+	}
+	err = varbin.Write(conn, binary.BigEndian, tag)
+	if err != nil {
+		return nil, err
+	}
+	err = readError(conn)
+	if err != nil {
+		return nil, err
+	}
+	var items []MutableRuleSetItem
+	err = varbin.Read(conn, binary.BigEndian, &items)
+	if err != nil {
+		return nil, err
+	}
+	return newIterator(common.Map(items, func(it MutableRuleSetItem) *MutableRuleSetItem { return &it })), nil
+}
+
+func (c *CommandClient) AddMutableRuleSetItem(tag string, itemType string, value string) error {
+	return c.updateMutableRuleSet(tag, true, itemType, value)
+}
+
+func (c *CommandClient) RemoveMutableRuleSetItem(tag string, itemType string, value string) error {
+	return c.updateMutableRuleSet(tag, false, itemType, value)
+}
+
+func (c *CommandClient) updateMutableRuleSet(tag string, isAdd bool, itemType string, value string) error {
+	conn, err := c.directConnect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = binary.Write(conn, binary.BigEndian, uint8(CommandUpdateMutableRuleSet))
+	if err != nil {
+		return err
+	}
+	err = varbin.Write(conn, binary.BigEndian, tag)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(conn, binary.BigEndian, isAdd)
+	if err != nil {
+		return err
+	}
+	err = varbin.Write(conn, binary.BigEndian, itemType)
+	if err != nil {
+		return err
+	}
+	err = varbin.Write(conn, binary.BigEndian, value)
+	if err != nil {
+		return err
+	}
+	return readError(conn)
+}
+
+func (s *CommandServer) findMutableRuleSet(tag string) (adapter.MutableRuleSet, error) {
+	boxService := s.service
+	if boxService == nil {
+		return nil, E.New("service not ready")
+	}
+	ruleSet, loaded := boxService.instance.Router().RuleSet(tag)
+	if !loaded {
+		return nil, E.New("rule-set not found: ", tag)
+	}
+	mutableRuleSet, isMutable := ruleSet.(adapter.MutableRuleSet)
+	if !isMutable {
+		return nil, E.New("rule-set is not mutable: ", tag)
+	}
+	return mutableRuleSet, nil
+}
+
+func (s *CommandServer) handleGetMutableRuleSet(conn net.Conn) error {
+	tag, err := varbin.ReadValue[string](conn, binary.BigEndian)
+	if err != nil {
+		return err
+	}
+	ruleSet, err := s.findMutableRuleSet(tag)
+	if err != nil {
+		return writeError(conn, err)
+	}
+	content := ruleSet.Content()
+	var items []MutableRuleSetItem
+	for _, value := range content.Domain {
+		items = append(items, MutableRuleSetItem{MutableRuleSetItemDomain, value})
+	}
+	for _, value := range content.DomainSuffix {
+		items = append(items, MutableRuleSetItem{MutableRuleSetItemDomainSuffix, value})
+	}
+	for _, value := range content.IPCIDR {
+		items = append(items, MutableRuleSetItem{MutableRuleSetItemIPCIDR, value})
+	}
+	err = writeError(conn, nil)
+	if err != nil {
+		return err
+	}
+	return varbin.Write(conn, binary.BigEndian, items)
+}
+
+func (s *CommandServer) handleUpdateMutableRuleSet(conn net.Conn) error {
+	tag, err := varbin.ReadValue[string](conn, binary.BigEndian)
+	if err != nil {
+		return err
+	}
+	var isAdd bool
+	err = binary.Read(conn, binary.BigEndian, &isAdd)
+	if err != nil {
+		return err
+	}
+	itemType, err := varbin.ReadValue[string](conn, binary.BigEndian)
+	if err != nil {
+		return err
+	}
+	value, err := varbin.ReadValue[string](conn, binary.BigEndian)
+	if err != nil {
+		return err
+	}
+	ruleSet, err := s.findMutableRuleSet(tag)
+	if err != nil {
+		return writeError(conn, err)
+	}
+	var content adapter.MutableRuleSetContent
+	switch itemType {
+	case MutableRuleSetItemDomain:
+		content.Domain = []string{value}
+	case MutableRuleSetItemDomainSuffix:
+		content.DomainSuffix = []string{value}
+	case MutableRuleSetItemIPCIDR:
+		content.IPCIDR = []string{value}
+	default:
+		return writeError(conn, E.New("unknown rule-set item type: ", itemType))
+	}
+	if isAdd {
+		err = ruleSet.Update(content, adapter.MutableRuleSetContent{})
+	} else {
+		err = ruleSet.Update(adapter.MutableRuleSetContent{}, content)
+	}
+	return writeError(conn, err)
+}
@@ -0,0 +1,44 @@
+package libbox
+
+import (
+	"encoding/binary"
+	"net"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/varbin"
+)
+
+// ReloadRules replaces route rules, rule-sets and DNS rules of the running service
+// with those in the configuration, without restarting it.
+func (c *CommandClient) ReloadRules(configContent string) error {
+	conn, err := c.directConnect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = binary.Write(conn, binary.BigEndian, uint8(CommandReloadRules))
+	if err != nil {
+		return err
+	}
+	err = varbin.Write(conn, binary.BigEndian, configContent)
+	if err != nil {
+		return err
+	}
+	return readError(conn)
+}
+
+func (s *CommandServer) handleReloadRules(conn net.Conn) error {
+	configContent, err := varbin.ReadValue[string](conn, binary.BigEndian)
+	if err != nil {
+		return err
+	}
+	boxService := s.service
+	if boxService == nil {
+		return writeError(conn, E.New("service not ready"))
+	}
+	options, err := parseConfig(boxService.ctx, configContent)
+	if err != nil {
+		return writeError(conn, err)
+	}
+	return writeError(conn, boxService.instance.ReloadRules(options))
+}
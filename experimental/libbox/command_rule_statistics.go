@@ -0,0 +1,117 @@
+package libbox
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/varbin"
+	"github.com/sagernet/sing/service"
+)
+
+type RuleStatistics struct {
+	IsDNS    bool
+	Index    int32
+	Type     string
+	Payload  string
+	Action   string
+	Matches  int64
+	Upload   int64
+	Download int64
+}
+
+type RuleStatisticsIterator interface {
+	HasNext() bool
+	Next() *RuleStatistics
+}
+
+func (c *CommandClient) GetRuleStatistics() (RuleStatisticsIterator, error) {
+	conn, err := c.directConnect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	err = binary.Write(conn, binary.BigEndian, uint8(CommandGetRuleStatistics))
+	if err != nil {
+		return nil, err
+	}
+	err = readError(conn)
+	if err != nil {
+		return nil, err
+	}
+	var statistics []RuleStatistics
+	err = varbin.Read(conn, binary.BigEndian, &statistics)
+	if err != nil {
+		return nil, err
+	}
+	return newIterator(common.Map(statistics, func(it RuleStatistics) *RuleStatistics { return &it })), nil
+}
+
+func (s *CommandServer) handleGetRuleStatistics(conn net.Conn) error {
+	boxService := s.service
+	if boxService == nil {
+		return writeError(conn, E.New("service not ready"))
+	}
+	var statistics []RuleStatistics
+	router := boxService.instance.Router()
+	routeRules, routeRuleStatistics := router.RulesWithStatistics()
+	for i, rule := range routeRules {
+		statistics = append(statistics, RuleStatistics{
+			Index:    int32(i),
+			Type:     rule.Type(),
+			Payload:  rule.String(),
+			Action:   rule.Action().String(),
+			Matches:  routeRuleStatistics[i].Matches.Load(),
+			Upload:   routeRuleStatistics[i].Upload.Load(),
+			Download: routeRuleStatistics[i].Download.Load(),
+		})
+	}
+	dnsRouter := service.FromContext[adapter.DNSRouter](boxService.ctx)
+	dnsRules, dnsRuleStatistics := dnsRouter.RulesWithStatistics()
+	for i, rule := range dnsRules {
+		statistics = append(statistics, RuleStatistics{
+			IsDNS:   true,
+			Index:   int32(i),
+			Type:    rule.Type(),
+			Payload: rule.String(),
+			Action:  rule.Action().String(),
+			Matches: dnsRuleStatistics[i].Matches.Load(),
+		})
+	}
+	err := writeError(conn, nil)
+	if err != nil {
+		return err
+	}
+	return varbin.Write(conn, binary.BigEndian, statistics)
+}
+
+func (c *CommandClient) ResetRuleStatistics() error {
+	conn, err := c.directConnect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = binary.Write(conn, binary.BigEndian, uint8(CommandResetRuleStatistics))
+	if err != nil {
+		return err
+	}
+	return readError(conn)
+}
+
+func (s *CommandServer) handleResetRuleStatistics(conn net.Conn) error {
+	boxService := s.service
+	if boxService == nil {
+		return writeError(conn, E.New("service not ready"))
+	}
+	_, routeRuleStatistics := boxService.instance.Router().RulesWithStatistics()
+	for _, statistics := range routeRuleStatistics {
+		statistics.Reset()
+	}
+	_, dnsRuleStatistics := service.FromContext[adapter.DNSRouter](boxService.ctx).RulesWithStatistics()
+	for _, statistics := range dnsRuleStatistics {
+		statistics.Reset()
+	}
+	return writeError(conn, nil)
+}
@@ -176,6 +176,16 @@ func (s *CommandServer) handleConnection(conn net.Conn) error {
 		return s.handleCloseConnection(conn)
 	case CommandGetDeprecatedNotes:
 		return s.handleGetDeprecatedNotes(conn)
+	case CommandGetRuleStatistics:
+		return s.handleGetRuleStatistics(conn)
+	case CommandResetRuleStatistics:
+		return s.handleResetRuleStatistics(conn)
+	case CommandReloadRules:
+		return s.handleReloadRules(conn)
+	case CommandGetMutableRuleSet:
+		return s.handleGetMutableRuleSet(conn)
+	case CommandUpdateMutableRuleSet:
+		return s.handleUpdateMutableRuleSet(conn)
 	default:
 		return E.New("unknown command: ", command)
 	}
@@ -0,0 +1,87 @@
+package v2rayapi
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service"
+)
+
+// Quota counters are exposed as quota>>>[tag]>>>user>>>[user]>>>traffic>>>used and
+// quota>>>[tag]>>>user>>>[user]>>>traffic>>>limit.
+//
+// Only GetStats with reset resets the used counter, QueryStats never resets quotas.
+
+func quotaStatName(status adapter.QuotaStatus, name string) string {
+	return "quota>>>" + status.Tag + ">>>user>>>" + status.User + ">>>traffic>>>" + name
+}
+
+func (s *StatsService) getQuotaStats(request *GetStatsRequest) (*GetStatsResponse, error) {
+	quotaManager := service.FromContext[adapter.QuotaManager](s.ctx)
+	if quotaManager == nil {
+		return nil, E.New(request.Name, " not found.")
+	}
+	for _, status := range quotaManager.Quotas() {
+		var value int64
+		switch request.Name {
+		case quotaStatName(status, "used"):
+			value = int64(status.Used)
+			if request.Reset_ {
+				err := quotaManager.ResetQuota(status.Tag, status.User)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case quotaStatName(status, "limit"):
+			value = int64(status.Limit)
+		default:
+			continue
+		}
+		return &GetStatsResponse{Stat: &Stat{Name: request.Name, Value: value}}, nil
+	}
+	return nil, E.New(request.Name, " not found.")
+}
+
+func (s *StatsService) queryQuotaStats(request *QueryStatsRequest) ([]*Stat, error) {
+	quotaManager := service.FromContext[adapter.QuotaManager](s.ctx)
+	if quotaManager == nil {
+		return nil, nil
+	}
+	var matchers []*regexp.Regexp
+	if request.Regexp {
+		for _, pattern := range request.Patterns {
+			matcher, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, matcher)
+		}
+	}
+	match := func(name string) bool {
+		if len(request.Patterns) == 0 {
+			return true
+		}
+		for i, pattern := range request.Patterns {
+			if request.Regexp {
+				if matchers[i].MatchString(name) {
+					return true
+				}
+			} else if strings.Contains(name, pattern) {
+				return true
+			}
+		}
+		return false
+	}
+	var stats []*Stat
+	for _, status := range quotaManager.Quotas() {
+		if name := quotaStatName(status, "used"); match(name) {
+			stats = append(stats, &Stat{Name: name, Value: int64(status.Used)})
+		}
+		if name := quotaStatName(status, "limit"); match(name) {
+			stats = append(stats, &Stat{Name: name, Value: int64(status.Limit)})
+		}
+	}
+	return stats, nil
+}
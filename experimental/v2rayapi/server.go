@@ -1,6 +1,7 @@
 package v2rayapi
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/http"
@@ -29,9 +30,9 @@ type Server struct {
 	statsService *StatsService
 }
 
-func NewServer(logger log.Logger, options option.V2RayAPIOptions) (adapter.V2RayServer, error) {
+func NewServer(ctx context.Context, logger log.Logger, options option.V2RayAPIOptions) (adapter.V2RayServer, error) {
 	grpcServer := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
-	statsService := NewStatsService(common.PtrValueOrDefault(options.Stats))
+	statsService := NewStatsService(ctx, common.PtrValueOrDefault(options.Stats))
 	if statsService != nil {
 		RegisterStatsServiceServer(grpcServer, statsService)
 	}
@@ -13,7 +13,6 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common/atomic"
 	"github.com/sagernet/sing/common/bufio"
-	E "github.com/sagernet/sing/common/exceptions"
 	N "github.com/sagernet/sing/common/network"
 )
 
@@ -27,6 +26,7 @@ var (
 )
 
 type StatsService struct {
+	ctx       context.Context
 	createdAt time.Time
 	inbounds  map[string]bool
 	outbounds map[string]bool
@@ -35,7 +35,7 @@ type StatsService struct {
 	counters  map[string]*atomic.Int64
 }
 
-func NewStatsService(options option.V2RayStatsServiceOptions) *StatsService {
+func NewStatsService(ctx context.Context, options option.V2RayStatsServiceOptions) *StatsService {
 	if !options.Enabled {
 		return nil
 	}
@@ -52,6 +52,7 @@ func NewStatsService(options option.V2RayStatsServiceOptions) *StatsService {
 		users[user] = true
 	}
 	return &StatsService{
+		ctx:       ctx,
 		createdAt: time.Now(),
 		inbounds:  inbounds,
 		outbounds: outbounds,
@@ -123,7 +124,7 @@ func (s *StatsService) GetStats(ctx context.Context, request *GetStatsRequest) (
 	counter, loaded := s.counters[request.Name]
 	s.access.Unlock()
 	if !loaded {
-		return nil, E.New(request.Name, " not found.")
+		return s.getQuotaStats(request)
 	}
 	var value int64
 	if request.Reset_ {
@@ -136,6 +137,11 @@ func (s *StatsService) GetStats(ctx context.Context, request *GetStatsRequest) (
 
 func (s *StatsService) QueryStats(ctx context.Context, request *QueryStatsRequest) (*QueryStatsResponse, error) {
 	var response QueryStatsResponse
+	quotaStats, err := s.queryQuotaStats(request)
+	if err != nil {
+		return nil, err
+	}
+	response.Stat = quotaStats
 	s.access.Lock()
 	defer s.access.Unlock()
 	if len(request.Patterns) == 0 {
@@ -70,6 +70,7 @@ func OutboundRegistry() *outbound.Registry {
 
 	group.RegisterSelector(registry)
 	group.RegisterURLTest(registry)
+	group.RegisterRace(registry)
 
 	socks.RegisterOutbound(registry)
 	http.RegisterOutbound(registry)
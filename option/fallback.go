@@ -0,0 +1,20 @@
+package option
+
+import (
+	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+type InboundFallbackOptions struct {
+	ServerName    badoption.Listable[string] `json:"server_name,omitempty"`
+	ALPN          badoption.Listable[string] `json:"alpn,omitempty"`
+	Path          string                     `json:"path,omitempty"`
+	Inbound       string                     `json:"inbound,omitempty"`
+	Server        string                     `json:"server,omitempty"`
+	ServerPort    uint16                     `json:"server_port,omitempty"`
+	ProxyProtocol uint8                      `json:"proxy_protocol,omitempty"`
+}
+
+func (o InboundFallbackOptions) Build() M.Socksaddr {
+	return M.ParseSocksaddrHostPort(o.Server, o.ServerPort)
+}
@@ -16,3 +16,8 @@ type URLTestOutboundOptions struct {
 	IdleTimeout               badoption.Duration `json:"idle_timeout,omitempty"`
 	InterruptExistConnections bool               `json:"interrupt_exist_connections,omitempty"`
 }
+
+type RaceOutboundOptions struct {
+	Outbounds    []string           `json:"outbounds"`
+	StaggerDelay badoption.Duration `json:"stagger_delay,omitempty"`
+}
@@ -0,0 +1,13 @@
+package option
+
+import "github.com/sagernet/sing/common/json/badoption"
+
+type QuotaOptions struct {
+	Tag      string                     `json:"tag"`
+	Users    badoption.Listable[string] `json:"users,omitempty"`
+	Limit    string                     `json:"limit"`
+	Reset    string                     `json:"reset,omitempty"`
+	ResetDay int                        `json:"reset_day,omitempty"`
+	Timezone string                     `json:"timezone,omitempty"`
+	Outbound string                     `json:"outbound,omitempty"`
+}
@@ -3,21 +3,25 @@ package option
 import "github.com/sagernet/sing/common/json/badoption"
 
 type RouteOptions struct {
-	GeoIP                      *GeoIPOptions                     `json:"geoip,omitempty"`
-	Geosite                    *GeositeOptions                   `json:"geosite,omitempty"`
-	Rules                      []Rule                            `json:"rules,omitempty"`
-	RuleSet                    []RuleSet                         `json:"rule_set,omitempty"`
-	Final                      string                            `json:"final,omitempty"`
-	FindProcess                bool                              `json:"find_process,omitempty"`
-	AutoDetectInterface        bool                              `json:"auto_detect_interface,omitempty"`
-	OverrideAndroidVPN         bool                              `json:"override_android_vpn,omitempty"`
-	DefaultInterface           string                            `json:"default_interface,omitempty"`
-	DefaultMark                FwMark                            `json:"default_mark,omitempty"`
-	DefaultDomainResolver      *DomainResolveOptions             `json:"default_domain_resolver,omitempty"`
-	DefaultNetworkStrategy     *NetworkStrategy                  `json:"default_network_strategy,omitempty"`
-	DefaultNetworkType         badoption.Listable[InterfaceType] `json:"default_network_type,omitempty"`
-	DefaultFallbackNetworkType badoption.Listable[InterfaceType] `json:"default_fallback_network_type,omitempty"`
-	DefaultFallbackDelay       badoption.Duration                `json:"default_fallback_delay,omitempty"`
+	GeoIP                      *GeoIPOptions                         `json:"geoip,omitempty"`
+	Geosite                    *GeositeOptions                       `json:"geosite,omitempty"`
+	ASN                        *ASNOptions                           `json:"asn,omitempty"`
+	Rules                      []Rule                                `json:"rules,omitempty"`
+	RuleSet                    []RuleSet                             `json:"rule_set,omitempty"`
+	Quotas                     []QuotaOptions                        `json:"quotas,omitempty"`
+	Script                     *ScriptOptions                        `json:"script,omitempty"`
+	Final                      string                                `json:"final,omitempty"`
+	FindProcess                bool                                  `json:"find_process,omitempty"`
+	DeviceAlias                map[string]badoption.Listable[string] `json:"device_alias,omitempty"`
+	AutoDetectInterface        bool                                  `json:"auto_detect_interface,omitempty"`
+	OverrideAndroidVPN         bool                                  `json:"override_android_vpn,omitempty"`
+	DefaultInterface           string                                `json:"default_interface,omitempty"`
+	DefaultMark                FwMark                                `json:"default_mark,omitempty"`
+	DefaultDomainResolver      *DomainResolveOptions                 `json:"default_domain_resolver,omitempty"`
+	DefaultNetworkStrategy     *NetworkStrategy                      `json:"default_network_strategy,omitempty"`
+	DefaultNetworkType         badoption.Listable[InterfaceType]     `json:"default_network_type,omitempty"`
+	DefaultFallbackNetworkType badoption.Listable[InterfaceType]     `json:"default_fallback_network_type,omitempty"`
+	DefaultFallbackDelay       badoption.Duration                    `json:"default_fallback_delay,omitempty"`
 }
 
 type GeoIPOptions struct {
@@ -31,3 +35,13 @@ type GeositeOptions struct {
 	DownloadURL    string `json:"download_url,omitempty"`
 	DownloadDetour string `json:"download_detour,omitempty"`
 }
+
+type ASNOptions struct {
+	Path string `json:"path,omitempty"`
+}
+
+type ScriptOptions struct {
+	Path    string                     `json:"path,omitempty"`
+	Content string                     `json:"content,omitempty"`
+	RuleSet badoption.Listable[string] `json:"rule_set,omitempty"`
+}
@@ -73,6 +73,11 @@ type RawDefaultRule struct {
 	AuthUser                 badoption.Listable[string]        `json:"auth_user,omitempty"`
 	Protocol                 badoption.Listable[string]        `json:"protocol,omitempty"`
 	Client                   badoption.Listable[string]        `json:"client,omitempty"`
+	ALPN                     badoption.Listable[string]        `json:"alpn,omitempty"`
+	TLSECH                   bool                              `json:"tls_ech,omitempty"`
+	HTTPMethod               badoption.Listable[string]        `json:"http_method,omitempty"`
+	HTTPPathRegex            badoption.Listable[string]        `json:"http_path_regex,omitempty"`
+	HTTPUserAgent            badoption.Listable[string]        `json:"http_user_agent,omitempty"`
 	Domain                   badoption.Listable[string]        `json:"domain,omitempty"`
 	DomainSuffix             badoption.Listable[string]        `json:"domain_suffix,omitempty"`
 	DomainKeyword            badoption.Listable[string]        `json:"domain_keyword,omitempty"`
@@ -82,15 +87,23 @@ type RawDefaultRule struct {
 	GeoIP                    badoption.Listable[string]        `json:"geoip,omitempty"`
 	SourceIPCIDR             badoption.Listable[string]        `json:"source_ip_cidr,omitempty"`
 	SourceIPIsPrivate        bool                              `json:"source_ip_is_private,omitempty"`
+	SourceIPASN              badoption.Listable[string]        `json:"source_ip_asn,omitempty"`
 	IPCIDR                   badoption.Listable[string]        `json:"ip_cidr,omitempty"`
 	IPIsPrivate              bool                              `json:"ip_is_private,omitempty"`
+	IPASN                    badoption.Listable[string]        `json:"ip_asn,omitempty"`
 	SourcePort               badoption.Listable[uint16]        `json:"source_port,omitempty"`
 	SourcePortRange          badoption.Listable[string]        `json:"source_port_range,omitempty"`
+	SourceMACAddress         badoption.Listable[string]        `json:"source_mac_address,omitempty"`
 	Port                     badoption.Listable[uint16]        `json:"port,omitempty"`
 	PortRange                badoption.Listable[string]        `json:"port_range,omitempty"`
 	ProcessName              badoption.Listable[string]        `json:"process_name,omitempty"`
 	ProcessPath              badoption.Listable[string]        `json:"process_path,omitempty"`
 	ProcessPathRegex         badoption.Listable[string]        `json:"process_path_regex,omitempty"`
+	ProcessParentName        badoption.Listable[string]        `json:"process_parent_name,omitempty"`
+	ProcessAncestorPath      badoption.Listable[string]        `json:"process_ancestor_path,omitempty"`
+	ProcessCmdlineRegex      badoption.Listable[string]        `json:"process_cmdline_regex,omitempty"`
+	ProcessCgroup            badoption.Listable[string]        `json:"process_cgroup,omitempty"`
+	ProcessCgroupRegex       badoption.Listable[string]        `json:"process_cgroup_regex,omitempty"`
 	PackageName              badoption.Listable[string]        `json:"package_name,omitempty"`
 	User                     badoption.Listable[string]        `json:"user,omitempty"`
 	UserID                   badoption.Listable[int32]         `json:"user_id,omitempty"`
@@ -100,8 +113,12 @@ type RawDefaultRule struct {
 	NetworkIsConstrained     bool                              `json:"network_is_constrained,omitempty"`
 	WIFISSID                 badoption.Listable[string]        `json:"wifi_ssid,omitempty"`
 	WIFIBSSID                badoption.Listable[string]        `json:"wifi_bssid,omitempty"`
+	TimeRange                badoption.Listable[string]        `json:"time_range,omitempty"`
+	Weekday                  badoption.Listable[string]        `json:"weekday,omitempty"`
+	Timezone                 string                            `json:"timezone,omitempty"`
 	RuleSet                  badoption.Listable[string]        `json:"rule_set,omitempty"`
 	RuleSetIPCIDRMatchSource bool                              `json:"rule_set_ip_cidr_match_source,omitempty"`
+	Script                   badoption.Listable[string]        `json:"script,omitempty"`
 	Invert                   bool                              `json:"invert,omitempty"`
 
 	// Deprecated: renamed to rule_set_ip_cidr_match_source
@@ -21,6 +21,7 @@ type _RuleAction struct {
 	RejectOptions       RejectActionOptions       `json:"-"`
 	SniffOptions        RouteActionSniff          `json:"-"`
 	ResolveOptions      RouteActionResolve        `json:"-"`
+	ScriptOptions       RouteActionScript         `json:"-"`
 }
 
 type RuleAction _RuleAction
@@ -46,6 +47,8 @@ func (r RuleAction) MarshalJSON() ([]byte, error) {
 		v = r.SniffOptions
 	case C.RuleActionTypeResolve:
 		v = r.ResolveOptions
+	case C.RuleActionTypeScript:
+		v = r.ScriptOptions
 	default:
 		return nil, E.New("unknown rule action: " + r.Action)
 	}
@@ -77,6 +80,8 @@ func (r *RuleAction) UnmarshalJSON(data []byte) error {
 		v = &r.SniffOptions
 	case C.RuleActionTypeResolve:
 		v = &r.ResolveOptions
+	case C.RuleActionTypeScript:
+		v = &r.ScriptOptions
 	default:
 		return E.New("unknown rule action: " + r.Action)
 	}
@@ -153,6 +158,18 @@ type RawRouteOptionsActionOptions struct {
 
 	TLSFragment              bool               `json:"tls_fragment,omitempty"`
 	TLSFragmentFallbackDelay badoption.Duration `json:"tls_fragment_fallback_delay,omitempty"`
+
+	QUICFragment        bool `json:"quic_fragment,omitempty"`
+	QUICFragmentReorder bool `json:"quic_fragment_reorder,omitempty"`
+	QUICFragmentPadding bool `json:"quic_fragment_padding,omitempty"`
+
+	HTTPHostSplit      bool `json:"http_host_split,omitempty"`
+	HTTPHostMixCase    bool `json:"http_host_mix_case,omitempty"`
+	HTTPHostExtraSpace bool `json:"http_host_extra_space,omitempty"`
+
+	LimitUploadMbps   int    `json:"limit_upload_mbps,omitempty"`
+	LimitDownloadMbps int    `json:"limit_download_mbps,omitempty"`
+	LimitKey          string `json:"limit_key,omitempty"`
 }
 
 type RouteOptionsActionOptions RawRouteOptionsActionOptions
@@ -174,6 +191,7 @@ type DNSRouteActionOptions struct {
 	DisableCache bool                  `json:"disable_cache,omitempty"`
 	RewriteTTL   *uint32               `json:"rewrite_ttl,omitempty"`
 	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
+	DNS64Prefix  *badoption.Prefix     `json:"dns64_prefix,omitempty"`
 }
 
 type _DNSRouteOptionsActionOptions struct {
@@ -181,6 +199,7 @@ type _DNSRouteOptionsActionOptions struct {
 	DisableCache bool                  `json:"disable_cache,omitempty"`
 	RewriteTTL   *uint32               `json:"rewrite_ttl,omitempty"`
 	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
+	DNS64Prefix  *badoption.Prefix     `json:"dns64_prefix,omitempty"`
 }
 
 type DNSRouteOptionsActionOptions _DNSRouteOptionsActionOptions
@@ -280,6 +299,11 @@ type RouteActionSniff struct {
 }
 
 type RouteActionResolve struct {
-	Strategy DomainStrategy `json:"strategy,omitempty"`
-	Server   string         `json:"server,omitempty"`
+	Strategy    DomainStrategy    `json:"strategy,omitempty"`
+	Server      string            `json:"server,omitempty"`
+	NAT64Prefix *badoption.Prefix `json:"nat64_prefix,omitempty"`
+}
+
+type RouteActionScript struct {
+	Function string `json:"function,omitempty"`
 }
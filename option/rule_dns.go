@@ -84,8 +84,10 @@ type RawDefaultDNSRule struct {
 	IPCIDR                   badoption.Listable[string]        `json:"ip_cidr,omitempty"`
 	IPIsPrivate              bool                              `json:"ip_is_private,omitempty"`
 	IPAcceptAny              bool                              `json:"ip_accept_any,omitempty"`
+	IPASN                    badoption.Listable[string]        `json:"ip_asn,omitempty"`
 	SourceIPCIDR             badoption.Listable[string]        `json:"source_ip_cidr,omitempty"`
 	SourceIPIsPrivate        bool                              `json:"source_ip_is_private,omitempty"`
+	SourceIPASN              badoption.Listable[string]        `json:"source_ip_asn,omitempty"`
 	SourcePort               badoption.Listable[uint16]        `json:"source_port,omitempty"`
 	SourcePortRange          badoption.Listable[string]        `json:"source_port_range,omitempty"`
 	Port                     badoption.Listable[uint16]        `json:"port,omitempty"`
@@ -93,6 +95,11 @@ type RawDefaultDNSRule struct {
 	ProcessName              badoption.Listable[string]        `json:"process_name,omitempty"`
 	ProcessPath              badoption.Listable[string]        `json:"process_path,omitempty"`
 	ProcessPathRegex         badoption.Listable[string]        `json:"process_path_regex,omitempty"`
+	ProcessParentName        badoption.Listable[string]        `json:"process_parent_name,omitempty"`
+	ProcessAncestorPath      badoption.Listable[string]        `json:"process_ancestor_path,omitempty"`
+	ProcessCmdlineRegex      badoption.Listable[string]        `json:"process_cmdline_regex,omitempty"`
+	ProcessCgroup            badoption.Listable[string]        `json:"process_cgroup,omitempty"`
+	ProcessCgroupRegex       badoption.Listable[string]        `json:"process_cgroup_regex,omitempty"`
 	PackageName              badoption.Listable[string]        `json:"package_name,omitempty"`
 	User                     badoption.Listable[string]        `json:"user,omitempty"`
 	UserID                   badoption.Listable[int32]         `json:"user_id,omitempty"`
@@ -103,6 +110,9 @@ type RawDefaultDNSRule struct {
 	NetworkIsConstrained     bool                              `json:"network_is_constrained,omitempty"`
 	WIFISSID                 badoption.Listable[string]        `json:"wifi_ssid,omitempty"`
 	WIFIBSSID                badoption.Listable[string]        `json:"wifi_bssid,omitempty"`
+	TimeRange                badoption.Listable[string]        `json:"time_range,omitempty"`
+	Weekday                  badoption.Listable[string]        `json:"weekday,omitempty"`
+	Timezone                 string                            `json:"timezone,omitempty"`
 	RuleSet                  badoption.Listable[string]        `json:"rule_set,omitempty"`
 	RuleSetIPCIDRMatchSource bool                              `json:"rule_set_ip_cidr_match_source,omitempty"`
 	RuleSetIPCIDRAcceptEmpty bool                              `json:"rule_set_ip_cidr_accept_empty,omitempty"`
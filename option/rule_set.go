@@ -3,6 +3,7 @@ package option
 import (
 	"reflect"
 
+	"github.com/sagernet/sing-box/common/srs/mapped"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/domain"
@@ -19,6 +20,7 @@ type _RuleSet struct {
 	Type          string        `json:"type,omitempty"`
 	Tag           string        `json:"tag"`
 	Format        string        `json:"format,omitempty"`
+	Behavior      string        `json:"behavior,omitempty"`
 	InlineOptions PlainRuleSet  `json:"-"`
 	LocalOptions  LocalRuleSet  `json:"-"`
 	RemoteOptions RemoteRuleSet `json:"-"`
@@ -36,6 +38,8 @@ func (r RuleSet) MarshalJSON() ([]byte, error) {
 		v = r.LocalOptions
 	case C.RuleSetTypeRemote:
 		v = r.RemoteOptions
+	case C.RuleSetTypeMutable:
+		return json.Marshal((_RuleSet)(r))
 	default:
 		return nil, E.New("unknown rule-set type: " + r.Type)
 	}
@@ -59,6 +63,12 @@ func (r *RuleSet) UnmarshalJSON(bytes []byte) error {
 		v = &r.LocalOptions
 	case C.RuleSetTypeRemote:
 		v = &r.RemoteOptions
+	case C.RuleSetTypeMutable:
+		if r.Format != "" || r.Behavior != "" {
+			return E.New("format is not supported by mutable rule-set")
+		}
+		// check unknown fields
+		return json.UnmarshalDisallowUnknownFields(bytes, &_RuleSet{})
 	default:
 		return E.New("unknown rule-set type: " + r.Type)
 	}
@@ -67,11 +77,23 @@ func (r *RuleSet) UnmarshalJSON(bytes []byte) error {
 		case "":
 			return E.New("missing format")
 		case C.RuleSetFormatSource, C.RuleSetFormatBinary:
+		case C.RuleSetFormatClash:
+			switch r.Behavior {
+			case "":
+				return E.New("missing behavior")
+			case C.RuleSetBehaviorDomain, C.RuleSetBehaviorIPCIDR, C.RuleSetBehaviorClassical:
+			default:
+				return E.New("unknown rule-set behavior: " + r.Behavior)
+			}
 		default:
 			return E.New("unknown rule-set format: " + r.Format)
 		}
+		if r.Format != C.RuleSetFormatClash && r.Behavior != "" {
+			return E.New("behavior is only supported by clash format")
+		}
 	} else {
 		r.Format = ""
+		r.Behavior = ""
 	}
 	err = badjson.UnmarshallExcluded(bytes, (*_RuleSet)(r), v)
 	if err != nil {
@@ -173,6 +195,10 @@ type DefaultHeadlessRule struct {
 	SourceIPSet   *netipx.IPSet   `json:"-"`
 	IPSet         *netipx.IPSet   `json:"-"`
 
+	MappedDomainSet   *mapped.DomainSet `json:"-"`
+	MappedSourceIPSet *mapped.IPSet     `json:"-"`
+	MappedIPSet       *mapped.IPSet     `json:"-"`
+
 	AdGuardDomain        badoption.Listable[string] `json:"-"`
 	AdGuardDomainMatcher *domain.AdGuardMatcher     `json:"-"`
 }
@@ -204,7 +230,7 @@ type PlainRuleSetCompat _PlainRuleSetCompat
 func (r PlainRuleSetCompat) MarshalJSON() ([]byte, error) {
 	var v any
 	switch r.Version {
-	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3:
+	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4:
 		v = r.Options
 	default:
 		return nil, E.New("unknown rule-set version: ", r.Version)
@@ -219,7 +245,7 @@ func (r *PlainRuleSetCompat) UnmarshalJSON(bytes []byte) error {
 	}
 	var v any
 	switch r.Version {
-	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3:
+	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4:
 		v = &r.Options
 	case 0:
 		return E.New("missing rule-set version")
@@ -236,7 +262,7 @@ func (r *PlainRuleSetCompat) UnmarshalJSON(bytes []byte) error {
 
 func (r PlainRuleSetCompat) Upgrade() (PlainRuleSet, error) {
 	switch r.Version {
-	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3:
+	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4:
 	default:
 		return PlainRuleSet{}, E.New("unknown rule-set version: " + F.ToString(r.Version))
 	}
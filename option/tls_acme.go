@@ -31,6 +31,7 @@ type _ACMEDNS01ChallengeOptions struct {
 	Provider          string                     `json:"provider,omitempty"`
 	AliDNSOptions     ACMEDNS01AliDNSOptions     `json:"-"`
 	CloudflareOptions ACMEDNS01CloudflareOptions `json:"-"`
+	RFC2136Options    ACMEDNS01RFC2136Options    `json:"-"`
 }
 
 type ACMEDNS01ChallengeOptions _ACMEDNS01ChallengeOptions
@@ -42,6 +43,8 @@ func (o ACMEDNS01ChallengeOptions) MarshalJSON() ([]byte, error) {
 		v = o.AliDNSOptions
 	case C.DNSProviderCloudflare:
 		v = o.CloudflareOptions
+	case C.DNSProviderRFC2136:
+		v = o.RFC2136Options
 	case "":
 		return nil, E.New("missing provider type")
 	default:
@@ -61,6 +64,8 @@ func (o *ACMEDNS01ChallengeOptions) UnmarshalJSON(bytes []byte) error {
 		v = &o.AliDNSOptions
 	case C.DNSProviderCloudflare:
 		v = &o.CloudflareOptions
+	case C.DNSProviderRFC2136:
+		v = &o.RFC2136Options
 	default:
 		return E.New("unknown provider type: " + o.Provider)
 	}
@@ -80,3 +85,10 @@ type ACMEDNS01AliDNSOptions struct {
 type ACMEDNS01CloudflareOptions struct {
 	APIToken string `json:"api_token,omitempty"`
 }
+
+type ACMEDNS01RFC2136Options struct {
+	Server       string `json:"server,omitempty"`
+	KeyName      string `json:"key_name,omitempty"`
+	KeyAlgorithm string `json:"key_algorithm,omitempty"`
+	Key          string `json:"key,omitempty"`
+}
@@ -0,0 +1,190 @@
+package group
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+func RegisterRace(registry *outbound.Registry) {
+	outbound.Register[option.RaceOutboundOptions](registry, C.TypeRace, NewRace)
+}
+
+var _ adapter.OutboundGroup = (*Race)(nil)
+
+// Race dials through all outbounds in parallel, starting each one after a stagger delay,
+// and keeps the first connection established.
+type Race struct {
+	outbound.Adapter
+	outbound     adapter.OutboundManager
+	logger       log.ContextLogger
+	tags         []string
+	staggerDelay time.Duration
+	outbounds    []adapter.Outbound
+	selected     atomic.TypedValue[adapter.Outbound]
+}
+
+func NewRace(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.RaceOutboundOptions) (adapter.Outbound, error) {
+	outbound := &Race{
+		Adapter:      outbound.NewAdapter(C.TypeRace, tag, []string{N.NetworkTCP, N.NetworkUDP}, options.Outbounds),
+		outbound:     service.FromContext[adapter.OutboundManager](ctx),
+		logger:       logger,
+		tags:         options.Outbounds,
+		staggerDelay: time.Duration(options.StaggerDelay),
+	}
+	if len(outbound.tags) == 0 {
+		return nil, E.New("missing tags")
+	}
+	if outbound.staggerDelay == 0 {
+		outbound.staggerDelay = N.DefaultFallbackDelay
+	}
+	return outbound, nil
+}
+
+func (s *Race) Start() error {
+	for i, tag := range s.tags {
+		detour, loaded := s.outbound.Outbound(tag)
+		if !loaded {
+			return E.New("outbound ", i, " not found: ", tag)
+		}
+		s.outbounds = append(s.outbounds, detour)
+	}
+	return nil
+}
+
+// Now returns the outbound that won the last race.
+func (s *Race) Now() string {
+	selected := s.selected.Load()
+	if selected == nil {
+		return s.tags[0]
+	}
+	return selected.Tag()
+}
+
+func (s *Race) All() []string {
+	return s.tags
+}
+
+func (s *Race) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	outbounds, err := s.outboundsFor(N.NetworkName(network))
+	if err != nil {
+		return nil, err
+	}
+	conn, detour, err := raceDial(ctx, outbounds, s.staggerDelay, func(ctx context.Context, detour adapter.Outbound) (net.Conn, error) {
+		return detour.DialContext(ctx, network, destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.selected.Store(detour)
+	s.logger.DebugContext(ctx, "race won by ", detour.Tag())
+	return conn, nil
+}
+
+func (s *Race) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	outbounds, err := s.outboundsFor(N.NetworkUDP)
+	if err != nil {
+		return nil, err
+	}
+	conn, detour, err := raceDial(ctx, outbounds, s.staggerDelay, func(ctx context.Context, detour adapter.Outbound) (net.PacketConn, error) {
+		return detour.ListenPacket(ctx, destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.selected.Store(detour)
+	s.logger.DebugContext(ctx, "race won by ", detour.Tag())
+	return conn, nil
+}
+
+func (s *Race) outboundsFor(network string) ([]adapter.Outbound, error) {
+	outbounds := common.Filter(s.outbounds, func(detour adapter.Outbound) bool {
+		return common.Contains(detour.Network(), network)
+	})
+	if len(outbounds) == 0 {
+		return nil, E.New("missing supported outbound")
+	}
+	return outbounds, nil
+}
+
+// raceDial starts dial for each outbound in order, the next one after staggerDelay or as soon as
+// the previous one fails, and returns the first success while closing the later ones.
+// Each dial gets its own context, only the losing ones are canceled, since the winning
+// connection may keep using its dial context after return.
+func raceDial[T io.Closer](ctx context.Context, outbounds []adapter.Outbound, staggerDelay time.Duration, dial func(ctx context.Context, detour adapter.Outbound) (T, error)) (T, adapter.Outbound, error) {
+	type raceResult struct {
+		conn   T
+		index  int
+		detour adapter.Outbound
+		err    error
+	}
+	returned := make(chan struct{})
+	defer close(returned)
+	cancels := make([]context.CancelFunc, 0, len(outbounds))
+	winner := -1
+	defer func() {
+		for i, cancel := range cancels {
+			if i != winner {
+				cancel()
+			}
+		}
+	}()
+	results := make(chan raceResult) // unbuffered
+	startRacer := func() {
+		index := len(cancels)
+		detour := outbounds[index]
+		dialCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			conn, err := dial(dialCtx, detour)
+			select {
+			case results <- raceResult{conn, index, detour, err}:
+			case <-returned:
+				if err == nil {
+					conn.Close()
+				}
+				cancel()
+			}
+		}()
+	}
+	startRacer()
+	staggerTimer := time.NewTimer(staggerDelay)
+	defer staggerTimer.Stop()
+	var errors []error
+	for {
+		select {
+		case <-staggerTimer.C:
+			if len(cancels) < len(outbounds) {
+				startRacer()
+				staggerTimer.Reset(staggerDelay)
+			}
+		case result := <-results:
+			if result.err == nil {
+				winner = result.index
+				return result.conn, result.detour, nil
+			}
+			cancels[result.index]()
+			errors = append(errors, E.Cause(result.err, "race ", result.detour.Tag()))
+			if len(errors) == len(outbounds) {
+				var conn T
+				return conn, nil, E.Errors(errors...)
+			}
+			if len(cancels) < len(outbounds) && staggerTimer.Stop() {
+				staggerTimer.Reset(0)
+			}
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	"github.com/sagernet/sing-box/log"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type raceTestConn struct {
+	closed chan struct{}
+}
+
+func newRaceTestConn() *raceTestConn {
+	return &raceTestConn{closed: make(chan struct{})}
+}
+
+func (c *raceTestConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+type raceTestOutbound struct {
+	outbound.Adapter
+}
+
+func newRaceTestOutbounds(tags ...string) []adapter.Outbound {
+	outbounds := make([]adapter.Outbound, 0, len(tags))
+	for _, tag := range tags {
+		outbounds = append(outbounds, &raceTestOutbound{outbound.NewAdapter("test", tag, []string{N.NetworkTCP}, nil)})
+	}
+	return outbounds
+}
+
+func (o *raceTestOutbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	return nil, os.ErrInvalid
+}
+
+func (o *raceTestOutbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, os.ErrInvalid
+}
+
+// raceContextConn is bound to its dial context, like a stream request created with it.
+type raceContextConn struct {
+	net.Conn
+	ctx context.Context
+}
+
+func (c *raceContextConn) Write(p []byte) (int, error) {
+	if c.ctx.Err() != nil {
+		return 0, c.ctx.Err()
+	}
+	return len(p), nil
+}
+
+func (c *raceContextConn) Close() error {
+	return nil
+}
+
+type raceContextOutbound struct {
+	outbound.Adapter
+	delay   time.Duration
+	dialCtx chan context.Context
+}
+
+func (o *raceContextOutbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	o.dialCtx <- ctx
+	select {
+	case <-time.After(o.delay):
+		return &raceContextConn{ctx: ctx}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (o *raceContextOutbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, os.ErrInvalid
+}
+
+func TestRaceDialKeepsWinnerContext(t *testing.T) {
+	t.Parallel()
+	slow := &raceContextOutbound{outbound.NewAdapter("test", "slow", []string{N.NetworkTCP}, nil), time.Hour, make(chan context.Context, 1)}
+	fast := &raceContextOutbound{outbound.NewAdapter("test", "fast", []string{N.NetworkTCP}, nil), 0, make(chan context.Context, 1)}
+	race := &Race{
+		logger:       log.NewNOPFactory().Logger(),
+		tags:         []string{"slow", "fast"},
+		staggerDelay: 10 * time.Millisecond,
+		outbounds:    []adapter.Outbound{slow, fast},
+	}
+	conn, err := race.DialContext(context.Background(), N.NetworkTCP, M.ParseSocksaddrHostPort("example.org", 443))
+	require.NoError(t, err)
+	require.Equal(t, "fast", race.Now())
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, (<-fast.dialCtx).Err())
+	select {
+	case <-(<-slow.dialCtx).Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("losing dial not canceled")
+	}
+}
+
+func TestRaceDialFirstWins(t *testing.T) {
+	t.Parallel()
+	conn := newRaceTestConn()
+	dialed := make(chan string, 2)
+	result, detour, err := raceDial(context.Background(), newRaceTestOutbounds("a", "b"), time.Hour, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		dialed <- detour.Tag()
+		return conn, nil
+	})
+	require.NoError(t, err)
+	require.Same(t, conn, result)
+	require.Equal(t, "a", detour.Tag())
+	require.Equal(t, "a", <-dialed)
+	require.Empty(t, dialed)
+}
+
+func TestRaceDialFailureStartsNext(t *testing.T) {
+	t.Parallel()
+	conn := newRaceTestConn()
+	start := time.Now()
+	result, detour, err := raceDial(context.Background(), newRaceTestOutbounds("a", "b"), time.Hour, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		if detour.Tag() == "a" {
+			return nil, errors.New("failed")
+		}
+		return conn, nil
+	})
+	require.NoError(t, err)
+	require.Same(t, conn, result)
+	require.Equal(t, "b", detour.Tag())
+	require.Less(t, time.Since(start), time.Minute)
+}
+
+func TestRaceDialClosesLosers(t *testing.T) {
+	t.Parallel()
+	slowConn := newRaceTestConn()
+	fastConn := newRaceTestConn()
+	release := make(chan struct{})
+	result, detour, err := raceDial(context.Background(), newRaceTestOutbounds("slow", "fast"), 10*time.Millisecond, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		if detour.Tag() == "slow" {
+			<-release
+			return slowConn, nil
+		}
+		return fastConn, nil
+	})
+	require.NoError(t, err)
+	require.Same(t, fastConn, result)
+	require.Equal(t, "fast", detour.Tag())
+	close(release)
+	select {
+	case <-slowConn.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("losing connection not closed")
+	}
+	select {
+	case <-fastConn.closed:
+		t.Fatal("winning connection closed")
+	default:
+	}
+}
+
+func TestRaceDialCancelsLosers(t *testing.T) {
+	t.Parallel()
+	canceled := make(chan struct{})
+	_, detour, err := raceDial(context.Background(), newRaceTestOutbounds("slow", "fast"), 10*time.Millisecond, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		if detour.Tag() == "slow" {
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		}
+		return newRaceTestConn(), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "fast", detour.Tag())
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("losing dial not canceled")
+	}
+}
+
+func TestRaceDialAllFailed(t *testing.T) {
+	t.Parallel()
+	result, detour, err := raceDial(context.Background(), newRaceTestOutbounds("a", "b", "c"), time.Hour, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		return nil, errors.New("failed " + detour.Tag())
+	})
+	require.Error(t, err)
+	require.Nil(t, result)
+	require.Nil(t, detour)
+	for _, tag := range []string{"a", "b", "c"} {
+		require.Contains(t, err.Error(), "failed "+tag)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
 	"github.com/sagernet/sing-box/common/tls"
@@ -39,6 +40,7 @@ type Inbound struct {
 	tlsConfig                tls.ServerConfig
 	fallbackAddr             M.Socksaddr
 	fallbackAddrTLSNextProto map[string]M.Socksaddr
+	fallbacks                *fallback.Fallbacks
 	transport                adapter.V2RayServerTransport
 }
 
@@ -57,7 +59,7 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		inbound.tlsConfig = tlsConfig
 	}
 	var fallbackHandler N.TCPConnectionHandlerEx
-	if options.Fallback != nil && options.Fallback.Server != "" || len(options.FallbackForALPN) > 0 {
+	if options.Fallback != nil && options.Fallback.Server != "" || len(options.FallbackForALPN) > 0 || len(options.Fallbacks) > 0 {
 		if options.Fallback != nil && options.Fallback.Server != "" {
 			inbound.fallbackAddr = options.Fallback.Build()
 			if !inbound.fallbackAddr.IsValid() {
@@ -78,6 +80,11 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 			}
 			inbound.fallbackAddrTLSNextProto = fallbackAddrNextProto
 		}
+		fallbacks, err := fallback.New(options.Fallbacks, inbound.tlsConfig != nil)
+		if err != nil {
+			return nil, err
+		}
+		inbound.fallbacks = fallbacks
 		fallbackHandler = adapter.NewUpstreamContextHandlerEx(inbound.fallbackConnection, nil)
 	}
 	service := trojan.NewService[int](adapter.NewUpstreamContextHandlerEx(inbound.newConnection, inbound.newPacketConnection), fallbackHandler, logger)
@@ -212,6 +219,23 @@ func (h *Inbound) newPacketConnection(ctx context.Context, conn N.PacketConn, me
 }
 
 func (h *Inbound) fallbackConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	if h.fallbacks != nil {
+		matchedFallback, newConn, err := h.fallbacks.Match(conn)
+		conn = newConn
+		if err != nil {
+			N.CloseOnHandshakeFailure(conn, onClose, err)
+			h.logger.ErrorContext(ctx, E.Cause(err, "process fallback connection from ", metadata.Source))
+			return
+		}
+		if matchedFallback != nil {
+			metadata.Inbound = h.Tag()
+			metadata.InboundType = h.Type()
+			conn = matchedFallback.Apply(conn, &metadata)
+			h.logger.InfoContext(ctx, "fallback connection to ", matchedFallback)
+			h.router.RouteConnectionEx(ctx, conn, metadata, onClose)
+			return
+		}
+	}
 	var fallbackAddr M.Socksaddr
 	if len(h.fallbackAddrTLSNextProto) > 0 {
 		if tlsConn, loaded := common.Cast[tls.Conn](conn); loaded {
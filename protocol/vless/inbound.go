@@ -4,9 +4,11 @@ import (
 	"context"
 	"net"
 	"os"
+	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
 	"github.com/sagernet/sing-box/common/tls"
@@ -20,11 +22,15 @@ import (
 	"github.com/sagernet/sing-vmess/vless"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	F "github.com/sagernet/sing/common/format"
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
+
+	"github.com/gofrs/uuid/v5"
 )
 
 func RegisterInbound(registry *inbound.Registry) {
@@ -43,6 +49,8 @@ type Inbound struct {
 	service   *vless.Service[int]
 	tlsConfig tls.ServerConfig
 	transport adapter.V2RayServerTransport
+	fallbacks *fallback.Fallbacks
+	userIDs   map[[16]byte]bool
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.VLESSInboundOptions) (adapter.Inbound, error) {
@@ -79,6 +87,23 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 			return nil, E.Cause(err, "create server transport: ", options.Transport.Type)
 		}
 	}
+	inbound.fallbacks, err = fallback.New(options.Fallbacks, inbound.tlsConfig != nil)
+	if err != nil {
+		return nil, err
+	}
+	if inbound.fallbacks != nil {
+		if inbound.transport != nil {
+			return nil, E.New("fallbacks is not supported with transport")
+		}
+		inbound.userIDs = make(map[[16]byte]bool)
+		for _, user := range options.Users {
+			userID := uuid.FromStringOrNil(user.UUID)
+			if userID == uuid.Nil {
+				userID = uuid.NewV5(uuid.Nil, user.UUID)
+			}
+			inbound.userIDs[userID] = true
+		}
+	}
 	inbound.listener = listener.New(listener.Options{
 		Context:           ctx,
 		Logger:            logger,
@@ -148,6 +173,22 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata a
 		}
 		conn = tlsConn
 	}
+	if h.fallbacks != nil {
+		var (
+			isFallback bool
+			err        error
+		)
+		conn, isFallback, err = h.checkFallback(conn)
+		if err != nil {
+			N.CloseOnHandshakeFailure(conn, onClose, err)
+			h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", metadata.Source))
+			return
+		}
+		if isFallback {
+			h.fallbackConnection(ctx, conn, metadata, onClose)
+			return
+		}
+	}
 	err := h.service.NewConnection(adapter.WithContext(ctx, &metadata), conn, metadata.Source, onClose)
 	if err != nil {
 		N.CloseOnHandshakeFailure(conn, onClose, err)
@@ -197,6 +238,50 @@ func (h *Inbound) newPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 	h.router.RoutePacketConnectionEx(ctx, conn, metadata, onClose)
 }
 
+func (h *Inbound) checkFallback(conn net.Conn) (net.Conn, bool, error) {
+	err := conn.SetReadDeadline(time.Now().Add(C.TCPTimeout))
+	if err != nil {
+		return conn, false, err
+	}
+	header := buf.NewSize(1 + 16)
+	_, err = header.ReadFullFrom(conn, 1+16)
+	deadlineErr := conn.SetReadDeadline(time.Time{})
+	if err == nil && deadlineErr != nil {
+		header.Release()
+		return conn, false, deadlineErr
+	}
+	if err != nil && header.IsEmpty() {
+		header.Release()
+		return conn, false, err
+	}
+	var userID [16]byte
+	isFallback := err != nil || header.Byte(0) != vless.Version
+	if !isFallback {
+		copy(userID[:], header.From(1))
+		isFallback = !h.userIDs[userID]
+	}
+	return bufio.NewCachedConn(conn, header), isFallback, nil
+}
+
+func (h *Inbound) fallbackConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	matchedFallback, conn, err := h.fallbacks.Match(conn)
+	if err != nil {
+		N.CloseOnHandshakeFailure(conn, onClose, err)
+		h.logger.ErrorContext(ctx, E.Cause(err, "process fallback connection from ", metadata.Source))
+		return
+	}
+	if matchedFallback == nil {
+		h.logger.DebugContext(ctx, "process connection from ", metadata.Source, ": no matching fallback")
+		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
+		return
+	}
+	metadata.Inbound = h.Tag()
+	metadata.InboundType = h.Type()
+	conn = matchedFallback.Apply(conn, &metadata)
+	h.logger.InfoContext(ctx, "fallback connection to ", matchedFallback)
+	h.router.RouteConnectionEx(ctx, conn, metadata, onClose)
+}
+
 var _ adapter.V2RayServerTransportHandler = (*inboundTransportHandler)(nil)
 
 type inboundTransportHandler Inbound
@@ -91,6 +91,18 @@ func (m *ConnectionManager) NewConnection(ctx context.Context, this N.Dialer, co
 		}
 		remoteConn = newConn
 	}
+	if metadata.Protocol == C.ProtocolHTTP && (metadata.HTTPHostSplit || metadata.HTTPHostMixCase || metadata.HTTPHostExtraSpace) {
+		fallbackDelay := metadata.TLSFragmentFallbackDelay
+		if fallbackDelay == 0 {
+			fallbackDelay = C.TLSFragmentFallbackDelay
+		}
+		remoteConn = tf.NewHTTPConn(remoteConn, ctx, tf.HTTPOptions{
+			SplitHost:      metadata.HTTPHostSplit,
+			MixHostCase:    metadata.HTTPHostMixCase,
+			ExtraHostSpace: metadata.HTTPHostExtraSpace,
+			FallbackDelay:  fallbackDelay,
+		})
+	}
 	m.access.Lock()
 	element := m.connections.PushBack(conn)
 	m.access.Unlock()
@@ -158,6 +170,9 @@ func (m *ConnectionManager) NewPacketConnection(ctx context.Context, this N.Dial
 		m.logger.ErrorContext(ctx, "report handshake success: ", err)
 		return
 	}
+	if metadata.QUICFragment {
+		remotePacketConn = tf.NewQUICPacketConn(remotePacketConn, metadata.QUICFragmentReorder, metadata.QUICFragmentPadding)
+	}
 	if destinationAddress.IsValid() {
 		var originDestination M.Socksaddr
 		if metadata.RouteOriginalDestination.IsValid() {
@@ -0,0 +1,105 @@
+package route
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/neighbor"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+var _ neighbor.Resolver = (*testNeighborResolver)(nil)
+
+type testNeighborResolver map[netip.Addr]net.HardwareAddr
+
+func (r testNeighborResolver) Lookup(address netip.Addr) (net.HardwareAddr, bool) {
+	hardwareAddr, loaded := r[address.Unmap()]
+	return hardwareAddr, loaded
+}
+
+func (r testNeighborResolver) Close() error {
+	return nil
+}
+
+func newTestNeighborRouter(t *testing.T) *Router {
+	deviceAlias := map[string]badoption.Listable[string]{
+		"tv":     {"AA-BB-CC-00-00-01"},
+		"laptop": {"aa:bb:cc:00:00:02", "aa:bb:cc:00:00:03"},
+	}
+	deviceAliasMap, err := newDeviceAliasMap(deviceAlias)
+	require.NoError(t, err)
+	return &Router{
+		logger:         log.NewNOPFactory().Logger(),
+		deviceAlias:    deviceAlias,
+		deviceAliasMap: deviceAliasMap,
+		neighborResolver: testNeighborResolver{
+			netip.MustParseAddr("192.168.1.10"): mustParseMAC(t, "aa:bb:cc:00:00:01"),
+			netip.MustParseAddr("192.168.1.11"): mustParseMAC(t, "aa:bb:cc:00:00:03"),
+			netip.MustParseAddr("192.168.1.12"): mustParseMAC(t, "aa:bb:cc:00:00:04"),
+			netip.MustParseAddr("fe80::1"):      mustParseMAC(t, "aa:bb:cc:00:00:05"),
+		},
+	}
+}
+
+func mustParseMAC(t *testing.T, address string) net.HardwareAddr {
+	hardwareAddr, err := net.ParseMAC(address)
+	require.NoError(t, err)
+	return hardwareAddr
+}
+
+func lookupTestSource(router *Router, source string) *adapter.InboundContext {
+	metadata := &adapter.InboundContext{Source: M.ParseSocksaddrHostPort(source, 10000)}
+	router.lookupSourceDevice(context.Background(), metadata)
+	return metadata
+}
+
+func TestLookupSourceDevice(t *testing.T) {
+	t.Parallel()
+	router := newTestNeighborRouter(t)
+	metadata := lookupTestSource(router, "192.168.1.10")
+	require.Equal(t, "aa:bb:cc:00:00:01", metadata.SourceMACAddress.String())
+	require.Equal(t, "tv", metadata.SourceDevice)
+	metadata = lookupTestSource(router, "::ffff:192.168.1.11")
+	require.Equal(t, "laptop", metadata.SourceDevice)
+	metadata = lookupTestSource(router, "192.168.1.12")
+	require.Equal(t, "aa:bb:cc:00:00:04", metadata.SourceMACAddress.String())
+	require.Empty(t, metadata.SourceDevice)
+	metadata = lookupTestSource(router, "192.168.1.13")
+	require.Nil(t, metadata.SourceMACAddress)
+	require.Empty(t, metadata.SourceDevice)
+
+	_, err := newDeviceAliasMap(map[string]badoption.Listable[string]{"tv": {"not-a-mac"}})
+	require.ErrorContains(t, err, "parse device_alias[tv]")
+}
+
+func TestSourceMACAddressItem(t *testing.T) {
+	t.Parallel()
+	router := newTestNeighborRouter(t)
+	item, err := rule.NewSourceMACAddressItem(router, []string{"AA-BB-CC-00-00-04", "laptop"})
+	require.NoError(t, err)
+	require.Equal(t, "source_mac_address=[AA-BB-CC-00-00-04 laptop]", item.String())
+	require.True(t, item.Match(lookupTestSource(router, "192.168.1.12")))
+	require.True(t, item.Match(lookupTestSource(router, "192.168.1.11")))
+	require.False(t, item.Match(lookupTestSource(router, "192.168.1.10")))
+	require.False(t, item.Match(lookupTestSource(router, "192.168.1.13")))
+	require.False(t, item.Match(lookupTestSource(router, "fe80::1")))
+
+	item, err = rule.NewSourceMACAddressItem(router, []string{"aa:bb:cc:00:00:05"})
+	require.NoError(t, err)
+	require.True(t, item.Match(lookupTestSource(router, "fe80::1")))
+
+	_, err = rule.NewSourceMACAddressItem(router, []string{"tv", "phone"})
+	require.ErrorContains(t, err, "unknown device alias: phone")
+	_, err = rule.NewSourceMACAddressItem(nil, []string{"tv"})
+	require.ErrorContains(t, err, "unknown device alias: tv")
+	_, err = rule.NewSourceMACAddressItem(router, []string{"aa:bb:cc:00:00"})
+	require.Error(t, err)
+}
@@ -0,0 +1,452 @@
+package route
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/humanize"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/service"
+)
+
+const quotaUpdateInterval = time.Minute
+
+var _ adapter.QuotaManager = (*QuotaManager)(nil)
+
+type QuotaManager struct {
+	ctx       context.Context
+	logger    logger.ContextLogger
+	quotas    []*quota
+	quotaMap  map[string]*quota
+	cacheFile adapter.CacheFile
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type quota struct {
+	tag      string
+	users    map[string]bool
+	limit    uint64
+	reset    string
+	resetDay int
+	location *time.Location
+	outbound string
+	access   sync.Mutex
+	counters map[string]*quotaCounter
+}
+
+type quotaCounter struct {
+	quota     *quota
+	used      atomic.Uint64
+	dirty     atomic.Bool
+	lastReset time.Time
+}
+
+func NewQuotaManager(ctx context.Context, logger logger.ContextLogger, options []option.QuotaOptions) (*QuotaManager, error) {
+	manager := &QuotaManager{
+		ctx:      ctx,
+		logger:   logger,
+		quotaMap: make(map[string]*quota),
+		done:     make(chan struct{}),
+	}
+	for i, quotaOptions := range options {
+		if quotaOptions.Tag == "" {
+			return nil, E.New("quota[", i, "]: missing tag")
+		}
+		if _, exists := manager.quotaMap[quotaOptions.Tag]; exists {
+			return nil, E.New("duplicate quota tag: ", quotaOptions.Tag)
+		}
+		limit, err := humanize.ParseBytes(quotaOptions.Limit)
+		if err != nil {
+			return nil, E.Cause(err, "quota[", quotaOptions.Tag, "]: parse limit")
+		}
+		if limit == 0 {
+			return nil, E.New("quota[", quotaOptions.Tag, "]: missing limit")
+		}
+		newQuota := &quota{
+			tag:      quotaOptions.Tag,
+			limit:    limit,
+			reset:    quotaOptions.Reset,
+			resetDay: quotaOptions.ResetDay,
+			location: time.Local,
+			outbound: quotaOptions.Outbound,
+			counters: make(map[string]*quotaCounter),
+		}
+		switch newQuota.reset {
+		case "":
+			newQuota.reset = C.QuotaResetNever
+		case C.QuotaResetNever, C.QuotaResetDaily:
+			if newQuota.resetDay != 0 {
+				return nil, E.New("quota[", quotaOptions.Tag, "]: reset_day is only supported for weekly or monthly reset")
+			}
+		case C.QuotaResetWeekly:
+			if newQuota.resetDay < 0 || newQuota.resetDay > 6 {
+				return nil, E.New("quota[", quotaOptions.Tag, "]: invalid weekly reset_day: ", newQuota.resetDay)
+			}
+		case C.QuotaResetMonthly:
+			if newQuota.resetDay == 0 {
+				newQuota.resetDay = 1
+			} else if newQuota.resetDay < 1 || newQuota.resetDay > 28 {
+				return nil, E.New("quota[", quotaOptions.Tag, "]: invalid monthly reset_day: ", newQuota.resetDay)
+			}
+		default:
+			return nil, E.New("quota[", quotaOptions.Tag, "]: unknown reset: ", newQuota.reset)
+		}
+		if quotaOptions.Timezone != "" {
+			newQuota.location, err = time.LoadLocation(quotaOptions.Timezone)
+			if err != nil {
+				return nil, E.Cause(err, "quota[", quotaOptions.Tag, "]: load timezone")
+			}
+		}
+		if len(quotaOptions.Users) > 0 {
+			newQuota.users = make(map[string]bool)
+			for _, user := range quotaOptions.Users {
+				newQuota.users[user] = true
+			}
+		}
+		manager.quotas = append(manager.quotas, newQuota)
+		manager.quotaMap[newQuota.tag] = newQuota
+	}
+	return manager, nil
+}
+
+func (m *QuotaManager) Name() string {
+	return "quota"
+}
+
+func (m *QuotaManager) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	outboundManager := service.FromContext[adapter.OutboundManager](m.ctx)
+	for _, currentQuota := range m.quotas {
+		if currentQuota.outbound == "" {
+			continue
+		}
+		if _, loaded := outboundManager.Outbound(currentQuota.outbound); !loaded {
+			return E.New("quota[", currentQuota.tag, "]: outbound not found: ", currentQuota.outbound)
+		}
+	}
+	m.cacheFile = service.FromContext[adapter.CacheFile](m.ctx)
+	if m.cacheFile != nil {
+		for _, currentQuota := range m.quotas {
+			for user, savedQuota := range m.cacheFile.LoadQuota(currentQuota.tag) {
+				if currentQuota.users != nil && !currentQuota.users[user] {
+					continue
+				}
+				counter := &quotaCounter{
+					quota:     currentQuota,
+					lastReset: savedQuota.LastReset,
+				}
+				counter.used.Store(savedQuota.Used)
+				currentQuota.counters[user] = counter
+			}
+		}
+	}
+	m.update()
+	go m.loopUpdate()
+	return nil
+}
+
+func (m *QuotaManager) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+	m.save()
+	return nil
+}
+
+func (m *QuotaManager) loopUpdate() {
+	ticker := time.NewTicker(quotaUpdateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.update()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *QuotaManager) update() {
+	now := m.now()
+	for _, currentQuota := range m.quotas {
+		currentQuota.access.Lock()
+		for user, counter := range currentQuota.counters {
+			nextReset := currentQuota.nextReset(counter.lastReset)
+			if !nextReset.IsZero() && !now.Before(nextReset) {
+				counter.used.Store(0)
+				counter.lastReset = now
+				counter.dirty.Store(true)
+				m.logger.Debug("quota[", currentQuota.tag, "]: reset for user ", user)
+			}
+		}
+		currentQuota.access.Unlock()
+	}
+	m.save()
+}
+
+func (m *QuotaManager) save() {
+	if m.cacheFile == nil {
+		return
+	}
+	for _, currentQuota := range m.quotas {
+		savedQuotas := make(map[string]*adapter.SavedQuota)
+		currentQuota.access.Lock()
+		for user, counter := range currentQuota.counters {
+			if counter.dirty.Swap(false) {
+				savedQuotas[user] = &adapter.SavedQuota{
+					Used:      counter.used.Load(),
+					LastReset: counter.lastReset,
+				}
+			}
+		}
+		currentQuota.access.Unlock()
+		if len(savedQuotas) == 0 {
+			continue
+		}
+		err := m.cacheFile.StoreQuota(currentQuota.tag, savedQuotas)
+		if err != nil {
+			m.logger.Error(E.Cause(err, "save quota[", currentQuota.tag, "]"))
+		}
+	}
+}
+
+func (m *QuotaManager) now() time.Time {
+	if timeFunc := ntp.TimeFuncFromContext(m.ctx); timeFunc != nil {
+		return timeFunc()
+	}
+	return time.Now()
+}
+
+func (m *QuotaManager) Exhausted(user string) (outbound string, exhausted bool) {
+	for _, currentQuota := range m.quotas {
+		if !currentQuota.match(user) {
+			continue
+		}
+		if currentQuota.counter(user, m.now).exhausted() {
+			return currentQuota.outbound, true
+		}
+	}
+	return "", false
+}
+
+func (m *QuotaManager) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) net.Conn {
+	counters := m.counters(metadata.User)
+	if len(counters) == 0 {
+		return conn
+	}
+	return &quotaConn{conn, counters}
+}
+
+func (m *QuotaManager) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) N.PacketConn {
+	counters := m.counters(metadata.User)
+	if len(counters) == 0 {
+		return conn
+	}
+	return &quotaPacketConn{conn, counters}
+}
+
+func (m *QuotaManager) counters(user string) []*quotaCounter {
+	var counters []*quotaCounter
+	for _, currentQuota := range m.quotas {
+		if currentQuota.match(user) {
+			counters = append(counters, currentQuota.counter(user, m.now))
+		}
+	}
+	return counters
+}
+
+func (m *QuotaManager) Quotas() []adapter.QuotaStatus {
+	var quotas []adapter.QuotaStatus
+	for _, currentQuota := range m.quotas {
+		currentQuota.access.Lock()
+		for user, counter := range currentQuota.counters {
+			quotas = append(quotas, adapter.QuotaStatus{
+				Tag:       currentQuota.tag,
+				User:      user,
+				Limit:     currentQuota.limit,
+				Used:      counter.used.Load(),
+				LastReset: counter.lastReset,
+				NextReset: currentQuota.nextReset(counter.lastReset),
+			})
+		}
+		currentQuota.access.Unlock()
+	}
+	return quotas
+}
+
+func (m *QuotaManager) ResetQuota(tag string, user string) error {
+	currentQuota, loaded := m.quotaMap[tag]
+	if !loaded {
+		return E.New("quota not found: ", tag)
+	}
+	now := m.now()
+	currentQuota.access.Lock()
+	if user == "" {
+		for _, counter := range currentQuota.counters {
+			counter.reset(now)
+		}
+	} else {
+		counter, loaded := currentQuota.counters[user]
+		if !loaded {
+			currentQuota.access.Unlock()
+			return E.New("quota[", tag, "]: user not found: ", user)
+		}
+		counter.reset(now)
+	}
+	currentQuota.access.Unlock()
+	m.save()
+	return nil
+}
+
+func (q *quota) match(user string) bool {
+	return user != "" && (q.users == nil || q.users[user])
+}
+
+func (q *quota) counter(user string, now func() time.Time) *quotaCounter {
+	q.access.Lock()
+	defer q.access.Unlock()
+	counter, loaded := q.counters[user]
+	if !loaded {
+		counter = &quotaCounter{
+			quota:     q,
+			lastReset: now(),
+		}
+		counter.dirty.Store(true)
+		q.counters[user] = counter
+	}
+	return counter
+}
+
+func (q *quota) nextReset(lastReset time.Time) time.Time {
+	lastReset = lastReset.In(q.location)
+	year, month, day := lastReset.Date()
+	switch q.reset {
+	case C.QuotaResetDaily:
+		return time.Date(year, month, day+1, 0, 0, 0, 0, q.location)
+	case C.QuotaResetWeekly:
+		days := (q.resetDay - int(lastReset.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return time.Date(year, month, day+days, 0, 0, 0, 0, q.location)
+	case C.QuotaResetMonthly:
+		nextReset := time.Date(year, month, q.resetDay, 0, 0, 0, 0, q.location)
+		if !nextReset.After(lastReset) {
+			nextReset = time.Date(year, month+1, q.resetDay, 0, 0, 0, 0, q.location)
+		}
+		return nextReset
+	default:
+		return time.Time{}
+	}
+}
+
+func (c *quotaCounter) exhausted() bool {
+	return c.used.Load() >= c.quota.limit
+}
+
+func (c *quotaCounter) add(n int) {
+	c.used.Add(uint64(n))
+	c.dirty.Store(true)
+}
+
+func (c *quotaCounter) reset(now time.Time) {
+	c.used.Store(0)
+	c.lastReset = now
+	c.dirty.Store(true)
+}
+
+func checkQuota(counters []*quotaCounter) error {
+	for _, counter := range counters {
+		if counter.exhausted() {
+			return E.New("quota[", counter.quota.tag, "] exhausted")
+		}
+	}
+	return nil
+}
+
+type quotaConn struct {
+	net.Conn
+	counters []*quotaCounter
+}
+
+func (c *quotaConn) Read(p []byte) (n int, err error) {
+	err = checkQuota(c.counters)
+	if err != nil {
+		return
+	}
+	n, err = c.Conn.Read(p)
+	for _, counter := range c.counters {
+		counter.add(n)
+	}
+	return
+}
+
+func (c *quotaConn) Write(p []byte) (n int, err error) {
+	err = checkQuota(c.counters)
+	if err != nil {
+		return
+	}
+	n, err = c.Conn.Write(p)
+	for _, counter := range c.counters {
+		counter.add(n)
+	}
+	return
+}
+
+func (c *quotaConn) Upstream() any {
+	return c.Conn
+}
+
+type quotaPacketConn struct {
+	N.PacketConn
+	counters []*quotaCounter
+}
+
+func (c *quotaPacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	err = checkQuota(c.counters)
+	if err != nil {
+		return
+	}
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err == nil {
+		for _, counter := range c.counters {
+			counter.add(buffer.Len())
+		}
+	}
+	return
+}
+
+func (c *quotaPacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	err := checkQuota(c.counters)
+	if err != nil {
+		buffer.Release()
+		return err
+	}
+	dataLen := buffer.Len()
+	err = c.PacketConn.WritePacket(buffer, destination)
+	if err == nil {
+		for _, counter := range c.counters {
+			counter.add(dataLen)
+		}
+	}
+	return err
+}
+
+func (c *quotaPacketConn) Upstream() any {
+	return c.PacketConn
+}
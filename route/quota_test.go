@@ -0,0 +1,177 @@
+package route
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTimeService struct {
+	access sync.Mutex
+	now    time.Time
+}
+
+func (s *testTimeService) TimeFunc() func() time.Time {
+	return func() time.Time {
+		s.access.Lock()
+		defer s.access.Unlock()
+		return s.now
+	}
+}
+
+func (s *testTimeService) set(now time.Time) {
+	s.access.Lock()
+	s.now = now
+	s.access.Unlock()
+}
+
+func TestQuotaNextReset(t *testing.T) {
+	t.Parallel()
+	// 2024-01-01 is a Monday
+	date := func(month time.Month, day int, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	for _, testCase := range []struct {
+		name      string
+		reset     string
+		resetDay  int
+		lastReset time.Time
+		nextReset time.Time
+	}{
+		{"never", C.QuotaResetNever, 0, date(1, 1, 12), time.Time{}},
+		{"daily", C.QuotaResetDaily, 0, date(1, 1, 12), date(1, 2, 0)},
+		{"daily at midnight", C.QuotaResetDaily, 0, date(1, 1, 0), date(1, 2, 0)},
+		{"daily end of year", C.QuotaResetDaily, 0, time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC), date(1, 1, 0)},
+		{"weekly later this week", C.QuotaResetWeekly, 3, date(1, 1, 12), date(1, 3, 0)},
+		{"weekly on reset day", C.QuotaResetWeekly, 1, date(1, 1, 0), date(1, 8, 0)},
+		{"weekly next week", C.QuotaResetWeekly, 0, date(1, 1, 12), date(1, 7, 0)},
+		{"monthly later this month", C.QuotaResetMonthly, 15, date(1, 10, 12), date(1, 15, 0)},
+		{"monthly on reset day", C.QuotaResetMonthly, 15, date(1, 15, 0), date(2, 15, 0)},
+		{"monthly next month", C.QuotaResetMonthly, 1, date(1, 20, 12), date(2, 1, 0)},
+		{"monthly next year", C.QuotaResetMonthly, 1, time.Date(2023, 12, 20, 0, 0, 0, 0, time.UTC), date(1, 1, 0)},
+	} {
+		currentQuota := &quota{
+			reset:    testCase.reset,
+			resetDay: testCase.resetDay,
+			location: time.UTC,
+		}
+		require.True(t, testCase.nextReset.Equal(currentQuota.nextReset(testCase.lastReset)), testCase.name)
+	}
+}
+
+func TestQuotaNextResetTimezone(t *testing.T) {
+	t.Parallel()
+	location := time.FixedZone("UTC+8", 8*60*60)
+	currentQuota := &quota{
+		reset:    C.QuotaResetDaily,
+		location: location,
+	}
+	// 2024-01-01 20:00 UTC is 2024-01-02 04:00 in UTC+8
+	nextReset := currentQuota.nextReset(time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC))
+	require.True(t, time.Date(2024, 1, 3, 0, 0, 0, 0, location).Equal(nextReset))
+}
+
+func TestQuotaCheck(t *testing.T) {
+	t.Parallel()
+	manager, err := NewQuotaManager(context.Background(), logger.NOP(), []option.QuotaOptions{{
+		Tag:   "test",
+		Users: []string{"user"},
+		Limit: "100B",
+	}})
+	require.NoError(t, err)
+	require.Empty(t, manager.counters(""))
+	require.Empty(t, manager.counters("other"))
+	counters := manager.counters("user")
+	require.Len(t, counters, 1)
+	require.NoError(t, checkQuota(counters))
+	conn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	go func() {
+		buffer := make([]byte, 1024)
+		for {
+			_, readErr := serverConn.Read(buffer)
+			if readErr != nil {
+				return
+			}
+		}
+	}()
+	quotaConn := manager.RoutedConnection(context.Background(), conn, adapter.InboundContext{User: "user"})
+	defer quotaConn.Close()
+	_, err = quotaConn.Write(make([]byte, 99))
+	require.NoError(t, err)
+	_, exhausted := manager.Exhausted("user")
+	require.False(t, exhausted)
+	_, err = quotaConn.Write(make([]byte, 1))
+	require.NoError(t, err)
+	_, exhausted = manager.Exhausted("user")
+	require.True(t, exhausted)
+	require.Error(t, checkQuota(counters))
+	_, err = quotaConn.Write(make([]byte, 1))
+	require.Error(t, err)
+	require.NoError(t, manager.ResetQuota("test", "user"))
+	require.NoError(t, checkQuota(counters))
+}
+
+func TestQuotaCacheFile(t *testing.T) {
+	t.Parallel()
+	timeService := &testTimeService{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	ctx := service.ContextWith[ntp.TimeService](context.Background(), timeService)
+	cacheFile := cachefile.New(ctx, option.CacheFileOptions{
+		Path: filepath.Join(t.TempDir(), "cache.db"),
+	})
+	require.NoError(t, cacheFile.Start(adapter.StartStateInitialize))
+	defer cacheFile.Close()
+	ctx = service.ContextWith[adapter.CacheFile](ctx, cacheFile)
+	quotaOptions := []option.QuotaOptions{{
+		Tag:      "test",
+		Limit:    "1KB",
+		Reset:    C.QuotaResetDaily,
+		Timezone: "UTC",
+	}}
+	newManager := func() *QuotaManager {
+		manager, err := NewQuotaManager(ctx, logger.NOP(), quotaOptions)
+		require.NoError(t, err)
+		require.NoError(t, manager.Start(adapter.StartStateStart))
+		return manager
+	}
+
+	manager := newManager()
+	manager.counters("user")[0].add(100)
+	require.NoError(t, manager.Close())
+
+	manager = newManager()
+	quotas := manager.Quotas()
+	require.Len(t, quotas, 1)
+	require.Equal(t, "user", quotas[0].User)
+	require.Equal(t, uint64(100), quotas[0].Used)
+	require.True(t, timeService.now.Equal(quotas[0].LastReset))
+	require.True(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Equal(quotas[0].NextReset))
+	require.NoError(t, manager.Close())
+
+	timeService.set(time.Date(2024, 1, 2, 0, 0, 1, 0, time.UTC))
+	manager = newManager()
+	quotas = manager.Quotas()
+	require.Len(t, quotas, 1)
+	require.Zero(t, quotas[0].Used)
+	require.NoError(t, manager.Close())
+
+	manager = newManager()
+	quotas = manager.Quotas()
+	require.Len(t, quotas, 1)
+	require.Zero(t, quotas[0].Used)
+	require.True(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC).Equal(quotas[0].NextReset))
+	require.NoError(t, manager.Close())
+}
@@ -0,0 +1,194 @@
+package route
+
+import (
+	"reflect"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// reloadRouter resolves rule-sets from the pending reload for rules created during it.
+type reloadRouter struct {
+	*Router
+	ruleSetMap map[string]adapter.RuleSet
+}
+
+func (r *reloadRouter) RuleSet(tag string) (adapter.RuleSet, bool) {
+	ruleSet, loaded := r.ruleSetMap[tag]
+	return ruleSet, loaded
+}
+
+// isRuleSetCleaned reports whether the rule-set released its rules after startup
+// because it was not referenced, so that it can not be reused.
+func isRuleSetCleaned(ruleSet adapter.RuleSet) bool {
+	cleanedRuleSet, isCleaned := ruleSet.(interface{ Cleaned() bool })
+	return isCleaned && cleanedRuleSet.Cleaned()
+}
+
+func (r *Router) Reload(rules []option.Rule, ruleSets []option.RuleSet, dnsRules []option.DNSRule) error {
+	r.reloadAccess.Lock()
+	defer r.reloadAccess.Unlock()
+	if !r.started {
+		return E.New("router is not started")
+	}
+	r.ruleAccess.RLock()
+	oldRules := r.rules
+	oldRuleSets := r.ruleSets
+	oldRuleSetMap := r.ruleSetMap
+	oldRuleSetOptions := r.ruleSetOptions
+	oldScriptRuleSets := r.scriptRuleSets
+	r.ruleAccess.RUnlock()
+
+	var (
+		newRuleSets       []adapter.RuleSet
+		newRuleSetMap     = make(map[string]adapter.RuleSet)
+		newRuleSetOptions = make(map[string]option.RuleSet)
+		createdRuleSets   []adapter.RuleSet
+		newRules          []adapter.Rule
+		newScriptRuleSets map[string]*R.RuleSetItem
+	)
+	closeCreated := func() {
+		for _, rule := range newRules {
+			rule.Close()
+		}
+		closeScriptRuleSetItems(newScriptRuleSets)
+		for _, ruleSet := range createdRuleSets {
+			ruleSet.Close()
+		}
+	}
+	for i, options := range ruleSets {
+		if _, exists := newRuleSetMap[options.Tag]; exists {
+			closeCreated()
+			return E.New("duplicate rule-set tag: ", options.Tag)
+		}
+		ruleSet, loaded := oldRuleSetMap[options.Tag]
+		if !loaded || !reflect.DeepEqual(oldRuleSetOptions[options.Tag], options) || isRuleSetCleaned(ruleSet) {
+			var err error
+			ruleSet, err = R.NewRuleSet(r.ctx, r.logger, options)
+			if err != nil {
+				closeCreated()
+				return E.Cause(err, "parse rule-set[", i, "]")
+			}
+			createdRuleSets = append(createdRuleSets, ruleSet)
+		}
+		newRuleSets = append(newRuleSets, ruleSet)
+		newRuleSetMap[options.Tag] = ruleSet
+		newRuleSetOptions[options.Tag] = options
+	}
+	if len(createdRuleSets) > 0 {
+		err := r.startRuleSets(createdRuleSets)
+		if err != nil {
+			closeCreated()
+			return err
+		}
+	}
+	err := r.checkReloadRequirements(rules, newRuleSets, dnsRules)
+	if err != nil {
+		closeCreated()
+		return err
+	}
+	router := &reloadRouter{r, newRuleSetMap}
+	ctx := R.ContextWithRouter(r.ctx, router)
+	for i, options := range rules {
+		rule, err := R.NewRule(ctx, r.logger, options, false)
+		if err != nil {
+			closeCreated()
+			return E.Cause(err, "parse rule[", i, "]")
+		}
+		newRules = append(newRules, rule)
+		err = rule.Start()
+		if err != nil {
+			closeCreated()
+			return E.Cause(err, "initialize rule[", i, "]")
+		}
+	}
+	newScriptRuleSets, err = r.newScriptRuleSetItems(router)
+	if err != nil {
+		closeCreated()
+		return err
+	}
+	commitDNS, err := r.dns.ReloadRules(ctx, dnsRules)
+	if err != nil {
+		closeCreated()
+		return err
+	}
+	for _, ruleSet := range createdRuleSets {
+		err = ruleSet.PostStart()
+		if err != nil {
+			r.logger.Error(E.Cause(err, "post start rule_set[", ruleSet.Name(), "]"))
+		}
+	}
+
+	r.ruleAccess.Lock()
+	r.rules = newRules
+	r.ruleStatistics = common.Map(newRules, func(it adapter.Rule) *adapter.RuleStatistics {
+		return new(adapter.RuleStatistics)
+	})
+	r.ruleSets = newRuleSets
+	r.ruleSetMap = newRuleSetMap
+	r.ruleSetOptions = newRuleSetOptions
+	r.scriptRuleSets = newScriptRuleSets
+	r.ruleAccess.Unlock()
+	commitDNS()
+	closeScriptRuleSetItems(oldScriptRuleSets)
+
+	for i, rule := range oldRules {
+		err = rule.Close()
+		if err != nil {
+			r.logger.Error(E.Cause(err, "close rule[", i, "]"))
+		}
+	}
+	for _, ruleSet := range oldRuleSets {
+		if newRuleSetMap[ruleSet.Name()] == ruleSet {
+			continue
+		}
+		err = ruleSet.Close()
+		if err != nil {
+			r.logger.Error(E.Cause(err, "close rule_set[", ruleSet.Name(), "]"))
+		}
+	}
+	for _, ruleSet := range createdRuleSets {
+		ruleSet.Cleanup()
+	}
+	r.logger.Info("reloaded ", len(newRules), " rules, ", len(newRuleSets), " rule-sets and ", len(dnsRules), " DNS rules")
+	return nil
+}
+
+func (r *Router) checkReloadRequirements(rules []option.Rule, ruleSets []adapter.RuleSet, dnsRules []option.DNSRule) error {
+	needFindProcess := hasRule(rules, isProcessRule) || hasDNSRule(dnsRules, isProcessDNSRule)
+	needWIFIState := hasRule(rules, isWIFIRule) || hasDNSRule(dnsRules, isWIFIDNSRule)
+	for _, ruleSet := range ruleSets {
+		metadata := ruleSet.Metadata()
+		if metadata.ContainsProcessRule {
+			needFindProcess = true
+		}
+		if metadata.ContainsWIFIRule {
+			needWIFIState = true
+		}
+	}
+	if needFindProcess && !r.needFindProcess {
+		return E.New("process rules are not enabled at startup, restart required")
+	}
+	if (hasRule(rules, isProcessCmdlineRule) || hasDNSRule(dnsRules, isProcessCmdlineDNSRule)) && !r.needFindCmdline {
+		return E.New("process_cmdline_regex rules are not enabled at startup, restart required")
+	}
+	if (hasRule(rules, isProcessAncestorRule) || hasDNSRule(dnsRules, isProcessAncestorDNSRule)) && !r.needFindAncestors {
+		return E.New("process_parent_name and process_ancestor_path rules are not enabled at startup, restart required")
+	}
+	if (hasRule(rules, isProcessCgroupRule) || hasDNSRule(dnsRules, isProcessCgroupDNSRule)) && !r.needFindCgroup {
+		return E.New("process_cgroup rules are not enabled at startup, restart required")
+	}
+	if needWIFIState && !r.needWIFIState {
+		return E.New("WIFI rules are not enabled at startup, restart required")
+	}
+	if hasRule(rules, isNeighborRule) && !r.needFindNeighbor {
+		return E.New("source_mac_address rules are not enabled at startup, restart required")
+	}
+	if (hasRule(rules, isASNRule) || hasDNSRule(dnsRules, isASNDNSRule)) && !r.needASN {
+		return E.New("ASN rules are not enabled at startup, restart required")
+	}
+	return nil
+}
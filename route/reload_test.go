@@ -0,0 +1,250 @@
+package route_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/include"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/route"
+	"github.com/sagernet/sing/common/json"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+const reloadConfig = `{
+  "log": {
+    "disabled": true
+  },
+  "outbounds": [
+    {
+      "type": "direct",
+      "tag": "direct"
+    },
+    {
+      "type": "direct",
+      "tag": "proxy"
+    }
+  ],
+  "route": {
+    "rules": [
+      {
+        "rule_set": "a",
+        "outbound": "proxy"
+      }
+    ],
+    "rule_set": [
+      {
+        "type": "inline",
+        "tag": "a",
+        "rules": [
+          {
+            "domain": "a.example.com"
+          }
+        ]
+      },
+      {
+        "type": "inline",
+        "tag": "b",
+        "rules": [
+          {
+            "domain": "b.example.com"
+          }
+        ]
+      }
+    ],
+    "final": "direct"
+  }
+}`
+
+func newReloadRouter(t *testing.T) (context.Context, *route.Router) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ctx = box.Context(ctx, include.InboundRegistry(), include.OutboundRegistry(), include.EndpointRegistry(), include.DNSTransportRegistry())
+	options, err := json.UnmarshalExtendedContext[option.Options](ctx, []byte(reloadConfig))
+	require.NoError(t, err)
+	instance, err := box.New(box.Options{Context: ctx, Options: options})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		instance.Close()
+	})
+	require.NoError(t, instance.PreStart())
+	require.NoError(t, instance.Router().Start(adapter.StartStatePostStart))
+	require.NoError(t, instance.Router().Start(adapter.StartStateStarted))
+	return ctx, instance.Router().(*route.Router)
+}
+
+func parseReloadOptions(t *testing.T, ctx context.Context, content string) option.RouteOptions {
+	options, err := json.UnmarshalExtendedContext[option.RouteOptions](ctx, []byte(content))
+	require.NoError(t, err)
+	return options
+}
+
+func requireOutbound(t *testing.T, router *route.Router, domain string, outbound string) {
+	result, err := router.Simulate(context.Background(), adapter.InboundContext{
+		Network:     N.NetworkTCP,
+		Destination: M.Socksaddr{Fqdn: domain, Port: 443},
+	}, route.SimulateOptions{DisableDNS: true})
+	require.NoError(t, err)
+	require.Equal(t, outbound, result.Outbound, domain)
+}
+
+func TestReload(t *testing.T) {
+	t.Parallel()
+	ctx, router := newReloadRouter(t)
+	requireOutbound(t, router, "a.example.com", "proxy")
+	requireOutbound(t, router, "c.example.com", "direct")
+	options := parseReloadOptions(t, ctx, `{
+  "rules": [
+    {
+      "domain": "c.example.com",
+      "outbound": "proxy"
+    }
+  ]
+}`)
+	require.NoError(t, router.Reload(options.Rules, options.RuleSet, nil))
+	require.Len(t, router.Rules(), 1)
+	_, ruleStatistics := router.RulesWithStatistics()
+	require.Len(t, ruleStatistics, 1)
+	require.Empty(t, router.RuleSets())
+	requireOutbound(t, router, "a.example.com", "direct")
+	requireOutbound(t, router, "c.example.com", "proxy")
+}
+
+func TestReloadRuleSetReuse(t *testing.T) {
+	t.Parallel()
+	ctx, router := newReloadRouter(t)
+	oldRuleSetA, loaded := router.RuleSet("a")
+	require.True(t, loaded)
+	oldRuleSetB, loaded := router.RuleSet("b")
+	require.True(t, loaded)
+	options, err := json.UnmarshalExtendedContext[option.Options](ctx, []byte(reloadConfig))
+	require.NoError(t, err)
+	baseOptions := options.Route
+
+	// unchanged rule-sets are kept across reloads
+	require.NoError(t, router.Reload(baseOptions.Rules, baseOptions.RuleSet, nil))
+	ruleSetA, _ := router.RuleSet("a")
+	require.Same(t, oldRuleSetA, ruleSetA)
+	requireOutbound(t, router, "a.example.com", "proxy")
+
+	// references are released when the last rule using a rule-set is removed,
+	// and taken again by the rules of a later reload
+	routeOptions := parseReloadOptions(t, ctx, `{
+  "rules": [
+    {
+      "domain": "c.example.com",
+      "outbound": "proxy"
+    }
+  ]
+}`)
+	require.NoError(t, router.Reload(routeOptions.Rules, baseOptions.RuleSet, nil))
+	requireOutbound(t, router, "a.example.com", "direct")
+	routeOptions = parseReloadOptions(t, ctx, `{
+  "rules": [
+    {
+      "rule_set": ["a", "b"],
+      "outbound": "proxy"
+    }
+  ]
+}`)
+	require.NoError(t, router.Reload(routeOptions.Rules, baseOptions.RuleSet, nil))
+	ruleSetA, _ = router.RuleSet("a")
+	require.Same(t, oldRuleSetA, ruleSetA)
+	// b was not referenced and released its rules at startup, so it is recreated
+	ruleSetB, _ := router.RuleSet("b")
+	require.NotSame(t, oldRuleSetB, ruleSetB)
+	requireOutbound(t, router, "a.example.com", "proxy")
+	requireOutbound(t, router, "b.example.com", "proxy")
+
+	// changed rule-sets are recreated
+	routeOptions = parseReloadOptions(t, ctx, `{
+  "rules": [
+    {
+      "rule_set": "a",
+      "outbound": "proxy"
+    }
+  ],
+  "rule_set": [
+    {
+      "type": "inline",
+      "tag": "a",
+      "rules": [
+        {
+          "domain": "d.example.com"
+        }
+      ]
+    }
+  ]
+}`)
+	require.NoError(t, router.Reload(routeOptions.Rules, routeOptions.RuleSet, nil))
+	ruleSetA, _ = router.RuleSet("a")
+	require.NotSame(t, oldRuleSetA, ruleSetA)
+	_, loaded = router.RuleSet("b")
+	require.False(t, loaded)
+	requireOutbound(t, router, "a.example.com", "direct")
+	requireOutbound(t, router, "d.example.com", "proxy")
+}
+
+func TestReloadRollback(t *testing.T) {
+	t.Parallel()
+	ctx, router := newReloadRouter(t)
+	oldRules := router.Rules()
+	oldRuleSetA, _ := router.RuleSet("a")
+	options := parseReloadOptions(t, ctx, `{
+  "rules": [
+    {
+      "rule_set": ["a", "c"],
+      "outbound": "proxy"
+    },
+    {
+      "rule_set": "missing",
+      "outbound": "proxy"
+    }
+  ],
+  "rule_set": [
+    {
+      "type": "inline",
+      "tag": "a",
+      "rules": [
+        {
+          "domain": "a.example.com"
+        }
+      ]
+    },
+    {
+      "type": "inline",
+      "tag": "c",
+      "rules": [
+        {
+          "domain": "c.example.com"
+        }
+      ]
+    }
+  ]
+}`)
+	require.Error(t, router.Reload(options.Rules, options.RuleSet, nil))
+	require.Equal(t, oldRules, router.Rules())
+	ruleSetA, _ := router.RuleSet("a")
+	require.Same(t, oldRuleSetA, ruleSetA)
+	_, loaded := router.RuleSet("c")
+	require.False(t, loaded)
+	requireOutbound(t, router, "a.example.com", "proxy")
+	requireOutbound(t, router, "c.example.com", "direct")
+
+	// the references taken by the failed reload are released
+	options = parseReloadOptions(t, ctx, `{
+  "rules": [
+    {
+      "domain": "c.example.com",
+      "outbound": "proxy"
+    }
+  ]
+}`)
+	require.NoError(t, router.Reload(options.Rules, nil, nil))
+	requireOutbound(t, router, "c.example.com", "proxy")
+}
@@ -12,7 +12,9 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/conntrack"
+	"github.com/sagernet/sing-box/common/nat64"
 	"github.com/sagernet/sing-box/common/process"
+	"github.com/sagernet/sing-box/common/ratelimit"
 	"github.com/sagernet/sing-box/common/sniff"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -20,6 +22,7 @@ import (
 	"github.com/sagernet/sing-mux"
 	"github.com/sagernet/sing-vmess"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
 	"github.com/sagernet/sing/common/buf"
 	"github.com/sagernet/sing/common/bufio"
 	"github.com/sagernet/sing/common/bufio/deadline"
@@ -97,7 +100,7 @@ func (r *Router) routeConnection(ctx context.Context, conn net.Conn, metadata ad
 	if deadline.NeedAdditionalReadDeadline(conn) {
 		conn = deadline.NewConn(conn)
 	}
-	selectedRule, _, buffers, _, err := r.matchRule(ctx, &metadata, false, conn, nil)
+	selectedRule, selectedRuleIndex, buffers, _, err := r.matchRule(ctx, &metadata, false, conn, nil)
 	if err != nil {
 		return err
 	}
@@ -135,6 +138,11 @@ func (r *Router) routeConnection(ctx context.Context, conn net.Conn, metadata ad
 		}
 		selectedOutbound = defaultOutbound
 	}
+	selectedOutbound, trackQuota, err := r.checkQuota(metadata, N.NetworkTCP, selectedOutbound)
+	if err != nil {
+		buf.ReleaseMulti(buffers)
+		return err
+	}
 
 	for _, buffer := range buffers {
 		conn = bufio.NewCachedConn(conn, buffer)
@@ -142,6 +150,18 @@ func (r *Router) routeConnection(ctx context.Context, conn net.Conn, metadata ad
 	if r.tracker != nil {
 		conn = r.tracker.RoutedConnection(ctx, conn, metadata, selectedRule, selectedOutbound)
 	}
+	if selectedRule != nil {
+		statistics := r.selectedRuleStatistics(selectedRule, selectedRuleIndex)
+		if statistics != nil {
+			conn = bufio.NewInt64CounterConn(conn, []*atomic.Int64{&statistics.Upload}, []*atomic.Int64{&statistics.Download})
+		}
+	}
+	if trackQuota {
+		conn = r.quota.RoutedConnection(ctx, conn, metadata)
+	}
+	if metadata.RateLimit != nil {
+		conn = ratelimit.NewConn(ctx, conn, metadata.RateLimit.Acquire(metadata.RateLimitKey))
+	}
 	if outboundHandler, isHandler := selectedOutbound.(adapter.ConnectionHandlerEx); isHandler {
 		outboundHandler.NewConnectionEx(ctx, conn, metadata, onClose)
 	} else {
@@ -217,7 +237,7 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 		conn = deadline.NewPacketConn(bufio.NewNetPacketConn(conn))
 	}*/
 
-	selectedRule, _, _, packetBuffers, err := r.matchRule(ctx, &metadata, false, nil, conn)
+	selectedRule, selectedRuleIndex, _, packetBuffers, err := r.matchRule(ctx, &metadata, false, nil, conn)
 	if err != nil {
 		return err
 	}
@@ -253,6 +273,11 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 		}
 		selectedOutbound = defaultOutbound
 	}
+	selectedOutbound, trackQuota, err := r.checkQuota(metadata, N.NetworkUDP, selectedOutbound)
+	if err != nil {
+		N.ReleaseMultiPacketBuffer(packetBuffers)
+		return err
+	}
 	for _, buffer := range packetBuffers {
 		conn = bufio.NewCachedPacketConn(conn, buffer.Buffer, buffer.Destination)
 		N.PutPacketBuffer(buffer)
@@ -260,6 +285,18 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 	if r.tracker != nil {
 		conn = r.tracker.RoutedPacketConnection(ctx, conn, metadata, selectedRule, selectedOutbound)
 	}
+	if selectedRule != nil {
+		statistics := r.selectedRuleStatistics(selectedRule, selectedRuleIndex)
+		if statistics != nil {
+			conn = bufio.NewInt64CounterPacketConn(conn, []*atomic.Int64{&statistics.Upload}, []*atomic.Int64{&statistics.Download})
+		}
+	}
+	if trackQuota {
+		conn = r.quota.RoutedPacketConnection(ctx, conn, metadata)
+	}
+	if metadata.RateLimit != nil {
+		conn = ratelimit.NewPacketConn(ctx, conn, metadata.RateLimit.Acquire(metadata.RateLimitKey))
+	}
 	if metadata.FakeIP {
 		conn = bufio.NewNATPacketConn(bufio.NewNetPacketConn(conn), metadata.OriginDestination, metadata.Destination)
 	}
@@ -324,6 +361,9 @@ func (r *Router) matchRule(
 			metadata.ProcessInfo = processInfo
 		}
 	}
+	if r.neighborResolver != nil && metadata.SourceMACAddress == nil && metadata.Source.Addr.IsValid() {
+		r.lookupSourceDevice(ctx, metadata)
+	}
 	if metadata.Destination.Addr.IsValid() && r.dnsTransport.FakeIP() != nil && r.dnsTransport.FakeIP().Store().Contains(metadata.Destination.Addr) {
 		domain, loaded := r.dnsTransport.FakeIP().Store().Lookup(metadata.Destination.Addr)
 		if !loaded {
@@ -383,12 +423,19 @@ func (r *Router) matchRule(
 		metadata.InboundOptions = option.InboundOptions{}
 	}
 
+	simulation := simulationFromContext(ctx)
+	rules, ruleStatistics := r.RulesWithStatistics()
 match:
-	for currentRuleIndex, currentRule := range r.rules {
+	for currentRuleIndex, currentRule := range rules {
 		metadata.ResetRuleCache()
 		if !currentRule.Match(metadata) {
 			continue
 		}
+		if simulation != nil {
+			simulation.match(currentRuleIndex, currentRule)
+		} else if !preMatch {
+			ruleStatistics[currentRuleIndex].Matches.Add(1)
+		}
 		if !preMatch {
 			ruleDescription := currentRule.String()
 			if ruleDescription != "" {
@@ -458,6 +505,24 @@ match:
 				metadata.TLSFragment = true
 				metadata.TLSFragmentFallbackDelay = routeOptions.TLSFragmentFallbackDelay
 			}
+			if routeOptions.QUICFragment {
+				metadata.QUICFragment = true
+				metadata.QUICFragmentReorder = routeOptions.QUICFragmentReorder
+				metadata.QUICFragmentPadding = routeOptions.QUICFragmentPadding
+			}
+			if routeOptions.HTTPHostSplit {
+				metadata.HTTPHostSplit = true
+			}
+			if routeOptions.HTTPHostMixCase {
+				metadata.HTTPHostMixCase = true
+			}
+			if routeOptions.HTTPHostExtraSpace {
+				metadata.HTTPHostExtraSpace = true
+			}
+			if routeOptions.RateLimit != nil {
+				metadata.RateLimit = routeOptions.RateLimit
+				metadata.RateLimitKey = rateLimitKey(routeOptions.RateLimitKey, metadata)
+			}
 		}
 		switch action := currentRule.Action().(type) {
 		case *rule.RuleActionSniff:
@@ -482,6 +547,19 @@ match:
 			if fatalErr != nil {
 				return
 			}
+		case *rule.RuleActionScript:
+			if !preMatch {
+				scriptRule := r.actionScript(metadata, currentRule, action)
+				if scriptRule != nil {
+					r.logger.DebugContext(ctx, "script ", action.Function, " => ", scriptRule.Action())
+					if simulation != nil {
+						simulation.record("script returned ", scriptRule.Action())
+					}
+					selectedRule = scriptRule
+					selectedRuleIndex = currentRuleIndex
+					break match
+				}
+			}
 		}
 		actionType := currentRule.Action().Type()
 		if actionType == C.RuleActionTypeRoute ||
@@ -518,6 +596,8 @@ func (r *Router) actionSniff(
 ) (buffer *buf.Buffer, packetBuffers []*N.PacketBuffer, fatalErr error) {
 	if sniff.Skip(metadata) {
 		return
+	} else if simulation := simulationFromContext(ctx); simulation != nil {
+		simulation.sniff(metadata, action)
 	} else if inputConn != nil {
 		sniffBuffer := buf.NewPacket()
 		var streamSniffers []sniff.StreamSniffer
@@ -669,20 +749,92 @@ func (r *Router) actionResolve(ctx context.Context, metadata *adapter.InboundCon
 				return E.New("DNS server not found: ", action.Server)
 			}
 		}
-		addresses, err := r.dns.Lookup(adapter.WithContext(ctx, metadata), metadata.Destination.Fqdn, adapter.DNSQueryOptions{
-			Transport: transport,
-			Strategy:  action.Strategy,
-		})
+		var (
+			addresses []netip.Addr
+			stubbed   bool
+			err       error
+		)
+		simulation := simulationFromContext(ctx)
+		if simulation != nil {
+			addresses, stubbed, err = simulation.lookup(metadata.Destination.Fqdn, action.Strategy)
+		}
+		if !stubbed {
+			addresses, err = r.dns.Lookup(adapter.WithContext(ctx, metadata), metadata.Destination.Fqdn, adapter.DNSQueryOptions{
+				Transport: transport,
+				Strategy:  action.Strategy,
+			})
+		}
 		if err != nil {
 			return err
 		}
+		if action.NAT64Prefix.IsValid() {
+			addresses = common.Uniq(common.Map(addresses, func(it netip.Addr) netip.Addr {
+				return nat64.Address(action.NAT64Prefix, it)
+			}))
+		}
 		metadata.DestinationAddresses = addresses
 		r.logger.DebugContext(ctx, "resolved [", strings.Join(F.MapToString(metadata.DestinationAddresses), " "), "]")
+		if simulation != nil {
+			simulation.record("resolved [", strings.Join(F.MapToString(metadata.DestinationAddresses), " "), "]")
+		}
 		if metadata.Destination.IsIPv4() {
 			metadata.IPVersion = 4
 		} else if metadata.Destination.IsIPv6() {
 			metadata.IPVersion = 6
 		}
+	} else if action.NAT64Prefix.IsValid() && metadata.Destination.IsIPv4() {
+		metadata.Destination.Addr = nat64.Address(action.NAT64Prefix, metadata.Destination.Addr)
+		metadata.IPVersion = 6
+		r.logger.DebugContext(ctx, "mapped destination to ", metadata.Destination)
+		if simulation := simulationFromContext(ctx); simulation != nil {
+			simulation.record("mapped destination to ", metadata.Destination)
+		}
 	}
 	return nil
 }
+
+func (r *Router) checkQuota(metadata adapter.InboundContext, network string, selectedOutbound adapter.Outbound) (adapter.Outbound, bool, error) {
+	if r.quota == nil || metadata.User == "" {
+		return selectedOutbound, false, nil
+	}
+	quotaOutbound, exhausted := r.quota.Exhausted(metadata.User)
+	if !exhausted {
+		return selectedOutbound, true, nil
+	}
+	if quotaOutbound == "" {
+		return nil, false, E.New("quota exhausted for user: ", metadata.User)
+	}
+	outbound, loaded := r.outbound.Outbound(quotaOutbound)
+	if !loaded {
+		return nil, false, E.New("quota outbound not found: ", quotaOutbound)
+	}
+	if !common.Contains(outbound.Network(), network) {
+		return nil, false, E.New(network, " is not supported by quota outbound: ", quotaOutbound)
+	}
+	return outbound, false, nil
+}
+
+func rateLimitKey(key string, metadata *adapter.InboundContext) string {
+	switch key {
+	case C.RuleActionLimitKeyAuthUser:
+		return metadata.User
+	case C.RuleActionLimitKeySourceIP:
+		return metadata.Source.Addr.Unmap().String()
+	default:
+		return ""
+	}
+}
+
+func (r *Router) lookupSourceDevice(ctx context.Context, metadata *adapter.InboundContext) {
+	hardwareAddr, loaded := r.neighborResolver.Lookup(metadata.Source.Addr)
+	if !loaded {
+		return
+	}
+	metadata.SourceMACAddress = hardwareAddr
+	metadata.SourceDevice = r.deviceAliasMap[hardwareAddr.String()]
+	if metadata.SourceDevice != "" {
+		r.logger.DebugContext(ctx, "found source device: ", metadata.SourceDevice, " (", hardwareAddr, ")")
+	} else {
+		r.logger.DebugContext(ctx, "found source MAC address: ", hardwareAddr)
+	}
+}
@@ -2,11 +2,17 @@ package route
 
 import (
 	"context"
+	"net"
+	"net/netip"
 	"os"
 	"runtime"
+	"sync"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/geoip"
+	"github.com/sagernet/sing-box/common/neighbor"
 	"github.com/sagernet/sing-box/common/process"
+	"github.com/sagernet/sing-box/common/script"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental/libbox/platform"
@@ -14,8 +20,10 @@ import (
 	"github.com/sagernet/sing-box/option"
 	R "github.com/sagernet/sing-box/route/rule"
 	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json/badoption"
 	"github.com/sagernet/sing/common/task"
 	"github.com/sagernet/sing/service"
+	"github.com/sagernet/sing/service/filemanager"
 	"github.com/sagernet/sing/service/pause"
 )
 
@@ -30,13 +38,32 @@ type Router struct {
 	dnsTransport      adapter.DNSTransportManager
 	connection        adapter.ConnectionManager
 	network           adapter.NetworkManager
+	reloadAccess      sync.Mutex
+	ruleAccess        sync.RWMutex
 	rules             []adapter.Rule
+	ruleStatistics    []*adapter.RuleStatistics
 	needFindProcess   bool
+	needFindCmdline   bool
+	needFindAncestors bool
+	needFindCgroup    bool
 	ruleSets          []adapter.RuleSet
 	ruleSetMap        map[string]adapter.RuleSet
+	ruleSetOptions    map[string]option.RuleSet
 	processSearcher   process.Searcher
+	needFindNeighbor  bool
+	deviceAlias       map[string]badoption.Listable[string]
+	deviceAliasMap    map[string]string
+	neighborResolver  neighbor.Resolver
+	needASN           bool
+	asnPath           string
+	asnReader         *geoip.ASNReader
+	scriptOptions     *option.ScriptOptions
+	scriptState       *script.State
+	script            adapter.Script
+	scriptRuleSets    map[string]*R.RuleSetItem
 	pauseManager      pause.Manager
 	tracker           adapter.ConnectionTracker
+	quota             adapter.QuotaManager
 	platformInterface platform.Interface
 	needWIFIState     bool
 	started           bool
@@ -54,20 +81,45 @@ func NewRouter(ctx context.Context, logFactory log.Factory, options option.Route
 		network:           service.FromContext[adapter.NetworkManager](ctx),
 		rules:             make([]adapter.Rule, 0, len(options.Rules)),
 		ruleSetMap:        make(map[string]adapter.RuleSet),
+		ruleSetOptions:    make(map[string]option.RuleSet),
 		needFindProcess:   hasRule(options.Rules, isProcessRule) || hasDNSRule(dnsOptions.Rules, isProcessDNSRule) || options.FindProcess,
+		needFindCmdline:   hasRule(options.Rules, isProcessCmdlineRule) || hasDNSRule(dnsOptions.Rules, isProcessCmdlineDNSRule),
+		needFindAncestors: hasRule(options.Rules, isProcessAncestorRule) || hasDNSRule(dnsOptions.Rules, isProcessAncestorDNSRule),
+		needFindCgroup:    hasRule(options.Rules, isProcessCgroupRule) || hasDNSRule(dnsOptions.Rules, isProcessCgroupDNSRule),
+		needFindNeighbor:  hasRule(options.Rules, isNeighborRule),
+		deviceAlias:       options.DeviceAlias,
+		needASN:           hasRule(options.Rules, isASNRule) || hasDNSRule(dnsOptions.Rules, isASNDNSRule),
+		asnPath:           asnPath(options.ASN),
+		scriptOptions:     options.Script,
+		scriptState:       script.NewState(),
 		pauseManager:      service.FromContext[pause.Manager](ctx),
+		quota:             service.FromContext[adapter.QuotaManager](ctx),
 		platformInterface: service.FromContext[platform.Interface](ctx),
 		needWIFIState:     hasRule(options.Rules, isWIFIRule) || hasDNSRule(dnsOptions.Rules, isWIFIDNSRule),
 	}
 }
 
+func asnPath(options *option.ASNOptions) string {
+	if options == nil || options.Path == "" {
+		return "asn.mmdb"
+	}
+	return options.Path
+}
+
 func (r *Router) Initialize(rules []option.Rule, ruleSets []option.RuleSet) error {
+	if r.scriptOptions != nil {
+		err := r.loadScript(r.scriptOptions)
+		if err != nil {
+			return err
+		}
+	}
 	for i, options := range rules {
 		rule, err := R.NewRule(r.ctx, r.logger, options, false)
 		if err != nil {
 			return E.Cause(err, "parse rule[", i, "]")
 		}
 		r.rules = append(r.rules, rule)
+		r.ruleStatistics = append(r.ruleStatistics, new(adapter.RuleStatistics))
 	}
 	for i, options := range ruleSets {
 		if _, exists := r.ruleSetMap[options.Tag]; exists {
@@ -79,6 +131,7 @@ func (r *Router) Initialize(rules []option.Rule, ruleSets []option.RuleSet) erro
 		}
 		r.ruleSets = append(r.ruleSets, ruleSet)
 		r.ruleSetMap[options.Tag] = ruleSet
+		r.ruleSetOptions[options.Tag] = options
 	}
 	return nil
 }
@@ -87,32 +140,14 @@ func (r *Router) Start(stage adapter.StartStage) error {
 	monitor := taskmonitor.New(r.logger, C.StartTimeout)
 	switch stage {
 	case adapter.StartStateStart:
-		var cacheContext *adapter.HTTPStartContext
 		if len(r.ruleSets) > 0 {
 			monitor.Start("initialize rule-set")
-			cacheContext = adapter.NewHTTPStartContext(r.ctx)
-			var ruleSetStartGroup task.Group
-			for i, ruleSet := range r.ruleSets {
-				ruleSetInPlace := ruleSet
-				ruleSetStartGroup.Append0(func(ctx context.Context) error {
-					err := ruleSetInPlace.StartContext(ctx, cacheContext)
-					if err != nil {
-						return E.Cause(err, "initialize rule-set[", i, "]")
-					}
-					return nil
-				})
-			}
-			ruleSetStartGroup.Concurrency(5)
-			ruleSetStartGroup.FastFail()
-			err := ruleSetStartGroup.Run(r.ctx)
+			err := r.startRuleSets(r.ruleSets)
 			monitor.Finish()
 			if err != nil {
 				return err
 			}
 		}
-		if cacheContext != nil {
-			cacheContext.Close()
-		}
 		needFindProcess := r.needFindProcess
 		for _, ruleSet := range r.ruleSets {
 			metadata := ruleSet.Metadata()
@@ -123,6 +158,7 @@ func (r *Router) Start(stage adapter.StartStage) error {
 				r.needWIFIState = true
 			}
 		}
+		r.needFindProcess = needFindProcess
 		if needFindProcess {
 			if r.platformInterface != nil {
 				r.processSearcher = r.platformInterface
@@ -131,6 +167,9 @@ func (r *Router) Start(stage adapter.StartStage) error {
 				searcher, err := process.NewSearcher(process.Config{
 					Logger:         r.logger,
 					PackageManager: r.network.PackageManager(),
+					FindCmdline:    r.needFindCmdline,
+					FindAncestors:  r.needFindAncestors,
+					FindCgroup:     r.needFindCgroup,
 				})
 				monitor.Finish()
 				if err != nil {
@@ -142,6 +181,34 @@ func (r *Router) Start(stage adapter.StartStage) error {
 				}
 			}
 		}
+		if r.needFindNeighbor {
+			deviceAliasMap, err := newDeviceAliasMap(r.deviceAlias)
+			if err != nil {
+				return err
+			}
+			r.deviceAliasMap = deviceAliasMap
+			monitor.Start("initialize neighbor resolver")
+			resolver, err := neighbor.NewResolver(r.logger)
+			monitor.Finish()
+			if err != nil {
+				if err == os.ErrInvalid {
+					r.logger.Warn("source_mac_address is only supported on Linux")
+				} else {
+					r.logger.Warn(E.Cause(err, "create neighbor resolver"))
+				}
+			} else {
+				r.neighborResolver = resolver
+			}
+		}
+		if r.needASN {
+			monitor.Start("open asn database")
+			reader, err := geoip.OpenASN(filemanager.BasePath(r.ctx, r.asnPath))
+			monitor.Finish()
+			if err != nil {
+				return E.Cause(err, "open asn database")
+			}
+			r.asnReader = reader
+		}
 	case adapter.StartStatePostStart:
 		for i, rule := range r.rules {
 			monitor.Start("initialize rule[", i, "]")
@@ -151,6 +218,11 @@ func (r *Router) Start(stage adapter.StartStage) error {
 				return E.Cause(err, "initialize rule[", i, "]")
 			}
 		}
+		scriptRuleSets, err := r.newScriptRuleSetItems(r)
+		if err != nil {
+			return err
+		}
+		r.scriptRuleSets = scriptRuleSets
 		for _, ruleSet := range r.ruleSets {
 			monitor.Start("post start rule_set[", ruleSet.Name(), "]")
 			err := ruleSet.PostStart()
@@ -170,8 +242,29 @@ func (r *Router) Start(stage adapter.StartStage) error {
 	return nil
 }
 
+func (r *Router) startRuleSets(ruleSets []adapter.RuleSet) error {
+	cacheContext := adapter.NewHTTPStartContext(r.ctx)
+	defer cacheContext.Close()
+	var ruleSetStartGroup task.Group
+	for _, ruleSet := range ruleSets {
+		ruleSetInPlace := ruleSet
+		ruleSetStartGroup.Append0(func(ctx context.Context) error {
+			err := ruleSetInPlace.StartContext(ctx, cacheContext)
+			if err != nil {
+				return E.Cause(err, "initialize rule-set[", ruleSetInPlace.Name(), "]")
+			}
+			return nil
+		})
+	}
+	ruleSetStartGroup.Concurrency(5)
+	ruleSetStartGroup.FastFail()
+	return ruleSetStartGroup.Run(r.ctx)
+}
+
 func (r *Router) Close() error {
 	monitor := taskmonitor.New(r.logger, C.StopTimeout)
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
 	var err error
 	for i, rule := range r.rules {
 		monitor.Start("close rule[", i, "]")
@@ -180,22 +273,88 @@ func (r *Router) Close() error {
 		})
 		monitor.Finish()
 	}
+	closeScriptRuleSetItems(r.scriptRuleSets)
+	if r.neighborResolver != nil {
+		err = E.Append(err, r.neighborResolver.Close(), func(err error) error {
+			return E.Cause(err, "close neighbor resolver")
+		})
+	}
+	if r.asnReader != nil {
+		err = E.Append(err, r.asnReader.Close(), func(err error) error {
+			return E.Cause(err, "close asn database")
+		})
+	}
 	return err
 }
 
 func (r *Router) RuleSet(tag string) (adapter.RuleSet, bool) {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
 	ruleSet, loaded := r.ruleSetMap[tag]
 	return ruleSet, loaded
 }
 
+func (r *Router) RuleSets() []adapter.RuleSet {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
+	return r.ruleSets
+}
+
+func (r *Router) LookupASN(addr netip.Addr) (geoip.ASN, bool) {
+	if r.asnReader == nil {
+		return geoip.ASN{}, false
+	}
+	return r.asnReader.Lookup(addr)
+}
+
 func (r *Router) NeedWIFIState() bool {
 	return r.needWIFIState
 }
 
+func (r *Router) HasDeviceAlias(alias string) bool {
+	_, loaded := r.deviceAlias[alias]
+	return loaded
+}
+
+func newDeviceAliasMap(deviceAlias map[string]badoption.Listable[string]) (map[string]string, error) {
+	deviceAliasMap := make(map[string]string)
+	for alias, addresses := range deviceAlias {
+		for _, address := range addresses {
+			hardwareAddr, err := net.ParseMAC(address)
+			if err != nil {
+				return nil, E.Cause(err, "parse device_alias[", alias, "]")
+			}
+			deviceAliasMap[hardwareAddr.String()] = alias
+		}
+	}
+	return deviceAliasMap, nil
+}
+
 func (r *Router) Rules() []adapter.Rule {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
 	return r.rules
 }
 
+func (r *Router) RulesWithStatistics() ([]adapter.Rule, []*adapter.RuleStatistics) {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
+	return r.rules, r.ruleStatistics
+}
+
+// selectedRuleStatistics returns nil if rules were reloaded after the rule is matched.
+func (r *Router) selectedRuleStatistics(selectedRule adapter.Rule, selectedRuleIndex int) *adapter.RuleStatistics {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
+	if scriptRule, isScriptRule := selectedRule.(*scriptRoutedRule); isScriptRule {
+		selectedRule = scriptRule.Rule
+	}
+	if selectedRuleIndex >= len(r.rules) || r.rules[selectedRuleIndex] != selectedRule {
+		return nil
+	}
+	return r.ruleStatistics[selectedRuleIndex]
+}
+
 func (r *Router) SetTracker(tracker adapter.ConnectionTracker) {
 	r.tracker = tracker
 }
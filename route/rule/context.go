@@ -0,0 +1,24 @@
+package rule
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/service"
+)
+
+type routerContextKey struct{}
+
+// ContextWithRouter overrides the router used by rules created with the context,
+// e.g. to resolve rule-sets that are not yet active during a reload.
+func ContextWithRouter(ctx context.Context, router adapter.Router) context.Context {
+	return context.WithValue(ctx, routerContextKey{}, router)
+}
+
+func routerFromContext(ctx context.Context) adapter.Router {
+	router, loaded := ctx.Value(routerContextKey{}).(adapter.Router)
+	if loaded {
+		return router
+	}
+	return service.FromContext[adapter.Router](ctx)
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/nat64"
+	"github.com/sagernet/sing-box/common/ratelimit"
 	"github.com/sagernet/sing-box/common/sniff"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -17,6 +19,7 @@ import (
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json/badoption"
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
@@ -27,31 +30,18 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 	case "":
 		return nil, nil
 	case C.RuleActionTypeRoute:
+		routeOptions, err := newRuleActionRouteOptions(option.RouteOptionsActionOptions(action.RouteOptions.RawRouteOptionsActionOptions))
+		if err != nil {
+			return nil, err
+		}
+		// udp_timeout is only applied by route-options
+		routeOptions.UDPTimeout = 0
 		return &RuleActionRoute{
-			Outbound: action.RouteOptions.Outbound,
-			RuleActionRouteOptions: RuleActionRouteOptions{
-				OverrideAddress:           M.ParseSocksaddrHostPort(action.RouteOptions.OverrideAddress, 0),
-				OverridePort:              action.RouteOptions.OverridePort,
-				NetworkStrategy:           (*C.NetworkStrategy)(action.RouteOptions.NetworkStrategy),
-				FallbackDelay:             time.Duration(action.RouteOptions.FallbackDelay),
-				UDPDisableDomainUnmapping: action.RouteOptions.UDPDisableDomainUnmapping,
-				UDPConnect:                action.RouteOptions.UDPConnect,
-				TLSFragment:               action.RouteOptions.TLSFragment,
-				TLSFragmentFallbackDelay:  time.Duration(action.RouteOptions.TLSFragmentFallbackDelay),
-			},
+			Outbound:               action.RouteOptions.Outbound,
+			RuleActionRouteOptions: *routeOptions,
 		}, nil
 	case C.RuleActionTypeRouteOptions:
-		return &RuleActionRouteOptions{
-			OverrideAddress:           M.ParseSocksaddrHostPort(action.RouteOptionsOptions.OverrideAddress, 0),
-			OverridePort:              action.RouteOptionsOptions.OverridePort,
-			NetworkStrategy:           (*C.NetworkStrategy)(action.RouteOptionsOptions.NetworkStrategy),
-			FallbackDelay:             time.Duration(action.RouteOptionsOptions.FallbackDelay),
-			UDPDisableDomainUnmapping: action.RouteOptionsOptions.UDPDisableDomainUnmapping,
-			UDPConnect:                action.RouteOptionsOptions.UDPConnect,
-			UDPTimeout:                time.Duration(action.RouteOptionsOptions.UDPTimeout),
-			TLSFragment:               action.RouteOptionsOptions.TLSFragment,
-			TLSFragmentFallbackDelay:  time.Duration(action.RouteOptionsOptions.TLSFragmentFallbackDelay),
-		}, nil
+		return newRuleActionRouteOptions(action.RouteOptionsOptions)
 	case C.RuleActionTypeDirect:
 		directDialer, err := dialer.New(ctx, option.DialerOptions(action.DirectOptions), false)
 		if err != nil {
@@ -87,15 +77,96 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 		}
 		return sniffAction, sniffAction.build()
 	case C.RuleActionTypeResolve:
+		nat64Prefix := netip.Prefix(common.PtrValueOrDefault(action.ResolveOptions.NAT64Prefix))
+		if nat64Prefix.IsValid() {
+			err := nat64.ValidatePrefix(nat64Prefix)
+			if err != nil {
+				return nil, E.Cause(err, "nat64_prefix")
+			}
+		}
 		return &RuleActionResolve{
-			Strategy: C.DomainStrategy(action.ResolveOptions.Strategy),
-			Server:   action.ResolveOptions.Server,
+			Strategy:    C.DomainStrategy(action.ResolveOptions.Strategy),
+			Server:      action.ResolveOptions.Server,
+			NAT64Prefix: nat64Prefix,
+		}, nil
+	case C.RuleActionTypeScript:
+		function := action.ScriptOptions.Function
+		if function == "" {
+			function = "main"
+		}
+		script := routerFromContext(ctx).Script()
+		if script == nil {
+			return nil, E.New("missing route.script")
+		}
+		if !script.HasFunction(function) {
+			return nil, E.New("script function not found: ", function)
+		}
+		return &RuleActionScript{
+			Function: function,
 		}, nil
 	default:
 		panic(F.ToString("unknown rule action: ", action.Action))
 	}
 }
 
+func newRuleActionRouteOptions(options option.RouteOptionsActionOptions) (*RuleActionRouteOptions, error) {
+	routeOptions := &RuleActionRouteOptions{
+		OverrideAddress:           M.ParseSocksaddrHostPort(options.OverrideAddress, 0),
+		OverridePort:              options.OverridePort,
+		NetworkStrategy:           (*C.NetworkStrategy)(options.NetworkStrategy),
+		FallbackDelay:             time.Duration(options.FallbackDelay),
+		UDPDisableDomainUnmapping: options.UDPDisableDomainUnmapping,
+		UDPConnect:                options.UDPConnect,
+		UDPTimeout:                time.Duration(options.UDPTimeout),
+		TLSFragment:               options.TLSFragment,
+		TLSFragmentFallbackDelay:  time.Duration(options.TLSFragmentFallbackDelay),
+		QUICFragment:              options.QUICFragment,
+		QUICFragmentReorder:       options.QUICFragmentReorder,
+		QUICFragmentPadding:       options.QUICFragmentPadding,
+		HTTPHostSplit:             options.HTTPHostSplit,
+		HTTPHostMixCase:           options.HTTPHostMixCase,
+		HTTPHostExtraSpace:        options.HTTPHostExtraSpace,
+	}
+	if options.LimitUploadMbps < 0 || options.LimitDownloadMbps < 0 {
+		return nil, E.New("invalid rate limit")
+	}
+	if options.LimitUploadMbps > 0 || options.LimitDownloadMbps > 0 {
+		switch options.LimitKey {
+		case "":
+			routeOptions.RateLimitKey = C.RuleActionLimitKeyRule
+		case C.RuleActionLimitKeyRule, C.RuleActionLimitKeyAuthUser, C.RuleActionLimitKeySourceIP, C.RuleActionLimitKeyConnection:
+			routeOptions.RateLimitKey = options.LimitKey
+		default:
+			return nil, E.New("unknown limit key: ", options.LimitKey)
+		}
+		routeOptions.RateLimit = ratelimit.NewGroup(
+			uint64(options.LimitUploadMbps)*C.MbpsToBps,
+			uint64(options.LimitDownloadMbps)*C.MbpsToBps,
+			routeOptions.RateLimitKey == C.RuleActionLimitKeyConnection,
+		)
+	} else if options.LimitKey != "" {
+		return nil, E.New("limit_key requires limit_upload_mbps or limit_download_mbps")
+	}
+	return routeOptions, nil
+}
+
+func validateDNSRuleAction(action option.DNSRuleAction) error {
+	var dns64Prefix *badoption.Prefix
+	switch action.Action {
+	case C.RuleActionTypeRoute:
+		dns64Prefix = action.RouteOptions.DNS64Prefix
+	case C.RuleActionTypeRouteOptions:
+		dns64Prefix = action.RouteOptionsOptions.DNS64Prefix
+	}
+	if dns64Prefix != nil {
+		err := nat64.ValidatePrefix(netip.Prefix(*dns64Prefix))
+		if err != nil {
+			return E.Cause(err, "dns64_prefix")
+		}
+	}
+	return nil
+}
+
 func NewDNSRuleAction(logger logger.ContextLogger, action option.DNSRuleAction) adapter.RuleAction {
 	switch action.Action {
 	case "":
@@ -108,6 +179,7 @@ func NewDNSRuleAction(logger logger.ContextLogger, action option.DNSRuleAction)
 				DisableCache: action.RouteOptions.DisableCache,
 				RewriteTTL:   action.RouteOptions.RewriteTTL,
 				ClientSubnet: netip.Prefix(common.PtrValueOrDefault(action.RouteOptions.ClientSubnet)),
+				DNS64Prefix:  netip.Prefix(common.PtrValueOrDefault(action.RouteOptions.DNS64Prefix)),
 			},
 		}
 	case C.RuleActionTypeRouteOptions:
@@ -116,6 +188,7 @@ func NewDNSRuleAction(logger logger.ContextLogger, action option.DNSRuleAction)
 			DisableCache: action.RouteOptionsOptions.DisableCache,
 			RewriteTTL:   action.RouteOptionsOptions.RewriteTTL,
 			ClientSubnet: netip.Prefix(common.PtrValueOrDefault(action.RouteOptionsOptions.ClientSubnet)),
+			DNS64Prefix:  netip.Prefix(common.PtrValueOrDefault(action.RouteOptionsOptions.DNS64Prefix)),
 		}
 	case C.RuleActionTypeReject:
 		return &RuleActionReject{
@@ -149,6 +222,15 @@ func (r *RuleActionRoute) String() string {
 	if r.TLSFragment {
 		descriptions = append(descriptions, "tls-fragment")
 	}
+	if r.QUICFragment {
+		descriptions = append(descriptions, "quic-fragment")
+	}
+	if r.HTTPHostSplit || r.HTTPHostMixCase || r.HTTPHostExtraSpace {
+		descriptions = append(descriptions, "http-host-desync")
+	}
+	if r.RateLimit != nil {
+		descriptions = append(descriptions, "limit="+r.RateLimitKey)
+	}
 	return F.ToString("route(", strings.Join(descriptions, ","), ")")
 }
 
@@ -164,6 +246,14 @@ type RuleActionRouteOptions struct {
 	UDPTimeout                time.Duration
 	TLSFragment               bool
 	TLSFragmentFallbackDelay  time.Duration
+	QUICFragment              bool
+	QUICFragmentReorder       bool
+	QUICFragmentPadding       bool
+	HTTPHostSplit             bool
+	HTTPHostMixCase           bool
+	HTTPHostExtraSpace        bool
+	RateLimit                 *ratelimit.Group
+	RateLimitKey              string
 }
 
 func (r *RuleActionRouteOptions) Type() string {
@@ -181,6 +271,18 @@ func (r *RuleActionRouteOptions) String() string {
 	if r.UDPTimeout > 0 {
 		descriptions = append(descriptions, "udp-timeout")
 	}
+	if r.TLSFragment {
+		descriptions = append(descriptions, "tls-fragment")
+	}
+	if r.QUICFragment {
+		descriptions = append(descriptions, "quic-fragment")
+	}
+	if r.HTTPHostSplit || r.HTTPHostMixCase || r.HTTPHostExtraSpace {
+		descriptions = append(descriptions, "http-host-desync")
+	}
+	if r.RateLimit != nil {
+		descriptions = append(descriptions, "limit="+r.RateLimitKey)
+	}
 	return F.ToString("route-options(", strings.Join(descriptions, ","), ")")
 }
 
@@ -205,6 +307,9 @@ func (r *RuleActionDNSRoute) String() string {
 	if r.ClientSubnet.IsValid() {
 		descriptions = append(descriptions, F.ToString("client-subnet=", r.ClientSubnet))
 	}
+	if r.DNS64Prefix.IsValid() {
+		descriptions = append(descriptions, F.ToString("dns64-prefix=", r.DNS64Prefix))
+	}
 	return F.ToString("route(", strings.Join(descriptions, ","), ")")
 }
 
@@ -213,6 +318,7 @@ type RuleActionDNSRouteOptions struct {
 	DisableCache bool
 	RewriteTTL   *uint32
 	ClientSubnet netip.Prefix
+	DNS64Prefix  netip.Prefix
 }
 
 func (r *RuleActionDNSRouteOptions) Type() string {
@@ -230,6 +336,9 @@ func (r *RuleActionDNSRouteOptions) String() string {
 	if r.ClientSubnet.IsValid() {
 		descriptions = append(descriptions, F.ToString("client-subnet=", r.ClientSubnet))
 	}
+	if r.DNS64Prefix.IsValid() {
+		descriptions = append(descriptions, F.ToString("dns64-prefix=", r.DNS64Prefix))
+	}
 	return F.ToString("route-options(", strings.Join(descriptions, ","), ")")
 }
 
@@ -358,8 +467,9 @@ func (r *RuleActionSniff) String() string {
 }
 
 type RuleActionResolve struct {
-	Strategy C.DomainStrategy
-	Server   string
+	Strategy    C.DomainStrategy
+	Server      string
+	NAT64Prefix netip.Prefix
 }
 
 func (r *RuleActionResolve) Type() string {
@@ -367,13 +477,30 @@ func (r *RuleActionResolve) Type() string {
 }
 
 func (r *RuleActionResolve) String() string {
-	if r.Strategy == C.DomainStrategyAsIS && r.Server == "" {
-		return F.ToString("resolve")
-	} else if r.Strategy != C.DomainStrategyAsIS && r.Server == "" {
-		return F.ToString("resolve(", option.DomainStrategy(r.Strategy).String(), ")")
-	} else if r.Strategy == C.DomainStrategyAsIS && r.Server != "" {
-		return F.ToString("resolve(", r.Server, ")")
-	} else {
-		return F.ToString("resolve(", option.DomainStrategy(r.Strategy).String(), ",", r.Server, ")")
+	var descriptions []string
+	if r.Strategy != C.DomainStrategyAsIS {
+		descriptions = append(descriptions, option.DomainStrategy(r.Strategy).String())
+	}
+	if r.Server != "" {
+		descriptions = append(descriptions, r.Server)
+	}
+	if r.NAT64Prefix.IsValid() {
+		descriptions = append(descriptions, F.ToString("nat64-prefix=", r.NAT64Prefix))
+	}
+	if len(descriptions) == 0 {
+		return "resolve"
 	}
+	return F.ToString("resolve(", strings.Join(descriptions, ","), ")")
+}
+
+type RuleActionScript struct {
+	Function string
+}
+
+func (r *RuleActionScript) Type() string {
+	return C.RuleActionTypeScript
+}
+
+func (r *RuleActionScript) String() string {
+	return F.ToString("script(", r.Function, ")")
 }
@@ -64,7 +64,7 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 			action: action,
 		},
 	}
-	router := service.FromContext[adapter.Router](ctx)
+	router := routerFromContext(ctx)
 	networkManager := service.FromContext[adapter.NetworkManager](ctx)
 	if len(options.Inbound) > 0 {
 		item := NewInboundRule(options.Inbound)
@@ -101,6 +101,34 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.ALPN) > 0 {
+		item := NewALPNItem(options.ALPN)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if options.TLSECH {
+		item := NewTLSECHItem()
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPMethod) > 0 {
+		item := NewHTTPMethodItem(options.HTTPMethod)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPPathRegex) > 0 {
+		item, err := NewHTTPPathRegexItem(options.HTTPPathRegex)
+		if err != nil {
+			return nil, E.Cause(err, "http_path_regex")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPUserAgent) > 0 {
+		item := NewHTTPUserAgentItem(options.HTTPUserAgent)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.Domain) > 0 || len(options.DomainSuffix) > 0 {
 		item := NewDomainItem(options.Domain, options.DomainSuffix)
 		rule.destinationAddressItems = append(rule.destinationAddressItems, item)
@@ -141,6 +169,14 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.SourceIPASN) > 0 {
+		item, err := NewASNItem(router, true, options.SourceIPASN)
+		if err != nil {
+			return nil, E.Cause(err, "source_ip_asn")
+		}
+		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.IPCIDR) > 0 {
 		item, err := NewIPCIDRItem(false, options.IPCIDR)
 		if err != nil {
@@ -154,6 +190,14 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.destinationIPCIDRItems = append(rule.destinationIPCIDRItems, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.IPASN) > 0 {
+		item, err := NewASNItem(router, false, options.IPASN)
+		if err != nil {
+			return nil, E.Cause(err, "ip_asn")
+		}
+		rule.destinationIPCIDRItems = append(rule.destinationIPCIDRItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.SourcePort) > 0 {
 		item := NewPortItem(true, options.SourcePort)
 		rule.sourcePortItems = append(rule.sourcePortItems, item)
@@ -167,6 +211,14 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.sourcePortItems = append(rule.sourcePortItems, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.SourceMACAddress) > 0 {
+		item, err := NewSourceMACAddressItem(router, options.SourceMACAddress)
+		if err != nil {
+			return nil, E.Cause(err, "source_mac_address")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.Port) > 0 {
 		item := NewPortItem(false, options.Port)
 		rule.destinationPortItems = append(rule.destinationPortItems, item)
@@ -198,6 +250,37 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.ProcessParentName) > 0 {
+		item := NewProcessParentNameItem(options.ProcessParentName)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.ProcessAncestorPath) > 0 {
+		item := NewProcessAncestorPathItem(options.ProcessAncestorPath)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.ProcessCmdlineRegex) > 0 {
+		item, err := NewProcessCmdlineRegexItem(options.ProcessCmdlineRegex)
+		if err != nil {
+			return nil, E.Cause(err, "process_cmdline_regex")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.ProcessCgroup) > 0 {
+		item := NewProcessCgroupItem(options.ProcessCgroup)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.ProcessCgroupRegex) > 0 {
+		item, err := NewProcessCgroupRegexItem(options.ProcessCgroupRegex)
+		if err != nil {
+			return nil, E.Cause(err, "process_cgroup_regex")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.PackageName) > 0 {
 		item := NewPackageNameItem(options.PackageName)
 		rule.items = append(rule.items, item)
@@ -243,6 +326,16 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.TimeRange) > 0 || len(options.Weekday) > 0 {
+		item, err := NewTimeItem(ctx, options.TimeRange, options.Weekday, options.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	} else if options.Timezone != "" {
+		return nil, E.New("timezone requires time_range or weekday")
+	}
 	if len(options.RuleSet) > 0 {
 		var matchSource bool
 		if options.RuleSetIPCIDRMatchSource {
@@ -257,6 +350,14 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.Script) > 0 {
+		item, err := NewScriptItem(router, logger, options.Script)
+		if err != nil {
+			return nil, E.Cause(err, "script")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	return rule, nil
 }
 
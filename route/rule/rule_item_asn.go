@@ -0,0 +1,69 @@
+package rule
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/geoip"
+)
+
+var _ RuleItem = (*ASNItem)(nil)
+
+type ASNItem struct {
+	router      adapter.Router
+	matcher     *geoip.ASNMatcher
+	isSource    bool
+	description string
+}
+
+func NewASNItem(router adapter.Router, isSource bool, values []string) (*ASNItem, error) {
+	matcher, err := geoip.NewASNMatcher(values)
+	if err != nil {
+		return nil, err
+	}
+	var description string
+	if isSource {
+		description = "source_ip_asn="
+	} else {
+		description = "ip_asn="
+	}
+	if len(values) == 1 {
+		description += values[0]
+	} else {
+		description += "[" + strings.Join(values, " ") + "]"
+	}
+	return &ASNItem{
+		router:      router,
+		matcher:     matcher,
+		isSource:    isSource,
+		description: description,
+	}, nil
+}
+
+func (r *ASNItem) Match(metadata *adapter.InboundContext) bool {
+	if r.isSource {
+		return r.match(metadata.Source.Addr)
+	}
+	if metadata.Destination.IsIP() {
+		return r.match(metadata.Destination.Addr)
+	}
+	for _, address := range metadata.DestinationAddresses {
+		if r.match(address) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ASNItem) match(addr netip.Addr) bool {
+	asn, loaded := r.router.LookupASN(addr)
+	if !loaded {
+		return false
+	}
+	return r.matcher.Match(asn)
+}
+
+func (r *ASNItem) String() string {
+	return r.description
+}
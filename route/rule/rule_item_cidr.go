@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/srs/mapped"
 	E "github.com/sagernet/sing/common/exceptions"
 
 	"go4.org/netipx"
@@ -13,11 +14,16 @@ import (
 var _ RuleItem = (*IPCIDRItem)(nil)
 
 type IPCIDRItem struct {
-	ipSet       *netipx.IPSet
+	ipSet       ipSet
 	isSource    bool
 	description string
 }
 
+// ipSet is implemented by *netipx.IPSet and *mapped.IPSet.
+type ipSet interface {
+	Contains(addr netip.Addr) bool
+}
+
 func NewIPCIDRItem(isSource bool, prefixStrings []string) (*IPCIDRItem, error) {
 	var builder netipx.IPSetBuilder
 	for i, prefixString := range prefixStrings {
@@ -72,6 +78,21 @@ func NewRawIPCIDRItem(isSource bool, ipSet *netipx.IPSet) *IPCIDRItem {
 	}
 }
 
+func NewMappedIPCIDRItem(isSource bool, ipSet *mapped.IPSet) *IPCIDRItem {
+	var description string
+	if isSource {
+		description = "source_ip_cidr="
+	} else {
+		description = "ip_cidr="
+	}
+	description += "<mapped>"
+	return &IPCIDRItem{
+		ipSet:       ipSet,
+		isSource:    isSource,
+		description: description,
+	}
+}
+
 func (r *IPCIDRItem) Match(metadata *adapter.InboundContext) bool {
 	if r.isSource || metadata.IPCIDRMatchSource {
 		return r.ipSet.Contains(metadata.Source.Addr)
@@ -4,16 +4,21 @@ import (
 	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/srs/mapped"
 	"github.com/sagernet/sing/common/domain"
 )
 
 var _ RuleItem = (*DomainItem)(nil)
 
 type DomainItem struct {
-	matcher     *domain.Matcher
+	matcher     domainMatcher
 	description string
 }
 
+type domainMatcher interface {
+	Match(domain string) bool
+}
+
 func NewDomainItem(domains []string, domainSuffixes []string) *DomainItem {
 	var description string
 	if dLen := len(domains); dLen > 0 {
@@ -50,6 +55,13 @@ func NewRawDomainItem(matcher *domain.Matcher) *DomainItem {
 	}
 }
 
+func NewMappedDomainItem(set *mapped.DomainSet) *DomainItem {
+	return &DomainItem{
+		set,
+		"domain/domain_suffix=<mapped>",
+	}
+}
+
 func (r *DomainItem) Match(metadata *adapter.InboundContext) bool {
 	var domainHost string
 	if metadata.Domain != "" {
@@ -0,0 +1,56 @@
+package rule
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMethodItem(t *testing.T) {
+	t.Parallel()
+	item := NewHTTPMethodItem([]string{"get", "POST"})
+	require.True(t, item.Match(&adapter.InboundContext{HTTPMethod: "GET"}))
+	require.True(t, item.Match(&adapter.InboundContext{HTTPMethod: "POST"}))
+	require.False(t, item.Match(&adapter.InboundContext{HTTPMethod: "PUT"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, "http_method=[get POST]", item.String())
+	require.Equal(t, "http_method=CONNECT", NewHTTPMethodItem([]string{"CONNECT"}).String())
+}
+
+func TestHTTPPathRegexItem(t *testing.T) {
+	t.Parallel()
+	item, err := NewHTTPPathRegexItem([]string{`^/api/v\d+/`, `\.m3u8$`})
+	require.NoError(t, err)
+	require.True(t, item.Match(&adapter.InboundContext{HTTPPath: "/api/v2/users"}))
+	require.True(t, item.Match(&adapter.InboundContext{HTTPPath: "/live/index.m3u8"}))
+	require.False(t, item.Match(&adapter.InboundContext{HTTPPath: "/static/api/v2/"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, `http_path_regex=[^/api/v\d+/ \.m3u8$]`, item.String())
+
+	item, err = NewHTTPPathRegexItem([]string{"^$"})
+	require.NoError(t, err)
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, "http_path_regex=^$", item.String())
+
+	item, err = NewHTTPPathRegexItem([]string{"a", "b", "c", "d"})
+	require.NoError(t, err)
+	require.Equal(t, "http_path_regex=[a b c...]", item.String())
+
+	_, err = NewHTTPPathRegexItem([]string{"/ok", "("})
+	require.ErrorContains(t, err, "parse expression 1")
+}
+
+func TestHTTPUserAgentItem(t *testing.T) {
+	t.Parallel()
+	item := NewHTTPUserAgentItem([]string{"curl/", "Firefox"})
+	require.True(t, item.Match(&adapter.InboundContext{HTTPUserAgent: "curl/8.5.0"}))
+	require.True(t, item.Match(&adapter.InboundContext{HTTPUserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"}))
+	require.False(t, item.Match(&adapter.InboundContext{HTTPUserAgent: "Mozilla/5.0 firefox/128.0"}))
+	require.False(t, item.Match(&adapter.InboundContext{HTTPUserAgent: "Wget/1.21"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, NewHTTPUserAgentItem([]string{""}).Match(&adapter.InboundContext{}))
+	require.Equal(t, "http_user_agent=[curl/ Firefox]", item.String())
+	require.Equal(t, "http_user_agent=[a b c...]", NewHTTPUserAgentItem([]string{"a", "b", "c", "d"}).String())
+}
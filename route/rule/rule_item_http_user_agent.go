@@ -0,0 +1,40 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var _ RuleItem = (*HTTPUserAgentItem)(nil)
+
+type HTTPUserAgentItem struct {
+	keywords []string
+}
+
+func NewHTTPUserAgentItem(keywords []string) *HTTPUserAgentItem {
+	return &HTTPUserAgentItem{keywords}
+}
+
+func (r *HTTPUserAgentItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.HTTPUserAgent == "" {
+		return false
+	}
+	for _, keyword := range r.keywords {
+		if strings.Contains(metadata.HTTPUserAgent, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPUserAgentItem) String() string {
+	kLen := len(r.keywords)
+	if kLen == 1 {
+		return "http_user_agent=" + r.keywords[0]
+	} else if kLen > 3 {
+		return "http_user_agent=[" + strings.Join(r.keywords[:3], " ") + "...]"
+	} else {
+		return "http_user_agent=[" + strings.Join(r.keywords, " ") + "]"
+	}
+}
@@ -0,0 +1,48 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var _ RuleItem = (*ProcessAncestorPathItem)(nil)
+
+type ProcessAncestorPathItem struct {
+	processes  []string
+	processMap map[string]bool
+}
+
+func NewProcessAncestorPathItem(processPathList []string) *ProcessAncestorPathItem {
+	rule := &ProcessAncestorPathItem{
+		processes:  processPathList,
+		processMap: make(map[string]bool),
+	}
+	for _, processPath := range processPathList {
+		rule.processMap[processPath] = true
+	}
+	return rule
+}
+
+func (r *ProcessAncestorPathItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.ProcessInfo == nil {
+		return false
+	}
+	for _, ancestorPath := range metadata.ProcessInfo.AncestorPaths {
+		if r.processMap[ancestorPath] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ProcessAncestorPathItem) String() string {
+	var description string
+	pLen := len(r.processes)
+	if pLen == 1 {
+		description = "process_ancestor_path=" + r.processes[0]
+	} else {
+		description = "process_ancestor_path=[" + strings.Join(r.processes, " ") + "]"
+	}
+	return description
+}
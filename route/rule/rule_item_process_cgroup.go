@@ -0,0 +1,47 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+)
+
+var _ RuleItem = (*ProcessCgroupItem)(nil)
+
+type ProcessCgroupItem struct {
+	prefixes    []string
+	cgroupPaths []string
+}
+
+func NewProcessCgroupItem(prefixes []string) *ProcessCgroupItem {
+	return &ProcessCgroupItem{
+		prefixes: prefixes,
+		cgroupPaths: common.Map(prefixes, func(it string) string {
+			return strings.TrimPrefix(it, "/")
+		}),
+	}
+}
+
+func (r *ProcessCgroupItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.ProcessInfo == nil || metadata.ProcessInfo.CgroupPath == "" {
+		return false
+	}
+	for _, cgroupPath := range r.cgroupPaths {
+		if strings.HasPrefix(metadata.ProcessInfo.CgroupPath, cgroupPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ProcessCgroupItem) String() string {
+	var description string
+	pLen := len(r.prefixes)
+	if pLen == 1 {
+		description = "process_cgroup=" + r.prefixes[0]
+	} else {
+		description = "process_cgroup=[" + strings.Join(r.prefixes, " ") + "]"
+	}
+	return description
+}
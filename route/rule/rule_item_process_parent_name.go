@@ -0,0 +1,43 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var _ RuleItem = (*ProcessParentNameItem)(nil)
+
+type ProcessParentNameItem struct {
+	processes  []string
+	processMap map[string]bool
+}
+
+func NewProcessParentNameItem(processNameList []string) *ProcessParentNameItem {
+	rule := &ProcessParentNameItem{
+		processes:  processNameList,
+		processMap: make(map[string]bool),
+	}
+	for _, processName := range processNameList {
+		rule.processMap[processName] = true
+	}
+	return rule
+}
+
+func (r *ProcessParentNameItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.ProcessInfo == nil || metadata.ProcessInfo.ParentProcessName == "" {
+		return false
+	}
+	return r.processMap[metadata.ProcessInfo.ParentProcessName]
+}
+
+func (r *ProcessParentNameItem) String() string {
+	var description string
+	pLen := len(r.processes)
+	if pLen == 1 {
+		description = "process_parent_name=" + r.processes[0]
+	} else {
+		description = "process_parent_name=[" + strings.Join(r.processes, " ") + "]"
+	}
+	return description
+}
@@ -0,0 +1,109 @@
+package rule
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/process"
+
+	"github.com/stretchr/testify/require"
+)
+
+func processMetadata(info process.Info) *adapter.InboundContext {
+	return &adapter.InboundContext{ProcessInfo: &info}
+}
+
+func TestProcessParentNameItem(t *testing.T) {
+	t.Parallel()
+	item := NewProcessParentNameItem([]string{"bash", "sshd"})
+	require.True(t, item.Match(processMetadata(process.Info{ParentProcessName: "bash"})))
+	require.True(t, item.Match(processMetadata(process.Info{ProcessPath: "/usr/bin/curl", ParentProcessName: "sshd"})))
+	require.False(t, item.Match(processMetadata(process.Info{ParentProcessName: "zsh"})))
+	require.False(t, item.Match(processMetadata(process.Info{ParentProcessName: "Bash"})))
+	require.False(t, item.Match(processMetadata(process.Info{ProcessPath: "/usr/bin/bash"})))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, NewProcessParentNameItem([]string{""}).Match(processMetadata(process.Info{})))
+	require.Equal(t, "process_parent_name=[bash sshd]", item.String())
+	require.Equal(t, "process_parent_name=bash", NewProcessParentNameItem([]string{"bash"}).String())
+}
+
+func TestProcessAncestorPathItem(t *testing.T) {
+	t.Parallel()
+	item := NewProcessAncestorPathItem([]string{"/usr/sbin/sshd", "/usr/bin/tmux"})
+	require.True(t, item.Match(processMetadata(process.Info{
+		AncestorPaths: []string{"/usr/bin/bash", "/usr/sbin/sshd", "/usr/lib/systemd/systemd"},
+	})))
+	require.True(t, item.Match(processMetadata(process.Info{
+		AncestorPaths: []string{"/usr/bin/tmux"},
+	})))
+	require.False(t, item.Match(processMetadata(process.Info{
+		ProcessPath:   "/usr/sbin/sshd",
+		AncestorPaths: []string{"/usr/lib/systemd/systemd"},
+	})))
+	require.False(t, item.Match(processMetadata(process.Info{
+		AncestorPaths: []string{"/usr/sbin"},
+	})))
+	require.False(t, item.Match(processMetadata(process.Info{})))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, "process_ancestor_path=[/usr/sbin/sshd /usr/bin/tmux]", item.String())
+	require.Equal(t, "process_ancestor_path=/usr/bin/tmux", NewProcessAncestorPathItem([]string{"/usr/bin/tmux"}).String())
+}
+
+func TestProcessCmdlineRegexItem(t *testing.T) {
+	t.Parallel()
+	item, err := NewProcessCmdlineRegexItem([]string{`^python3? .*manage\.py`, `--proxy-server=`})
+	require.NoError(t, err)
+	require.True(t, item.Match(processMetadata(process.Info{ProcessCmdline: "python3 /srv/app/manage.py runserver"})))
+	require.True(t, item.Match(processMetadata(process.Info{ProcessCmdline: "chromium --proxy-server=socks5://127.0.0.1:1080"})))
+	require.False(t, item.Match(processMetadata(process.Info{ProcessCmdline: "/usr/bin/python3 manage.py"})))
+	require.False(t, item.Match(processMetadata(process.Info{ProcessPath: "/usr/bin/python3"})))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, `process_cmdline_regex=[^python3? .*manage\.py --proxy-server=]`, item.String())
+
+	item, err = NewProcessCmdlineRegexItem([]string{"^$"})
+	require.NoError(t, err)
+	require.False(t, item.Match(processMetadata(process.Info{})))
+	require.Equal(t, "process_cmdline_regex=^$", item.String())
+
+	item, err = NewProcessCmdlineRegexItem([]string{"a", "b", "c", "d"})
+	require.NoError(t, err)
+	require.Equal(t, "process_cmdline_regex=[a b c]", item.String())
+
+	_, err = NewProcessCmdlineRegexItem([]string{"curl", "["})
+	require.ErrorContains(t, err, "parse expression 1")
+}
+
+func TestProcessCgroupItem(t *testing.T) {
+	t.Parallel()
+	item := NewProcessCgroupItem([]string{"/system.slice/foo", "user.slice/user-1000.slice/"})
+	require.True(t, item.Match(processMetadata(process.Info{CgroupPath: "system.slice/foo.service"})))
+	require.True(t, item.Match(processMetadata(process.Info{CgroupPath: "system.slice/foobar.service"})))
+	require.True(t, item.Match(processMetadata(process.Info{CgroupPath: "user.slice/user-1000.slice/session-2.scope"})))
+	require.False(t, item.Match(processMetadata(process.Info{CgroupPath: "user.slice/user-1000.slice"})))
+	require.False(t, item.Match(processMetadata(process.Info{CgroupPath: "system.slice/bar.service"})))
+	require.False(t, item.Match(processMetadata(process.Info{})))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, NewProcessCgroupItem([]string{"/"}).Match(processMetadata(process.Info{})))
+	require.True(t, NewProcessCgroupItem([]string{"/"}).Match(processMetadata(process.Info{CgroupPath: "init.scope"})))
+	require.Equal(t, "process_cgroup=[/system.slice/foo user.slice/user-1000.slice/]", item.String())
+	require.Equal(t, "process_cgroup=system.slice", NewProcessCgroupItem([]string{"system.slice"}).String())
+}
+
+func TestProcessCgroupRegexItem(t *testing.T) {
+	t.Parallel()
+	item, err := NewProcessCgroupRegexItem([]string{`^system\.slice/docker-[0-9a-f]+\.scope$`, `/app-firefox-`})
+	require.NoError(t, err)
+	require.True(t, item.Match(processMetadata(process.Info{CgroupPath: "system.slice/docker-4f2a9c.scope"})))
+	require.True(t, item.Match(processMetadata(process.Info{CgroupPath: "user.slice/user-1000.slice/user@1000.service/app.slice/app-firefox-1234.scope"})))
+	require.False(t, item.Match(processMetadata(process.Info{CgroupPath: "/system.slice/docker-4f2a9c.scope"})))
+	require.False(t, item.Match(processMetadata(process.Info{CgroupPath: "system.slice/docker.service"})))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, `process_cgroup_regex=[^system\.slice/docker-[0-9a-f]+\.scope$ /app-firefox-]`, item.String())
+
+	item, err = NewProcessCgroupRegexItem([]string{"^$"})
+	require.NoError(t, err)
+	require.False(t, item.Match(processMetadata(process.Info{})))
+
+	_, err = NewProcessCgroupRegexItem([]string{"system.slice", "(?P<"})
+	require.ErrorContains(t, err, "parse expression 1")
+}
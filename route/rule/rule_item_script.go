@@ -0,0 +1,87 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+var _ RuleItem = (*ScriptItem)(nil)
+
+type ScriptItem struct {
+	router    adapter.Router
+	logger    log.ContextLogger
+	functions []string
+}
+
+func NewScriptItem(router adapter.Router, logger log.ContextLogger, functions []string) (*ScriptItem, error) {
+	script := router.Script()
+	if script == nil {
+		return nil, E.New("missing route.script")
+	}
+	for _, function := range functions {
+		if !script.HasFunction(function) {
+			return nil, E.New("script function not found: ", function)
+		}
+	}
+	return &ScriptItem{
+		router:    router,
+		logger:    logger,
+		functions: functions,
+	}, nil
+}
+
+func (r *ScriptItem) Match(metadata *adapter.InboundContext) bool {
+	script := r.router.Script()
+	if script == nil {
+		return false
+	}
+	for _, function := range r.functions {
+		result, err := script.Call(function, metadata)
+		if err != nil {
+			r.logger.Error(E.Cause(err, "call script function ", function))
+			continue
+		}
+		matched, isBool := result.(bool)
+		if !isBool {
+			r.logger.Error("script function ", function, " returned ", result, ", expected bool")
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ScriptItem) String() string {
+	if len(r.functions) == 1 {
+		return "script=" + r.functions[0]
+	}
+	return "script=[" + strings.Join(r.functions, " ") + "]"
+}
+
+// ScriptFunctions returns the script functions referenced by the rule, its sub rules and actions.
+func ScriptFunctions(rule adapter.HeadlessRule) []string {
+	var functions []string
+	switch currentRule := rule.(type) {
+	case *DefaultRule:
+		for _, item := range currentRule.items {
+			if scriptItem, isScript := item.(*ScriptItem); isScript {
+				functions = append(functions, scriptItem.functions...)
+			}
+		}
+	case *LogicalRule:
+		for _, subRule := range currentRule.rules {
+			functions = append(functions, ScriptFunctions(subRule)...)
+		}
+	}
+	if actionRule, isRule := rule.(adapter.Rule); isRule {
+		if action, isScript := actionRule.Action().(*RuleActionScript); isScript {
+			functions = append(functions, action.Function)
+		}
+	}
+	return functions
+}
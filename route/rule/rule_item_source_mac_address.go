@@ -0,0 +1,55 @@
+package rule
+
+import (
+	"net"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*SourceMACAddressItem)(nil)
+
+type SourceMACAddressItem struct {
+	addresses  []string
+	addressMap map[string]bool
+	aliasMap   map[string]bool
+}
+
+func NewSourceMACAddressItem(router adapter.Router, addresses []string) (*SourceMACAddressItem, error) {
+	addressMap := make(map[string]bool)
+	aliasMap := make(map[string]bool)
+	for _, address := range addresses {
+		hardwareAddr, err := net.ParseMAC(address)
+		if err == nil {
+			addressMap[hardwareAddr.String()] = true
+		} else if router != nil && router.HasDeviceAlias(address) {
+			aliasMap[address] = true
+		} else {
+			return nil, E.New("invalid MAC address or unknown device alias: ", address)
+		}
+	}
+	return &SourceMACAddressItem{
+		addresses:  addresses,
+		addressMap: addressMap,
+		aliasMap:   aliasMap,
+	}, nil
+}
+
+func (r *SourceMACAddressItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.SourceMACAddress == nil {
+		return false
+	}
+	if metadata.SourceDevice != "" && r.aliasMap[metadata.SourceDevice] {
+		return true
+	}
+	return r.addressMap[metadata.SourceMACAddress.String()]
+}
+
+func (r *SourceMACAddressItem) String() string {
+	if len(r.addresses) == 1 {
+		return F.ToString("source_mac_address=", r.addresses[0])
+	}
+	return F.ToString("source_mac_address=[", strings.Join(r.addresses, " "), "]")
+}
@@ -0,0 +1,172 @@
+package rule
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	// embedded so that timezone works without system zoneinfo, e.g. on Windows and Android
+	_ "time/tzdata"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/ntp"
+)
+
+var _ RuleItem = (*TimeItem)(nil)
+
+type TimeItem struct {
+	ctx         context.Context
+	timeRanges  []timeRange
+	weekdays    map[time.Weekday]bool
+	location    *time.Location
+	description string
+}
+
+type timeRange struct {
+	start int
+	end   int
+}
+
+func NewTimeItem(ctx context.Context, timeRangeList []string, weekdayList []string, timezone string) (*TimeItem, error) {
+	item := &TimeItem{
+		ctx:      ctx,
+		location: time.Local,
+	}
+	var descriptions []string
+	for _, rangeString := range timeRangeList {
+		parsedRange, err := parseTimeRange(rangeString)
+		if err != nil {
+			return nil, E.Cause(err, "parse time_range: ", rangeString)
+		}
+		item.timeRanges = append(item.timeRanges, parsedRange)
+	}
+	if len(timeRangeList) == 1 {
+		descriptions = append(descriptions, "time_range="+timeRangeList[0])
+	} else if len(timeRangeList) > 1 {
+		descriptions = append(descriptions, "time_range=["+strings.Join(timeRangeList, " ")+"]")
+	}
+	if len(weekdayList) > 0 {
+		item.weekdays = make(map[time.Weekday]bool)
+		for _, weekdayString := range weekdayList {
+			weekday, err := parseWeekday(weekdayString)
+			if err != nil {
+				return nil, err
+			}
+			item.weekdays[weekday] = true
+		}
+		if len(weekdayList) == 1 {
+			descriptions = append(descriptions, "weekday="+weekdayList[0])
+		} else {
+			descriptions = append(descriptions, "weekday=["+strings.Join(weekdayList, " ")+"]")
+		}
+	}
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, E.Cause(err, "load timezone")
+		}
+		item.location = location
+		descriptions = append(descriptions, "timezone="+timezone)
+	}
+	item.description = strings.Join(descriptions, " ")
+	return item, nil
+}
+
+func (r *TimeItem) Match(metadata *adapter.InboundContext) bool {
+	var now time.Time
+	if timeFunc := ntp.TimeFuncFromContext(r.ctx); timeFunc != nil {
+		now = timeFunc()
+	} else {
+		now = time.Now()
+	}
+	now = now.In(r.location)
+	weekday := now.Weekday()
+	minutes := now.Hour()*60 + now.Minute()
+	if len(r.timeRanges) == 0 {
+		return r.weekdays[weekday]
+	}
+	for _, currentRange := range r.timeRanges {
+		if currentRange.start < currentRange.end {
+			if minutes >= currentRange.start && minutes < currentRange.end && r.matchWeekday(weekday) {
+				return true
+			}
+		} else {
+			// ranges across midnight belong to the day they start
+			if minutes >= currentRange.start && r.matchWeekday(weekday) {
+				return true
+			}
+			if minutes < currentRange.end && r.matchWeekday((weekday+6)%7) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *TimeItem) matchWeekday(weekday time.Weekday) bool {
+	return r.weekdays == nil || r.weekdays[weekday]
+}
+
+func (r *TimeItem) String() string {
+	return r.description
+}
+
+func parseTimeRange(rangeString string) (timeRange, error) {
+	startString, endString, loaded := strings.Cut(rangeString, "-")
+	if !loaded {
+		return timeRange{}, E.New("missing '-'")
+	}
+	start, err := parseTimeOfDay(strings.TrimSpace(startString))
+	if err != nil {
+		return timeRange{}, E.Cause(err, "parse start time")
+	}
+	end, err := parseTimeOfDay(strings.TrimSpace(endString))
+	if err != nil {
+		return timeRange{}, E.Cause(err, "parse end time")
+	}
+	if start == 24*60 {
+		return timeRange{}, E.New("invalid start time: ", startString)
+	}
+	if start == end {
+		return timeRange{}, E.New("empty time range")
+	}
+	return timeRange{start, end}, nil
+}
+
+func parseTimeOfDay(timeString string) (int, error) {
+	hourString, minuteString, loaded := strings.Cut(timeString, ":")
+	if !loaded {
+		return 0, E.New("invalid time: ", timeString)
+	}
+	hour, err := strconv.ParseUint(hourString, 10, 8)
+	if err != nil || hour > 24 {
+		return 0, E.New("invalid hour: ", hourString)
+	}
+	minute, err := strconv.ParseUint(minuteString, 10, 8)
+	if err != nil || minute > 59 || hour == 24 && minute != 0 {
+		return 0, E.New("invalid minute: ", minuteString)
+	}
+	return int(hour*60 + minute), nil
+}
+
+func parseWeekday(weekdayString string) (time.Weekday, error) {
+	switch strings.ToLower(weekdayString) {
+	case "sunday", "sun":
+		return time.Sunday, nil
+	case "monday", "mon":
+		return time.Monday, nil
+	case "tuesday", "tue":
+		return time.Tuesday, nil
+	case "wednesday", "wed":
+		return time.Wednesday, nil
+	case "thursday", "thu":
+		return time.Thursday, nil
+	case "friday", "fri":
+		return time.Friday, nil
+	case "saturday", "sat":
+		return time.Saturday, nil
+	default:
+		return 0, E.New("unknown weekday: ", weekdayString)
+	}
+}
@@ -0,0 +1,85 @@
+package rule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fixedTimeService time.Time
+
+func (s fixedTimeService) TimeFunc() func() time.Time {
+	return func() time.Time {
+		return time.Time(s)
+	}
+}
+
+func TestTimeItem(t *testing.T) {
+	t.Parallel()
+	// 2024-01-01 is a Monday
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	for _, testCase := range []struct {
+		name      string
+		timeRange []string
+		weekday   []string
+		timezone  string
+		now       time.Time
+		match     bool
+	}{
+		{"range start", []string{"09:00-17:00"}, nil, "UTC", at(1, 9, 0), true},
+		{"range inside", []string{"09:00-17:00"}, nil, "UTC", at(1, 16, 59), true},
+		{"range end", []string{"09:00-17:00"}, nil, "UTC", at(1, 17, 0), false},
+		{"range before", []string{"09:00-17:00"}, nil, "UTC", at(1, 8, 59), false},
+		{"range until midnight", []string{"18:00-24:00"}, nil, "UTC", at(1, 23, 59), true},
+		{"multiple ranges", []string{"01:00-02:00", "03:00-04:00"}, nil, "UTC", at(1, 3, 30), true},
+		{"midnight wrap before", []string{"22:00-06:00"}, nil, "UTC", at(1, 23, 0), true},
+		{"midnight wrap after", []string{"22:00-06:00"}, nil, "UTC", at(2, 5, 59), true},
+		{"midnight wrap end", []string{"22:00-06:00"}, nil, "UTC", at(2, 6, 0), false},
+		{"midnight wrap outside", []string{"22:00-06:00"}, nil, "UTC", at(2, 12, 0), false},
+		{"weekday only", nil, []string{"mon"}, "UTC", at(1, 12, 0), true},
+		{"weekday only other day", nil, []string{"saturday", "sun"}, "UTC", at(1, 12, 0), false},
+		{"weekday boundary", nil, []string{"sun"}, "UTC", at(7, 23, 59), true},
+		{"weekday boundary next", nil, []string{"sun"}, "UTC", at(8, 0, 0), false},
+		{"range and weekday", []string{"09:00-17:00"}, []string{"tue"}, "UTC", at(1, 10, 0), false},
+		{"midnight wrap on start day", []string{"22:00-06:00"}, []string{"mon"}, "UTC", at(2, 5, 0), true},
+		{"midnight wrap on next day", []string{"22:00-06:00"}, []string{"mon"}, "UTC", at(1, 5, 0), false},
+		{"timezone", []string{"09:00-10:00"}, nil, "Asia/Shanghai", at(1, 1, 30), true},
+		{"timezone outside", []string{"09:00-10:00"}, nil, "Asia/Shanghai", at(1, 9, 30), false},
+		{"timezone weekday", nil, []string{"tue"}, "Asia/Tokyo", at(1, 20, 0), true},
+		{"timezone weekday behind", nil, []string{"sun"}, "America/New_York", at(1, 3, 0), true},
+	} {
+		ctx := service.ContextWith[ntp.TimeService](context.Background(), fixedTimeService(testCase.now))
+		item, err := NewTimeItem(ctx, testCase.timeRange, testCase.weekday, testCase.timezone)
+		require.NoError(t, err, testCase.name)
+		require.Equal(t, testCase.match, item.Match(&adapter.InboundContext{}), testCase.name)
+	}
+}
+
+func TestTimeItemInvalid(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		timeRange []string
+		weekday   []string
+		timezone  string
+	}{
+		{timeRange: []string{"09:00"}},
+		{timeRange: []string{"25:00-01:00"}},
+		{timeRange: []string{"09:60-10:00"}},
+		{timeRange: []string{"24:00-01:00"}},
+		{timeRange: []string{"24:30-01:00"}},
+		{timeRange: []string{"10:00-10:00"}},
+		{weekday: []string{"someday"}},
+		{timeRange: []string{"09:00-10:00"}, timezone: "Mars/Olympus_Mons"},
+	} {
+		_, err := NewTimeItem(context.Background(), testCase.timeRange, testCase.weekday, testCase.timezone)
+		require.Error(t, err, testCase)
+	}
+}
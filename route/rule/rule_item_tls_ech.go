@@ -0,0 +1,21 @@
+package rule
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var _ RuleItem = (*TLSECHItem)(nil)
+
+type TLSECHItem struct{}
+
+func NewTLSECHItem() *TLSECHItem {
+	return &TLSECHItem{}
+}
+
+func (r *TLSECHItem) Match(metadata *adapter.InboundContext) bool {
+	return metadata.ECH
+}
+
+func (r *TLSECHItem) String() string {
+	return "tls_ech=true"
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/convertor/clash"
+	"github.com/sagernet/sing-box/common/srs/mapped"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
@@ -19,6 +21,8 @@ func NewRuleSet(ctx context.Context, logger logger.ContextLogger, options option
 		return NewLocalRuleSet(ctx, logger, options)
 	case C.RuleSetTypeRemote:
 		return NewRemoteRuleSet(ctx, logger, options), nil
+	case C.RuleSetTypeMutable:
+		return NewMutableRuleSet(ctx, logger, options), nil
 	default:
 		return nil, E.New("unknown rule-set type: ", options.Type)
 	}
@@ -30,7 +34,18 @@ func extractIPSetFromRule(rawRule adapter.HeadlessRule) []*netipx.IPSet {
 		return common.FlatMap(rule.destinationIPCIDRItems, func(rawItem RuleItem) []*netipx.IPSet {
 			switch item := rawItem.(type) {
 			case *IPCIDRItem:
-				return []*netipx.IPSet{item.ipSet}
+				switch set := item.ipSet.(type) {
+				case *netipx.IPSet:
+					return []*netipx.IPSet{set}
+				case *mapped.IPSet:
+					ipSet, err := set.IPSet()
+					if err != nil {
+						return nil
+					}
+					return []*netipx.IPSet{ipSet}
+				default:
+					return nil
+				}
 			default:
 				return nil
 			}
@@ -67,5 +82,67 @@ func isWIFIHeadlessRule(rule option.DefaultHeadlessRule) bool {
 }
 
 func isIPCIDRHeadlessRule(rule option.DefaultHeadlessRule) bool {
-	return len(rule.IPCIDR) > 0 || rule.IPSet != nil
+	return len(rule.IPCIDR) > 0 || rule.IPSet != nil || rule.MappedIPSet != nil
+}
+
+// countHeadlessRules counts the entries of rules like Clash rule providers do,
+// logical rules and rules without list items are counted as one.
+func countHeadlessRules(rules []option.HeadlessRule) int {
+	var count int
+	for _, rule := range rules {
+		switch rule.Type {
+		case C.RuleTypeDefault:
+			count += countDefaultHeadlessRule(rule.DefaultOptions)
+		case C.RuleTypeLogical:
+			count++
+		}
+	}
+	return count
+}
+
+func countDefaultHeadlessRule(rule option.DefaultHeadlessRule) int {
+	count := len(rule.QueryType) + len(rule.Network) + len(rule.Domain) + len(rule.DomainSuffix) + len(rule.DomainKeyword) + len(rule.DomainRegex) +
+		len(rule.SourceIPCIDR) + len(rule.IPCIDR) + len(rule.SourcePort) + len(rule.SourcePortRange) + len(rule.Port) + len(rule.PortRange) +
+		len(rule.ProcessName) + len(rule.ProcessPath) + len(rule.ProcessPathRegex) + len(rule.PackageName) + len(rule.NetworkType) +
+		len(rule.WIFISSID) + len(rule.WIFIBSSID) + len(rule.AdGuardDomain)
+	if rule.DomainMatcher != nil {
+		domainList, prefixList := rule.DomainMatcher.Dump()
+		count += len(domainList) + len(prefixList)
+	}
+	if rule.AdGuardDomainMatcher != nil {
+		count += len(rule.AdGuardDomainMatcher.Dump())
+	}
+	if rule.SourceIPSet != nil {
+		count += len(rule.SourceIPSet.Prefixes())
+	}
+	if rule.IPSet != nil {
+		count += len(rule.IPSet.Prefixes())
+	}
+	if rule.MappedDomainSet != nil {
+		count += rule.MappedDomainSet.Len()
+	}
+	if rule.MappedSourceIPSet != nil {
+		count += rule.MappedSourceIPSet.Len()
+	}
+	if rule.MappedIPSet != nil {
+		count += rule.MappedIPSet.Len()
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+func readClashRuleSet(logger logger.Logger, tag string, content []byte, behavior string) (option.PlainRuleSetCompat, error) {
+	rules, warnings, err := clash.Convert(content, behavior)
+	if err != nil {
+		return option.PlainRuleSetCompat{}, err
+	}
+	for _, warning := range warnings {
+		logger.Warn("rule-set ", tag, ": ", warning)
+	}
+	return option.PlainRuleSetCompat{
+		Version: C.RuleSetVersionCurrent,
+		Options: option.PlainRuleSet{Rules: rules},
+	}, nil
 }
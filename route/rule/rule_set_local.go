@@ -5,10 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sagernet/fswatch"
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/srs"
+	"github.com/sagernet/sing-box/common/srs/mapped"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
@@ -26,22 +28,30 @@ import (
 var _ adapter.RuleSet = (*LocalRuleSet)(nil)
 
 type LocalRuleSet struct {
-	ctx        context.Context
-	logger     logger.Logger
-	tag        string
-	rules      []adapter.HeadlessRule
-	metadata   adapter.RuleSetMetadata
-	fileFormat string
-	watcher    *fswatch.Watcher
-	refs       atomic.Int32
+	ctx          context.Context
+	logger       logger.Logger
+	tag          string
+	ruleSetType  string
+	rules        []adapter.HeadlessRule
+	metadata     adapter.RuleSetMetadata
+	fileFormat   string
+	fileBehavior string
+	filePath     string
+	ruleCount    atomic.TypedValue[int]
+	lastUpdated  atomic.TypedValue[time.Time]
+	watcher      *fswatch.Watcher
+	refs         atomic.Int32
+	cleaned      atomic.Bool
 }
 
 func NewLocalRuleSet(ctx context.Context, logger logger.Logger, options option.RuleSet) (*LocalRuleSet, error) {
 	ruleSet := &LocalRuleSet{
-		ctx:        ctx,
-		logger:     logger,
-		tag:        options.Tag,
-		fileFormat: options.Format,
+		ctx:          ctx,
+		logger:       logger,
+		tag:          options.Tag,
+		ruleSetType:  options.Type,
+		fileFormat:   options.Format,
+		fileBehavior: options.Behavior,
 	}
 	if options.Type == C.RuleSetTypeInline {
 		if len(options.InlineOptions.Rules) == 0 {
@@ -52,7 +62,8 @@ func NewLocalRuleSet(ctx context.Context, logger logger.Logger, options option.R
 			return nil, err
 		}
 	} else {
-		err := ruleSet.reloadFile(filemanager.BasePath(ctx, options.LocalOptions.Path))
+		ruleSet.filePath = filemanager.BasePath(ctx, options.LocalOptions.Path)
+		err := ruleSet.reloadFile(ruleSet.filePath)
 		if err != nil {
 			return nil, err
 		}
@@ -108,11 +119,20 @@ func (s *LocalRuleSet) reloadFile(path string) error {
 		}
 
 	case C.RuleSetFormatBinary:
-		setFile, err := os.Open(path)
+		setFile, err := mapped.Open(s.ctx, path)
 		if err != nil {
 			return err
 		}
-		ruleSet, err = srs.Read(setFile, false)
+		ruleSet, err = srs.ReadFile(setFile, false)
+		if err != nil {
+			return err
+		}
+	case C.RuleSetFormatClash:
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		ruleSet, err = readClashRuleSet(s.logger, s.tag, content, s.fileBehavior)
 		if err != nil {
 			return err
 		}
@@ -141,6 +161,8 @@ func (s *LocalRuleSet) reloadRules(headlessRules []option.HeadlessRule) error {
 	metadata.ContainsIPCIDRRule = hasHeadlessRule(headlessRules, isIPCIDRHeadlessRule)
 	s.rules = rules
 	s.metadata = metadata
+	s.ruleCount.Store(countHeadlessRules(headlessRules))
+	s.lastUpdated.Store(time.Now())
 	return nil
 }
 
@@ -169,9 +191,15 @@ func (s *LocalRuleSet) DecRef() {
 func (s *LocalRuleSet) Cleanup() {
 	if s.refs.Load() == 0 {
 		s.rules = nil
+		s.cleaned.Store(true)
 	}
 }
 
+// Cleaned reports whether the rules have been released by Cleanup.
+func (s *LocalRuleSet) Cleaned() bool {
+	return s.cleaned.Load()
+}
+
 func (s *LocalRuleSet) RegisterCallback(callback adapter.RuleSetUpdateCallback) *list.Element[adapter.RuleSetUpdateCallback] {
 	return nil
 }
@@ -179,6 +207,23 @@ func (s *LocalRuleSet) RegisterCallback(callback adapter.RuleSetUpdateCallback)
 func (s *LocalRuleSet) UnregisterCallback(element *list.Element[adapter.RuleSetUpdateCallback]) {
 }
 
+func (s *LocalRuleSet) Status() adapter.RuleSetStatus {
+	return adapter.RuleSetStatus{
+		Type:        s.ruleSetType,
+		Format:      s.fileFormat,
+		Behavior:    s.fileBehavior,
+		RuleCount:   s.ruleCount.Load(),
+		LastUpdated: s.lastUpdated.Load(),
+	}
+}
+
+func (s *LocalRuleSet) Refresh(ctx context.Context) error {
+	if s.filePath == "" {
+		return nil
+	}
+	return s.reloadFile(s.filePath)
+}
+
 func (s *LocalRuleSet) Close() error {
 	s.rules = nil
 	return common.Close(common.PtrOrNil(s.watcher))
@@ -0,0 +1,324 @@
+package rule
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/common/x/list"
+	"github.com/sagernet/sing/service"
+
+	"go4.org/netipx"
+)
+
+var _ adapter.MutableRuleSet = (*MutableRuleSet)(nil)
+
+type MutableRuleSet struct {
+	ctx            context.Context
+	logger         logger.ContextLogger
+	tag            string
+	cacheFile      adapter.CacheFile
+	access         sync.Mutex
+	content        adapter.MutableRuleSetContent
+	lastUpdated    time.Time
+	compiled       atomic.Pointer[mutableRuleSetRules]
+	callbackAccess sync.Mutex
+	callbacks      list.List[adapter.RuleSetUpdateCallback]
+	refs           atomic.Int32
+}
+
+// mutableRuleSetRules is replaced as a whole on update, so that it can be read without the lock.
+type mutableRuleSetRules struct {
+	rules    []adapter.HeadlessRule
+	metadata adapter.RuleSetMetadata
+}
+
+func NewMutableRuleSet(ctx context.Context, logger logger.ContextLogger, options option.RuleSet) *MutableRuleSet {
+	ruleSet := &MutableRuleSet{
+		ctx:    ctx,
+		logger: logger,
+		tag:    options.Tag,
+	}
+	ruleSet.compiled.Store(&mutableRuleSetRules{})
+	return ruleSet
+}
+
+func (s *MutableRuleSet) Name() string {
+	return s.tag
+}
+
+func (s *MutableRuleSet) String() string {
+	return strings.Join(F.MapToString(s.compiled.Load().rules), " ")
+}
+
+func (s *MutableRuleSet) StartContext(ctx context.Context, startContext *adapter.HTTPStartContext) error {
+	s.cacheFile = service.FromContext[adapter.CacheFile](s.ctx)
+	if s.cacheFile == nil {
+		s.logger.Warn("cache_file is not enabled, changes to mutable rule-set ", s.tag, " will be lost on restart")
+		return nil
+	}
+	savedSet := s.cacheFile.LoadRuleSet(s.tag)
+	if savedSet == nil {
+		return nil
+	}
+	content, err := parseMutableRuleSetContent(savedSet.Content)
+	if err != nil {
+		return E.Cause(err, "restore cached rule-set")
+	}
+	content, err = normalizeMutableRuleSetContent(content)
+	if err != nil {
+		return E.Cause(err, "restore cached rule-set")
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.lastUpdated = savedSet.LastUpdated
+	return s.reloadContent(content)
+}
+
+func (s *MutableRuleSet) PostStart() error {
+	return nil
+}
+
+func (s *MutableRuleSet) Metadata() adapter.RuleSetMetadata {
+	return s.compiled.Load().metadata
+}
+
+func (s *MutableRuleSet) ExtractIPSet() []*netipx.IPSet {
+	return common.FlatMap(s.compiled.Load().rules, extractIPSetFromRule)
+}
+
+func (s *MutableRuleSet) IncRef() {
+	s.refs.Add(1)
+}
+
+func (s *MutableRuleSet) DecRef() {
+	if s.refs.Add(-1) < 0 {
+		panic("rule-set: negative refs")
+	}
+}
+
+func (s *MutableRuleSet) Cleanup() {
+	// rules are kept, since they can still be listed and changed through the API
+}
+
+func (s *MutableRuleSet) RegisterCallback(callback adapter.RuleSetUpdateCallback) *list.Element[adapter.RuleSetUpdateCallback] {
+	s.callbackAccess.Lock()
+	defer s.callbackAccess.Unlock()
+	return s.callbacks.PushBack(callback)
+}
+
+func (s *MutableRuleSet) UnregisterCallback(element *list.Element[adapter.RuleSetUpdateCallback]) {
+	s.callbackAccess.Lock()
+	defer s.callbackAccess.Unlock()
+	s.callbacks.Remove(element)
+}
+
+func (s *MutableRuleSet) Status() adapter.RuleSetStatus {
+	s.access.Lock()
+	defer s.access.Unlock()
+	return adapter.RuleSetStatus{
+		Type:        C.RuleSetTypeMutable,
+		RuleCount:   len(s.content.Domain) + len(s.content.DomainSuffix) + len(s.content.IPCIDR),
+		LastUpdated: s.lastUpdated,
+	}
+}
+
+func (s *MutableRuleSet) Refresh(ctx context.Context) error {
+	return nil
+}
+
+func (s *MutableRuleSet) Close() error {
+	return nil
+}
+
+func (s *MutableRuleSet) Match(metadata *adapter.InboundContext) bool {
+	for _, rule := range s.compiled.Load().rules {
+		if rule.Match(metadata) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MutableRuleSet) Content() adapter.MutableRuleSetContent {
+	s.access.Lock()
+	defer s.access.Unlock()
+	return adapter.MutableRuleSetContent{
+		Domain:       append([]string(nil), s.content.Domain...),
+		DomainSuffix: append([]string(nil), s.content.DomainSuffix...),
+		IPCIDR:       append([]string(nil), s.content.IPCIDR...),
+	}
+}
+
+func (s *MutableRuleSet) Update(add adapter.MutableRuleSetContent, remove adapter.MutableRuleSetContent) error {
+	add, err := normalizeMutableRuleSetContent(add)
+	if err != nil {
+		return err
+	}
+	remove, err = normalizeMutableRuleSetContent(remove)
+	if err != nil {
+		return err
+	}
+	s.access.Lock()
+	content := adapter.MutableRuleSetContent{
+		Domain:       updateEntries(s.content.Domain, add.Domain, remove.Domain),
+		DomainSuffix: updateEntries(s.content.DomainSuffix, add.DomainSuffix, remove.DomainSuffix),
+		IPCIDR:       updateEntries(s.content.IPCIDR, add.IPCIDR, remove.IPCIDR),
+	}
+	err = s.reloadContent(content)
+	if err != nil {
+		s.access.Unlock()
+		return err
+	}
+	s.lastUpdated = time.Now()
+	if s.cacheFile != nil {
+		var contentBytes []byte
+		contentBytes, err = json.Marshal(mutableRuleSetSource(content))
+		if err == nil {
+			err = s.cacheFile.SaveRuleSet(s.tag, &adapter.SavedRuleSet{
+				Content:     contentBytes,
+				LastUpdated: s.lastUpdated,
+			})
+		}
+	}
+	s.access.Unlock()
+	if err != nil {
+		s.logger.Error(E.Cause(err, "save rule-set ", s.tag))
+	}
+	s.logger.Info("updated rule-set ", s.tag)
+	s.callbackAccess.Lock()
+	callbacks := s.callbacks.Array()
+	s.callbackAccess.Unlock()
+	for _, callback := range callbacks {
+		callback(s)
+	}
+	return nil
+}
+
+func (s *MutableRuleSet) reloadContent(content adapter.MutableRuleSetContent) error {
+	plainRuleSet := mutableRuleSetSource(content).Options
+	rules := make([]adapter.HeadlessRule, len(plainRuleSet.Rules))
+	var err error
+	for i, ruleOptions := range plainRuleSet.Rules {
+		rules[i], err = NewHeadlessRule(s.ctx, ruleOptions)
+		if err != nil {
+			return E.Cause(err, "parse rule_set.rules.[", i, "]")
+		}
+	}
+	s.content = content
+	s.compiled.Store(&mutableRuleSetRules{
+		rules: rules,
+		metadata: adapter.RuleSetMetadata{
+			ContainsIPCIDRRule: len(content.IPCIDR) > 0,
+		},
+	})
+	return nil
+}
+
+func mutableRuleSetSource(content adapter.MutableRuleSetContent) option.PlainRuleSetCompat {
+	ruleSet := option.PlainRuleSetCompat{
+		Version: C.RuleSetVersionCurrent,
+	}
+	if len(content.Domain) > 0 || len(content.DomainSuffix) > 0 {
+		ruleSet.Options.Rules = append(ruleSet.Options.Rules, option.HeadlessRule{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultHeadlessRule{
+				Domain:       content.Domain,
+				DomainSuffix: content.DomainSuffix,
+			},
+		})
+	}
+	// kept in a separate rule, since ip_cidr and domain items of the same rule are ANDed
+	if len(content.IPCIDR) > 0 {
+		ruleSet.Options.Rules = append(ruleSet.Options.Rules, option.HeadlessRule{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultHeadlessRule{
+				IPCIDR: content.IPCIDR,
+			},
+		})
+	}
+	return ruleSet
+}
+
+func parseMutableRuleSetContent(contentBytes []byte) (adapter.MutableRuleSetContent, error) {
+	compat, err := json.UnmarshalExtended[option.PlainRuleSetCompat](contentBytes)
+	if err != nil {
+		return adapter.MutableRuleSetContent{}, err
+	}
+	plainRuleSet, err := compat.Upgrade()
+	if err != nil {
+		return adapter.MutableRuleSetContent{}, err
+	}
+	var content adapter.MutableRuleSetContent
+	for _, rule := range plainRuleSet.Rules {
+		if rule.Type != C.RuleTypeDefault {
+			return adapter.MutableRuleSetContent{}, E.New("unexpected rule type: ", rule.Type)
+		}
+		content.Domain = append(content.Domain, rule.DefaultOptions.Domain...)
+		content.DomainSuffix = append(content.DomainSuffix, rule.DefaultOptions.DomainSuffix...)
+		content.IPCIDR = append(content.IPCIDR, rule.DefaultOptions.IPCIDR...)
+	}
+	return content, nil
+}
+
+func normalizeMutableRuleSetContent(content adapter.MutableRuleSetContent) (adapter.MutableRuleSetContent, error) {
+	var normalized adapter.MutableRuleSetContent
+	for _, domain := range content.Domain {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if !M.IsDomainName(domain) {
+			return adapter.MutableRuleSetContent{}, E.New("invalid domain: ", domain)
+		}
+		normalized.Domain = append(normalized.Domain, domain)
+	}
+	for _, domainSuffix := range content.DomainSuffix {
+		domainSuffix = strings.ToLower(strings.TrimSuffix(domainSuffix, "."))
+		if !M.IsDomainName(strings.TrimPrefix(domainSuffix, ".")) {
+			return adapter.MutableRuleSetContent{}, E.New("invalid domain suffix: ", domainSuffix)
+		}
+		normalized.DomainSuffix = append(normalized.DomainSuffix, domainSuffix)
+	}
+	for _, ipCIDR := range content.IPCIDR {
+		prefix, err := netip.ParsePrefix(ipCIDR)
+		if err != nil {
+			address, addrErr := netip.ParseAddr(ipCIDR)
+			if addrErr != nil {
+				return adapter.MutableRuleSetContent{}, E.Cause(err, "invalid ip_cidr: ", ipCIDR)
+			}
+			prefix = netip.PrefixFrom(address, address.BitLen())
+		}
+		normalized.IPCIDR = append(normalized.IPCIDR, prefix.Masked().String())
+	}
+	return normalized, nil
+}
+
+func updateEntries(entries []string, add []string, remove []string) []string {
+	entrySet := make(map[string]bool, len(entries)+len(add))
+	for _, entry := range entries {
+		entrySet[entry] = true
+	}
+	for _, entry := range remove {
+		delete(entrySet, entry)
+	}
+	for _, entry := range add {
+		entrySet[entry] = true
+	}
+	newEntries := make([]string, 0, len(entrySet))
+	for entry := range entrySet {
+		newEntries = append(newEntries, entry)
+	}
+	sort.Strings(newEntries)
+	return newEntries
+}
@@ -0,0 +1,136 @@
+package rule
+
+import (
+	"context"
+	"net/netip"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMutableRuleSet(t *testing.T, ctx context.Context) *MutableRuleSet {
+	ruleSet := NewMutableRuleSet(ctx, logger.NOP(), option.RuleSet{Tag: "test"})
+	require.NoError(t, ruleSet.StartContext(ctx, nil))
+	return ruleSet
+}
+
+func TestMutableRuleSetUpdate(t *testing.T) {
+	t.Parallel()
+	ruleSet := newTestMutableRuleSet(t, context.Background())
+	require.False(t, ruleSet.Match(&adapter.InboundContext{Domain: "example.com"}))
+	require.NoError(t, ruleSet.Update(adapter.MutableRuleSetContent{
+		Domain:       []string{"Example.COM.", "example.com", "www.example.net"},
+		DomainSuffix: []string{".Example.ORG."},
+		IPCIDR:       []string{"10.0.0.1", "192.168.1.5/24", "2001:db8::1/32"},
+	}, adapter.MutableRuleSetContent{}))
+	require.Equal(t, adapter.MutableRuleSetContent{
+		Domain:       []string{"example.com", "www.example.net"},
+		DomainSuffix: []string{".example.org"},
+		IPCIDR:       []string{"10.0.0.1/32", "192.168.1.0/24", "2001:db8::/32"},
+	}, ruleSet.Content())
+	require.True(t, ruleSet.Metadata().ContainsIPCIDRRule)
+	require.True(t, ruleSet.Match(&adapter.InboundContext{Domain: "example.com"}))
+	require.True(t, ruleSet.Match(&adapter.InboundContext{Domain: "www.example.org"}))
+	require.True(t, ruleSet.Match(&adapter.InboundContext{
+		Destination: M.SocksaddrFrom(netip.MustParseAddr("192.168.1.200"), 443),
+	}))
+
+	require.NoError(t, ruleSet.Update(adapter.MutableRuleSetContent{
+		Domain: []string{"www.example.com"},
+	}, adapter.MutableRuleSetContent{
+		Domain:       []string{"EXAMPLE.com."},
+		DomainSuffix: []string{".example.org"},
+		IPCIDR:       []string{"10.0.0.1", "192.168.1.1/24", "2001:db8::/32"},
+	}))
+	require.Equal(t, adapter.MutableRuleSetContent{
+		Domain: []string{"www.example.com", "www.example.net"},
+	}, ruleSet.Content())
+	require.False(t, ruleSet.Metadata().ContainsIPCIDRRule)
+	require.False(t, ruleSet.Match(&adapter.InboundContext{Domain: "example.com"}))
+	require.False(t, ruleSet.Match(&adapter.InboundContext{Domain: "www.example.org"}))
+	require.Equal(t, 2, ruleSet.Status().RuleCount)
+
+	for _, content := range []adapter.MutableRuleSetContent{
+		{Domain: []string{"invalid domain"}},
+		{DomainSuffix: []string{"..example.com"}},
+		{IPCIDR: []string{"10.0.0.256"}},
+	} {
+		require.Error(t, ruleSet.Update(content, adapter.MutableRuleSetContent{}))
+		require.Error(t, ruleSet.Update(adapter.MutableRuleSetContent{}, content))
+	}
+	require.Equal(t, []string{"www.example.com", "www.example.net"}, ruleSet.Content().Domain)
+}
+
+func TestMutableRuleSetCacheFile(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cacheFile := cachefile.New(ctx, option.CacheFileOptions{
+		Path: filepath.Join(t.TempDir(), "cache.db"),
+	})
+	require.NoError(t, cacheFile.Start(adapter.StartStateInitialize))
+	defer cacheFile.Close()
+	ctx = service.ContextWith[adapter.CacheFile](ctx, cacheFile)
+
+	ruleSet := newTestMutableRuleSet(t, ctx)
+	require.NoError(t, ruleSet.Update(adapter.MutableRuleSetContent{
+		Domain: []string{"example.com"},
+		IPCIDR: []string{"10.0.0.0/8"},
+	}, adapter.MutableRuleSetContent{}))
+	lastUpdated := ruleSet.Status().LastUpdated
+
+	restoredRuleSet := newTestMutableRuleSet(t, ctx)
+	require.Equal(t, ruleSet.Content(), restoredRuleSet.Content())
+	require.Equal(t, lastUpdated.Unix(), restoredRuleSet.Status().LastUpdated.Unix())
+	require.True(t, restoredRuleSet.Metadata().ContainsIPCIDRRule)
+	require.True(t, restoredRuleSet.Match(&adapter.InboundContext{Domain: "example.com"}))
+}
+
+func TestMutableRuleSetCallback(t *testing.T) {
+	t.Parallel()
+	ruleSet := newTestMutableRuleSet(t, context.Background())
+	var updated []adapter.RuleSet
+	element := ruleSet.RegisterCallback(func(it adapter.RuleSet) {
+		updated = append(updated, it)
+	})
+	content := adapter.MutableRuleSetContent{Domain: []string{"example.com"}}
+	require.NoError(t, ruleSet.Update(content, adapter.MutableRuleSetContent{}))
+	require.Len(t, updated, 1)
+	require.Same(t, ruleSet, updated[0])
+	require.Error(t, ruleSet.Update(adapter.MutableRuleSetContent{Domain: []string{"invalid domain"}}, adapter.MutableRuleSetContent{}))
+	require.Len(t, updated, 1)
+	ruleSet.UnregisterCallback(element)
+	require.NoError(t, ruleSet.Update(adapter.MutableRuleSetContent{}, content))
+	require.Len(t, updated, 1)
+}
+
+func TestMutableRuleSetConcurrentMatch(t *testing.T) {
+	t.Parallel()
+	ruleSet := newTestMutableRuleSet(t, context.Background())
+	var group sync.WaitGroup
+	group.Add(1)
+	go func() {
+		defer group.Done()
+		for i := 0; i < 100; i++ {
+			ruleSet.Match(&adapter.InboundContext{Domain: "example.com"})
+			ruleSet.Metadata()
+			ruleSet.ExtractIPSet()
+			_ = ruleSet.String()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, ruleSet.Update(adapter.MutableRuleSetContent{
+			Domain: []string{"example.com"},
+			IPCIDR: []string{"10.0.0.0/8"},
+		}, adapter.MutableRuleSetContent{}))
+	}
+	group.Wait()
+}
@@ -1,7 +1,6 @@
 package rule
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
 	"io"
@@ -44,14 +43,17 @@ type RemoteRuleSet struct {
 	updateInterval  time.Duration
 	dialer          N.Dialer
 	rules           []adapter.HeadlessRule
-	lastUpdated     time.Time
+	ruleCount       atomic.TypedValue[int]
+	lastUpdated     atomic.TypedValue[time.Time]
 	lastEtag        string
+	updateAccess    sync.Mutex
 	updateTicker    *time.Ticker
 	cacheFile       adapter.CacheFile
 	pauseManager    pause.Manager
 	callbackAccess  sync.Mutex
 	callbacks       list.List[adapter.RuleSetUpdateCallback]
 	refs            atomic.Int32
+	cleaned         atomic.Bool
 }
 
 func NewRemoteRuleSet(ctx context.Context, logger logger.ContextLogger, options option.RuleSet) *RemoteRuleSet {
@@ -100,11 +102,11 @@ func (s *RemoteRuleSet) StartContext(ctx context.Context, startContext *adapter.
 			if err != nil {
 				return E.Cause(err, "restore cached rule-set")
 			}
-			s.lastUpdated = savedSet.LastUpdated
+			s.lastUpdated.Store(savedSet.LastUpdated)
 			s.lastEtag = savedSet.LastEtag
 		}
 	}
-	if s.lastUpdated.IsZero() {
+	if s.lastUpdated.Load().IsZero() {
 		err := s.fetchOnce(ctx, startContext)
 		if err != nil {
 			return E.Cause(err, "initial rule-set: ", s.options.Tag)
@@ -140,9 +142,15 @@ func (s *RemoteRuleSet) DecRef() {
 func (s *RemoteRuleSet) Cleanup() {
 	if s.refs.Load() == 0 {
 		s.rules = nil
+		s.cleaned.Store(true)
 	}
 }
 
+// Cleaned reports whether the rules have been released by Cleanup.
+func (s *RemoteRuleSet) Cleaned() bool {
+	return s.cleaned.Load()
+}
+
 func (s *RemoteRuleSet) RegisterCallback(callback adapter.RuleSetUpdateCallback) *list.Element[adapter.RuleSetUpdateCallback] {
 	s.callbackAccess.Lock()
 	defer s.callbackAccess.Unlock()
@@ -167,7 +175,12 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 			return err
 		}
 	case C.RuleSetFormatBinary:
-		ruleSet, err = srs.Read(bytes.NewReader(content), false)
+		ruleSet, err = srs.ReadBytes(content, false)
+		if err != nil {
+			return err
+		}
+	case C.RuleSetFormatClash:
+		ruleSet, err = readClashRuleSet(s.logger, s.options.Tag, content, s.options.Behavior)
 		if err != nil {
 			return err
 		}
@@ -188,6 +201,7 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 	s.metadata.ContainsProcessRule = hasHeadlessRule(plainRuleSet.Rules, isProcessHeadlessRule)
 	s.metadata.ContainsWIFIRule = hasHeadlessRule(plainRuleSet.Rules, isWIFIHeadlessRule)
 	s.metadata.ContainsIPCIDRRule = hasHeadlessRule(plainRuleSet.Rules, isIPCIDRHeadlessRule)
+	s.ruleCount.Store(countHeadlessRules(plainRuleSet.Rules))
 	s.rules = rules
 	s.callbackAccess.Lock()
 	callbacks := s.callbacks.Array()
@@ -199,12 +213,10 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 }
 
 func (s *RemoteRuleSet) loopUpdate() {
-	if time.Since(s.lastUpdated) > s.updateInterval {
-		err := s.fetchOnce(s.ctx, nil)
+	if time.Since(s.lastUpdated.Load()) > s.updateInterval {
+		err := s.update(s.ctx)
 		if err != nil {
 			s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
-		} else if s.refs.Load() == 0 {
-			s.rules = nil
 		}
 	}
 	for {
@@ -214,16 +226,27 @@ func (s *RemoteRuleSet) loopUpdate() {
 			return
 		case <-s.updateTicker.C:
 			s.pauseManager.WaitActive()
-			err := s.fetchOnce(s.ctx, nil)
+			err := s.update(s.ctx)
 			if err != nil {
 				s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
-			} else if s.refs.Load() == 0 {
-				s.rules = nil
 			}
 		}
 	}
 }
 
+func (s *RemoteRuleSet) update(ctx context.Context) error {
+	s.updateAccess.Lock()
+	defer s.updateAccess.Unlock()
+	err := s.fetchOnce(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if s.refs.Load() == 0 {
+		s.rules = nil
+	}
+	return nil
+}
+
 func (s *RemoteRuleSet) fetchOnce(ctx context.Context, startContext *adapter.HTTPStartContext) error {
 	s.logger.Debug("updating rule-set ", s.options.Tag, " from URL: ", s.options.RemoteOptions.URL)
 	var httpClient *http.Client
@@ -258,11 +281,12 @@ func (s *RemoteRuleSet) fetchOnce(ctx context.Context, startContext *adapter.HTT
 	switch response.StatusCode {
 	case http.StatusOK:
 	case http.StatusNotModified:
-		s.lastUpdated = time.Now()
+		lastUpdated := time.Now()
+		s.lastUpdated.Store(lastUpdated)
 		if s.cacheFile != nil {
 			savedRuleSet := s.cacheFile.LoadRuleSet(s.options.Tag)
 			if savedRuleSet != nil {
-				savedRuleSet.LastUpdated = s.lastUpdated
+				savedRuleSet.LastUpdated = lastUpdated
 				err = s.cacheFile.SaveRuleSet(s.options.Tag, savedRuleSet)
 				if err != nil {
 					s.logger.Error("save rule-set updated time: ", err)
@@ -290,10 +314,11 @@ func (s *RemoteRuleSet) fetchOnce(ctx context.Context, startContext *adapter.HTT
 	if eTagHeader != "" {
 		s.lastEtag = eTagHeader
 	}
-	s.lastUpdated = time.Now()
+	lastUpdated := time.Now()
+	s.lastUpdated.Store(lastUpdated)
 	if s.cacheFile != nil {
 		err = s.cacheFile.SaveRuleSet(s.options.Tag, &adapter.SavedRuleSet{
-			LastUpdated: s.lastUpdated,
+			LastUpdated: lastUpdated,
 			Content:     content,
 			LastEtag:    s.lastEtag,
 		})
@@ -305,9 +330,28 @@ func (s *RemoteRuleSet) fetchOnce(ctx context.Context, startContext *adapter.HTT
 	return nil
 }
 
+func (s *RemoteRuleSet) Status() adapter.RuleSetStatus {
+	return adapter.RuleSetStatus{
+		Type:        C.RuleSetTypeRemote,
+		Format:      s.options.Format,
+		Behavior:    s.options.Behavior,
+		RuleCount:   s.ruleCount.Load(),
+		LastUpdated: s.lastUpdated.Load(),
+	}
+}
+
+func (s *RemoteRuleSet) Refresh(ctx context.Context) error {
+	if s.updateTicker != nil {
+		s.updateTicker.Reset(s.updateInterval)
+	}
+	return s.update(ctx)
+}
+
 func (s *RemoteRuleSet) Close() error {
 	s.rules = nil
-	s.updateTicker.Stop()
+	if s.updateTicker != nil {
+		s.updateTicker.Stop()
+	}
 	s.cancel()
 	return nil
 }
@@ -38,11 +38,51 @@ func hasDNSRule(rules []option.DNSRule, cond func(rule option.DefaultDNSRule) bo
 }
 
 func isProcessRule(rule option.DefaultRule) bool {
-	return len(rule.ProcessName) > 0 || len(rule.ProcessPath) > 0 || len(rule.ProcessPathRegex) > 0 || len(rule.PackageName) > 0 || len(rule.User) > 0 || len(rule.UserID) > 0
+	return len(rule.ProcessName) > 0 || len(rule.ProcessPath) > 0 || len(rule.ProcessPathRegex) > 0 ||
+		len(rule.ProcessParentName) > 0 || len(rule.ProcessAncestorPath) > 0 || len(rule.ProcessCmdlineRegex) > 0 ||
+		len(rule.ProcessCgroup) > 0 || len(rule.ProcessCgroupRegex) > 0 || len(rule.PackageName) > 0 || len(rule.User) > 0 || len(rule.UserID) > 0
 }
 
 func isProcessDNSRule(rule option.DefaultDNSRule) bool {
-	return len(rule.ProcessName) > 0 || len(rule.ProcessPath) > 0 || len(rule.ProcessPathRegex) > 0 || len(rule.PackageName) > 0 || len(rule.User) > 0 || len(rule.UserID) > 0
+	return len(rule.ProcessName) > 0 || len(rule.ProcessPath) > 0 || len(rule.ProcessPathRegex) > 0 ||
+		len(rule.ProcessParentName) > 0 || len(rule.ProcessAncestorPath) > 0 || len(rule.ProcessCmdlineRegex) > 0 ||
+		len(rule.ProcessCgroup) > 0 || len(rule.ProcessCgroupRegex) > 0 || len(rule.PackageName) > 0 || len(rule.User) > 0 || len(rule.UserID) > 0
+}
+
+func isProcessCmdlineRule(rule option.DefaultRule) bool {
+	return len(rule.ProcessCmdlineRegex) > 0
+}
+
+func isProcessCmdlineDNSRule(rule option.DefaultDNSRule) bool {
+	return len(rule.ProcessCmdlineRegex) > 0
+}
+
+func isProcessAncestorRule(rule option.DefaultRule) bool {
+	return len(rule.ProcessParentName) > 0 || len(rule.ProcessAncestorPath) > 0
+}
+
+func isProcessAncestorDNSRule(rule option.DefaultDNSRule) bool {
+	return len(rule.ProcessParentName) > 0 || len(rule.ProcessAncestorPath) > 0
+}
+
+func isProcessCgroupRule(rule option.DefaultRule) bool {
+	return len(rule.ProcessCgroup) > 0 || len(rule.ProcessCgroupRegex) > 0
+}
+
+func isProcessCgroupDNSRule(rule option.DefaultDNSRule) bool {
+	return len(rule.ProcessCgroup) > 0 || len(rule.ProcessCgroupRegex) > 0
+}
+
+func isNeighborRule(rule option.DefaultRule) bool {
+	return len(rule.SourceMACAddress) > 0
+}
+
+func isASNRule(rule option.DefaultRule) bool {
+	return len(rule.IPASN) > 0 || len(rule.SourceIPASN) > 0
+}
+
+func isASNDNSRule(rule option.DefaultDNSRule) bool {
+	return len(rule.IPASN) > 0 || len(rule.SourceIPASN) > 0
 }
 
 func isWIFIRule(rule option.DefaultRule) bool {
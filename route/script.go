@@ -0,0 +1,157 @@
+package route
+
+import (
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/script"
+	"github.com/sagernet/sing-box/option"
+	R "github.com/sagernet/sing-box/route/rule"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+// scriptRoutedRule reports the outbound selected by a script action as a route action.
+type scriptRoutedRule struct {
+	adapter.Rule
+	action *R.RuleActionRoute
+}
+
+func (r *scriptRoutedRule) Action() adapter.RuleAction {
+	return r.action
+}
+
+func (r *Router) Script() adapter.Script {
+	r.ruleAccess.RLock()
+	defer r.ruleAccess.RUnlock()
+	return r.script
+}
+
+func (r *Router) UpdateScript(content string) error {
+	if r.scriptOptions == nil {
+		return E.New("missing route.script")
+	}
+	newScript, err := r.newScript(content, r.scriptState)
+	if err != nil {
+		return err
+	}
+	// rules can not be replaced while checking them
+	r.reloadAccess.Lock()
+	defer r.reloadAccess.Unlock()
+	for i, rule := range r.Rules() {
+		for _, function := range R.ScriptFunctions(rule) {
+			if !newScript.HasFunction(function) {
+				return E.New("script function not found: ", function, ", required by rule[", i, "]")
+			}
+		}
+	}
+	r.ruleAccess.Lock()
+	r.script = newScript
+	r.ruleAccess.Unlock()
+	r.logger.Info("updated script")
+	return nil
+}
+
+func (r *Router) loadScript(options *option.ScriptOptions) error {
+	content := options.Content
+	if options.Path != "" {
+		if content != "" {
+			return E.New("script: path and content are mutually exclusive")
+		}
+		data, err := os.ReadFile(filemanager.BasePath(r.ctx, options.Path))
+		if err != nil {
+			return E.Cause(err, "read script")
+		}
+		content = string(data)
+	}
+	newScript, err := r.newScript(content, r.scriptState)
+	if err != nil {
+		return err
+	}
+	r.script = newScript
+	return nil
+}
+
+// CompileScript creates a script with a separate state, for testing without affecting the running one.
+func (r *Router) CompileScript(content string) (adapter.Script, error) {
+	return r.newScript(content, script.NewState())
+}
+
+func (r *Router) newScript(content string, state *script.State) (adapter.Script, error) {
+	var name string
+	if r.scriptOptions != nil {
+		name = r.scriptOptions.Path
+	}
+	if name == "" {
+		name = "script"
+	}
+	newScript, err := script.New(script.Options{
+		Logger:       r.logger,
+		Name:         name,
+		Content:      content,
+		State:        state,
+		MatchRuleSet: r.matchScriptRuleSet,
+	})
+	if err != nil {
+		return nil, E.Cause(err, "load script")
+	}
+	return newScript, nil
+}
+
+func (r *Router) newScriptRuleSetItems(router adapter.Router) (map[string]*R.RuleSetItem, error) {
+	if r.scriptOptions == nil {
+		return nil, nil
+	}
+	items := make(map[string]*R.RuleSetItem)
+	for _, tag := range r.scriptOptions.RuleSet {
+		item := R.NewRuleSetItem(router, []string{tag}, false, false)
+		err := item.Start()
+		if err != nil {
+			closeScriptRuleSetItems(items)
+			return nil, E.Cause(err, "script")
+		}
+		items[tag] = item
+	}
+	return items, nil
+}
+
+func closeScriptRuleSetItems(items map[string]*R.RuleSetItem) {
+	for _, item := range items {
+		item.Close()
+	}
+}
+
+func (r *Router) matchScriptRuleSet(tag string, metadata *adapter.InboundContext) (bool, error) {
+	r.ruleAccess.RLock()
+	item, loaded := r.scriptRuleSets[tag]
+	r.ruleAccess.RUnlock()
+	if !loaded {
+		return false, E.New("rule-set not listed in route.script.rule_set: ", tag)
+	}
+	// match on a copy to keep the rule cache of the calling rule
+	matchMetadata := *metadata
+	matchMetadata.ResetRuleCache()
+	return item.Match(&matchMetadata), nil
+}
+
+func (r *Router) actionScript(metadata *adapter.InboundContext, currentRule adapter.Rule, action *R.RuleActionScript) adapter.Rule {
+	currentScript := r.Script()
+	if currentScript == nil {
+		return nil
+	}
+	result, err := currentScript.Call(action.Function, metadata)
+	if err != nil {
+		r.logger.Error(E.Cause(err, "call script function ", action.Function))
+		return nil
+	}
+	switch outbound := result.(type) {
+	case nil:
+		return nil
+	case string:
+		if outbound != "" {
+			return &scriptRoutedRule{currentRule, &R.RuleActionRoute{Outbound: outbound}}
+		}
+	}
+	r.logger.Error("script function ", action.Function, " returned ", result, ", expected outbound tag or None")
+	return nil
+}
@@ -0,0 +1,79 @@
+//go:build with_script
+
+package route_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/include"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+const scriptConfig = `{
+  "log": {
+    "disabled": true
+  },
+  "outbounds": [
+    {
+      "type": "direct",
+      "tag": "direct"
+    }
+  ],
+  "route": {
+    "script": {
+      "content": "def is_ads(metadata):\n  return False\n\ndef main(metadata):\n  return None\n"
+    },
+    "rules": [
+      {
+        "type": "logical",
+        "mode": "or",
+        "rules": [
+          {
+            "script": "is_ads"
+          },
+          {
+            "port": 80
+          }
+        ],
+        "action": "reject"
+      },
+      {
+        "action": "script"
+      }
+    ]
+  }
+}`
+
+func TestUpdateScript(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = box.Context(ctx, include.InboundRegistry(), include.OutboundRegistry(), include.EndpointRegistry(), include.DNSTransportRegistry())
+	options, err := json.UnmarshalExtendedContext[option.Options](ctx, []byte(scriptConfig))
+	require.NoError(t, err)
+	instance, err := box.New(box.Options{Context: ctx, Options: options})
+	require.NoError(t, err)
+	defer instance.Close()
+	require.NoError(t, instance.PreStart())
+	router := instance.Router()
+	require.NoError(t, router.Start(adapter.StartStatePostStart))
+	oldScript := router.Script()
+
+	for _, content := range []string{
+		"def main(metadata):\n  return None\n",
+		"def is_ads(metadata):\n  return True\n",
+	} {
+		require.Error(t, router.UpdateScript(content))
+		require.Equal(t, oldScript, router.Script())
+	}
+
+	require.NoError(t, router.UpdateScript("def is_ads(metadata):\n  return True\n\ndef main(metadata):\n  return \"direct\"\n\ndef other(metadata):\n  return None\n"))
+	require.NotEqual(t, oldScript, router.Script())
+	require.True(t, router.Script().HasFunction("other"))
+}
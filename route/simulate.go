@@ -0,0 +1,159 @@
+package route
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+type SimulateOptions struct {
+	SniffProtocol string
+	SniffDomain   string
+	SniffClient   string
+	SniffALPN     []string
+	SniffECH      bool
+	DNSStubs      map[string][]netip.Addr
+	DisableDNS    bool
+}
+
+type SimulateStep struct {
+	// RuleIndex is -1 for steps applied by legacy inbound options.
+	RuleIndex int
+	Rule      adapter.Rule
+	Details   []string
+}
+
+type SimulateResult struct {
+	Steps             []SimulateStep
+	SelectedRule      adapter.Rule
+	SelectedRuleIndex int
+	Outbound          string
+	Metadata          adapter.InboundContext
+}
+
+type simulationKey struct{}
+
+type routeSimulation struct {
+	options SimulateOptions
+	steps   []SimulateStep
+}
+
+func simulationFromContext(ctx context.Context) *routeSimulation {
+	simulation, _ := ctx.Value(simulationKey{}).(*routeSimulation)
+	return simulation
+}
+
+// Simulate runs the route rules against the given metadata without a real connection.
+// Sniff results are taken from the options, and DNS lookups may be stubbed.
+func (r *Router) Simulate(ctx context.Context, metadata adapter.InboundContext, options SimulateOptions) (*SimulateResult, error) {
+	simulation := &routeSimulation{options: options}
+	ctx = context.WithValue(ctx, simulationKey{}, simulation)
+	selectedRule, selectedRuleIndex, _, _, err := r.matchRule(ctx, &metadata, false, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &SimulateResult{
+		SelectedRule:      selectedRule,
+		SelectedRuleIndex: -1,
+		Metadata:          metadata,
+	}
+	var selectedOutbound adapter.Outbound
+	if selectedRule != nil {
+		result.SelectedRuleIndex = selectedRuleIndex
+		switch action := selectedRule.Action().(type) {
+		case *rule.RuleActionRoute:
+			var loaded bool
+			selectedOutbound, loaded = r.outbound.Outbound(action.Outbound)
+			if !loaded {
+				return nil, E.New("outbound not found: ", action.Outbound)
+			}
+		case *rule.RuleActionReject, *rule.RuleActionHijackDNS:
+			result.Steps = simulation.steps
+			return result, nil
+		}
+	}
+	if selectedOutbound == nil {
+		selectedOutbound = r.outbound.Default()
+	}
+	if !common.Contains(selectedOutbound.Network(), metadata.Network) {
+		return nil, E.New(metadata.Network, " is not supported by outbound: ", selectedOutbound.Tag())
+	}
+	quotaOutbound, _, err := r.checkQuota(metadata, metadata.Network, selectedOutbound)
+	if err != nil {
+		return nil, err
+	}
+	if quotaOutbound != selectedOutbound {
+		simulation.record("quota exhausted, rerouted from ", selectedOutbound.Tag())
+	}
+	result.Steps = simulation.steps
+	result.Outbound = quotaOutbound.Tag()
+	return result, nil
+}
+
+func (s *routeSimulation) match(ruleIndex int, currentRule adapter.Rule) {
+	s.steps = append(s.steps, SimulateStep{
+		RuleIndex: ruleIndex,
+		Rule:      currentRule,
+	})
+}
+
+func (s *routeSimulation) record(details ...any) {
+	if len(s.steps) == 0 {
+		s.steps = append(s.steps, SimulateStep{RuleIndex: -1})
+	}
+	step := &s.steps[len(s.steps)-1]
+	step.Details = append(step.Details, F.ToString(details...))
+}
+
+func (s *routeSimulation) sniff(metadata *adapter.InboundContext, action *rule.RuleActionSniff) {
+	if s.options.SniffProtocol == "" {
+		s.record("sniffed nothing")
+		return
+	}
+	metadata.Protocol = s.options.SniffProtocol
+	metadata.Domain = s.options.SniffDomain
+	metadata.Client = s.options.SniffClient
+	metadata.ALPN = s.options.SniffALPN
+	metadata.ECH = s.options.SniffECH
+	//goland:noinspection GoDeprecation
+	if action.OverrideDestination && M.IsDomainName(metadata.Domain) {
+		metadata.Destination = M.Socksaddr{
+			Fqdn: metadata.Domain,
+			Port: metadata.Destination.Port,
+		}
+		s.record("destination overridden to ", metadata.Destination)
+	}
+	if metadata.Domain != "" {
+		s.record("sniffed protocol: ", metadata.Protocol, ", domain: ", metadata.Domain)
+	} else {
+		s.record("sniffed protocol: ", metadata.Protocol)
+	}
+}
+
+func (s *routeSimulation) lookup(domain string, strategy C.DomainStrategy) ([]netip.Addr, bool, error) {
+	addresses, loaded := s.options.DNSStubs[strings.ToLower(domain)]
+	if !loaded {
+		if s.options.DisableDNS {
+			return nil, true, E.New("no DNS stub for ", domain)
+		}
+		return nil, false, nil
+	}
+	switch strategy {
+	case C.DomainStrategyIPv4Only:
+		addresses = common.Filter(addresses, netip.Addr.Is4)
+	case C.DomainStrategyIPv6Only:
+		addresses = common.Filter(addresses, netip.Addr.Is6)
+	}
+	if len(addresses) == 0 {
+		return nil, true, E.New("no matching stubbed addresses for ", domain)
+	}
+	return addresses, true, nil
+}
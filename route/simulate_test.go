@@ -0,0 +1,135 @@
+package route_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/include"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/route"
+	"github.com/sagernet/sing/common/json"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+const simulateConfig = `{
+  "log": {
+    "disabled": true
+  },
+  "outbounds": [
+    {
+      "type": "direct",
+      "tag": "direct"
+    },
+    {
+      "type": "direct",
+      "tag": "proxy"
+    },
+    {
+      "type": "direct",
+      "tag": "lan"
+    }
+  ],
+  "route": {
+    "rules": [
+      {
+        "action": "sniff"
+      },
+      {
+        "protocol": "tls",
+        "domain_suffix": "example.org",
+        "outbound": "proxy"
+      },
+      {
+        "action": "resolve"
+      },
+      {
+        "ip_cidr": "10.0.0.0/8",
+        "outbound": "lan"
+      }
+    ],
+    "final": "direct"
+  }
+}`
+
+func newSimulateRouter(t *testing.T) *route.Router {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ctx = box.Context(ctx, include.InboundRegistry(), include.OutboundRegistry(), include.EndpointRegistry(), include.DNSTransportRegistry())
+	options, err := json.UnmarshalExtendedContext[option.Options](ctx, []byte(simulateConfig))
+	require.NoError(t, err)
+	instance, err := box.New(box.Options{Context: ctx, Options: options})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		instance.Close()
+	})
+	require.NoError(t, instance.PreStart())
+	require.NoError(t, instance.Router().Start(adapter.StartStatePostStart))
+	return instance.Router().(*route.Router)
+}
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+	router := newSimulateRouter(t)
+	ctx := context.Background()
+
+	t.Run("sniff", func(t *testing.T) {
+		result, err := router.Simulate(ctx, adapter.InboundContext{
+			Network:     N.NetworkTCP,
+			Destination: M.ParseSocksaddrHostPort("1.1.1.1", 443),
+		}, route.SimulateOptions{
+			SniffProtocol: "tls",
+			SniffDomain:   "www.example.org",
+			DisableDNS:    true,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Steps, 2)
+		require.Equal(t, 0, result.Steps[0].RuleIndex)
+		require.Equal(t, []string{"sniffed protocol: tls, domain: www.example.org"}, result.Steps[0].Details)
+		require.Equal(t, 1, result.SelectedRuleIndex)
+		require.Equal(t, "proxy", result.Outbound)
+		require.Equal(t, "www.example.org", result.Metadata.Domain)
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		result, err := router.Simulate(ctx, adapter.InboundContext{
+			Network:     N.NetworkTCP,
+			Destination: M.Socksaddr{Fqdn: "nas.example.com", Port: 443},
+		}, route.SimulateOptions{
+			DNSStubs: map[string][]netip.Addr{
+				"nas.example.com": {netip.MustParseAddr("10.1.2.3")},
+			},
+			DisableDNS: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Steps, 3)
+		require.Equal(t, []string{"sniffed nothing"}, result.Steps[0].Details)
+		require.Equal(t, 2, result.Steps[1].RuleIndex)
+		require.Equal(t, []string{"resolved [10.1.2.3]"}, result.Steps[1].Details)
+		require.Equal(t, 3, result.SelectedRuleIndex)
+		require.Equal(t, "lan", result.Outbound)
+		require.Equal(t, []netip.Addr{netip.MustParseAddr("10.1.2.3")}, result.Metadata.DestinationAddresses)
+	})
+
+	t.Run("final", func(t *testing.T) {
+		result, err := router.Simulate(ctx, adapter.InboundContext{
+			Network:     N.NetworkUDP,
+			Destination: M.Socksaddr{Fqdn: "www.example.com", Port: 443},
+		}, route.SimulateOptions{
+			DNSStubs: map[string][]netip.Addr{
+				"www.example.com": {netip.MustParseAddr("93.184.216.34")},
+			},
+			DisableDNS: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Steps, 2)
+		require.Nil(t, result.SelectedRule)
+		require.Equal(t, -1, result.SelectedRuleIndex)
+		require.Equal(t, "direct", result.Outbound)
+	})
+}